@@ -0,0 +1,163 @@
+// adaptive.go
+//
+// Adaptive box weights. With the adaptive mode on, each player's recent
+// accuracy in every box is compared with a threshold, and a box where the
+// player answers worse than that is drawn more often: its weight grows in
+// proportion to how far accuracy falls short, up to max_factor times the
+// configured weight when nothing is answered correctly. Boxes at or above
+// the threshold keep their weight. Accuracy is taken over the last window
+// answers given in each box; answers recorded before answers carried their
+// box don't count. Due dates keep using the configured weights.
+// scheduler-state shows the numbers behind the adjustment.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+const (
+	defaultAdaptiveWindow    = 30
+	defaultAdaptiveThreshold = 0.8
+	defaultAdaptiveMaxFactor = 2.0
+	// minAdaptiveAnswers is the fewest answers in a box its weight is
+	// adjusted on.
+	minAdaptiveAnswers = 5
+	// adaptiveScale keeps adjusted weights whole numbers without losing
+	// much precision.
+	adaptiveScale = 10
+)
+
+// AdaptiveConfig is the "adaptive" block of the scheduler settings.
+type AdaptiveConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Window is the number of recent answers per box accuracy is taken over.
+	Window int `json:"window,omitempty"`
+	// Threshold is the accuracy below which a box is drawn more often.
+	Threshold float64 `json:"threshold,omitempty"`
+	// MaxFactor is the most a box weight is multiplied by.
+	MaxFactor float64 `json:"max_factor,omitempty"`
+}
+
+// withDefaults fills in the unset settings.
+func (c AdaptiveConfig) withDefaults() AdaptiveConfig {
+	if c.Window <= 0 {
+		c.Window = defaultAdaptiveWindow
+	}
+	if c.Threshold <= 0 {
+		c.Threshold = defaultAdaptiveThreshold
+	}
+	if c.MaxFactor < 1 {
+		c.MaxFactor = defaultAdaptiveMaxFactor
+	}
+	return c
+}
+
+// BoxState is one box in the output of scheduler-state.
+type BoxState struct {
+	Box int `json:"box"`
+	// Weight is the configured weight, Adjusted the one used for player.
+	// With the adaptive mode on both are scaled by adaptiveScale.
+	Weight   int     `json:"weight"`
+	Adjusted int     `json:"adjusted"`
+	Answers  int     `json:"answers"`
+	Accuracy float64 `json:"accuracy"`
+	Factor   float64 `json:"factor"`
+}
+
+// boxStates works out the recent accuracy and weight of every box for
+// player.
+func boxStates(player PlayerData, config SchedulerConfig) []BoxState {
+	weights := config.boxWeights()
+	states := make([]BoxState, topBox)
+	for i := range states {
+		states[i] = BoxState{Box: i + 1, Weight: weights[i], Adjusted: weights[i], Factor: 1}
+	}
+	adaptive := config.Adaptive.withDefaults()
+
+	correct := make([]int, topBox)
+	for i := len(player.History) - 1; i >= 0; i-- {
+		item := player.History[i]
+		if item.Box < 1 || item.Box > topBox || states[item.Box-1].Answers >= adaptive.Window {
+			continue
+		}
+		states[item.Box-1].Answers++
+		if item.Correct {
+			correct[item.Box-1]++
+		}
+	}
+	for i := range states {
+		state := &states[i]
+		if state.Answers > 0 {
+			state.Accuracy = float64(correct[i]) / float64(state.Answers)
+		}
+		if !config.Adaptive.Enabled {
+			continue
+		}
+		state.Weight *= adaptiveScale
+		if state.Answers >= minAdaptiveAnswers && state.Accuracy < adaptive.Threshold {
+			shortfall := (adaptive.Threshold - state.Accuracy) / adaptive.Threshold
+			state.Factor = 1 + (adaptive.MaxFactor-1)*shortfall
+		}
+		state.Adjusted = int(math.Round(float64(state.Weight) * state.Factor))
+	}
+	return states
+}
+
+// weightsFor returns the weights of boxes 1 to 5 for player.
+func (c SchedulerConfig) weightsFor(player PlayerData) []int {
+	if !c.Adaptive.Enabled {
+		return c.boxWeights()
+	}
+	weights := make([]int, topBox)
+	for i, state := range boxStates(player, c) {
+		weights[i] = state.Adjusted
+	}
+	return weights
+}
+
+// --- Command Handlers ---
+
+func handleSchedulerState(playerID string, asJSON bool) {
+	player, ok := loadAllProgress()[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	config := loadConfig().Scheduler
+	states := boxStates(player, config)
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(map[string]interface{}{"adaptive": config.Adaptive.Enabled, "boxes": states}); err != nil {
+			fatalf("Error writing scheduler state JSON: %v", err)
+		}
+		return
+	}
+
+	loc := resolveLocale(player.Locale)
+	if !config.Adaptive.Enabled {
+		fmt.Println(tr("Adaptive weighting is off; boxes are drawn by the configured weights."))
+	} else {
+		adaptive := config.Adaptive.withDefaults()
+		fmt.Printf(tr("Adaptive weighting: boxes below %s accuracy over their last %d answers are drawn up to %s times as often.\n"),
+			loc.Percent(adaptive.Threshold), adaptive.Window, loc.Float(adaptive.MaxFactor, 1))
+	}
+	total, configured := 0, 0
+	for _, state := range states {
+		total += state.Adjusted
+		configured += state.Weight
+	}
+	fmt.Println(tr("\nBox  Answers  Accuracy  Factor  Share (configured)"))
+	for _, state := range states {
+		accuracy := "-"
+		if state.Answers > 0 {
+			accuracy = loc.Percent(state.Accuracy)
+		}
+		fmt.Printf("%3d  %7s  %8s  %6s  %s (%s)\n", state.Box, loc.Number(state.Answers), accuracy, "x"+loc.Float(state.Factor, 2),
+			loc.Percent(float64(state.Adjusted)/float64(total)), loc.Percent(float64(state.Weight)/float64(configured)))
+	}
+	fmt.Println(tr("\nShares are for boxes that all hold cards; empty boxes are never drawn."))
+}
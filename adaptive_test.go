@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// boxHistory returns answers in box, correct of them right.
+func boxHistory(box, answers, correct int) []AnswerLogItem {
+	history := make([]AnswerLogItem, answers)
+	for i := range history {
+		history[i] = AnswerLogItem{CardID: "c", Box: box, Correct: i < correct}
+	}
+	return history
+}
+
+func TestWeightsFor(t *testing.T) {
+	adaptive := AdaptiveConfig{Enabled: true}
+	tests := []struct {
+		name    string
+		config  SchedulerConfig
+		history []AnswerLogItem
+		want    []int
+	}{
+		{"off", SchedulerConfig{}, boxHistory(1, 10, 0), defaultBoxWeights},
+		{"no answers", SchedulerConfig{Adaptive: adaptive}, nil, []int{160, 80, 40, 20, 10}},
+		{"half the threshold", SchedulerConfig{Adaptive: adaptive}, boxHistory(1, 10, 4), []int{240, 80, 40, 20, 10}},
+		{"nothing right", SchedulerConfig{Adaptive: adaptive}, boxHistory(2, 10, 0), []int{160, 160, 40, 20, 10}},
+		{"at the threshold", SchedulerConfig{Adaptive: adaptive}, boxHistory(3, 10, 8), []int{160, 80, 40, 20, 10}},
+		{"too few answers", SchedulerConfig{Adaptive: adaptive}, boxHistory(4, minAdaptiveAnswers-1, 0), []int{160, 80, 40, 20, 10}},
+		{"configured factor", SchedulerConfig{Adaptive: AdaptiveConfig{Enabled: true, MaxFactor: 3}}, boxHistory(5, 10, 0), []int{160, 80, 40, 20, 30}},
+		{
+			name:    "only the window counts",
+			config:  SchedulerConfig{Adaptive: AdaptiveConfig{Enabled: true, Window: 10}},
+			history: append(boxHistory(1, 20, 0), boxHistory(1, 10, 10)...),
+			want:    []int{160, 80, 40, 20, 10},
+		},
+		{
+			name:    "answers without a box",
+			config:  SchedulerConfig{Adaptive: adaptive},
+			history: boxHistory(0, 10, 0),
+			want:    []int{160, 80, 40, 20, 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player := PlayerData{History: tt.history}
+			if got := tt.config.weightsFor(player); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("weightsFor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectCardDrawsWeakBoxesMoreOften(t *testing.T) {
+	player, cards := boxPlayer()
+	player.History = boxHistory(3, 10, 0)
+	count := func(config SchedulerConfig) int {
+		rng := newRand(1)
+		inBox3 := 0
+		for i := 0; i < 10000; i++ {
+			if _, box, _ := selectCard(cards, player, nil, config, testNow, rng); box == 3 {
+				inBox3++
+			}
+		}
+		return inBox3
+	}
+	fixed := count(SchedulerConfig{RecentCards: intPtr(0)})
+	adaptive := count(SchedulerConfig{RecentCards: intPtr(0), Adaptive: AdaptiveConfig{Enabled: true}})
+	if adaptive <= fixed*3/2 {
+		t.Errorf("box 3 drawn %d times with adaptive weights and %d without, want clearly more", adaptive, fixed)
+	}
+}
@@ -0,0 +1,296 @@
+// archive.go
+//
+// Archiving of old answer history and compressed backups of progress.json.
+// Archived segments and backups are written gzip-compressed and read back
+// through a streaming reader, so heavy users don't keep years of history
+// inside the file that every command has to parse.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gzipMagic is the two-byte header every gzip stream starts with.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// --- Command Handlers ---
+
+func handleArchiveHistory(playerID string, olderThan time.Duration) {
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	var archived, kept []AnswerLogItem
+	for _, item := range player.History {
+		if item.Timestamp.Before(cutoff) {
+			archived = append(archived, item)
+		} else {
+			kept = append(kept, item)
+		}
+	}
+	if len(archived) == 0 {
+		fmt.Println(tr("No history entries old enough to archive."))
+		return
+	}
+
+	segmentPath := writeHistorySegment(playerID, archived)
+
+	if kept == nil {
+		kept = make([]AnswerLogItem, 0)
+	}
+	player.History = kept
+	allProgress[playerID] = player
+	saveAllProgress(allProgress)
+	fmt.Printf(tr("Archived %d history entries to %s.\n"), len(archived), segmentPath)
+}
+
+func handleBackup(keep int) {
+	// Answers only in the journal belong in the backup
+	foldJournal()
+	dataDir := getDataDir()
+	source := filepath.Join(dataDir, "progress.json")
+	data, err := ioutil.ReadFile(source)
+	if err != nil {
+		fatalf("Error reading progress file (%s): %v", source, err)
+	}
+
+	backupDir := filepath.Join(dataDir, "backups")
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		fatalf("Error creating backup directory (%s): %v", backupDir, err)
+	}
+	backupPath := filepath.Join(backupDir, "progress-"+time.Now().Format("20060102-150405.000000")+".json.gz")
+	if err := writeCompressed(backupPath, data); err != nil {
+		fatalf("Error writing backup (%s): %v", backupPath, err)
+	}
+	fmt.Printf(tr("Backup written to %s.\n"), backupPath)
+
+	if keep > 0 {
+		pruneBackups(backupDir, keep)
+	}
+}
+
+func handleRestoreBackup(backupPath string) {
+	reader, err := openMaybeCompressed(backupPath)
+	if err != nil {
+		fatalf("Error opening backup (%s): %v", backupPath, err)
+	}
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		fatalf("Error reading backup (%s): %v", backupPath, err)
+	}
+	// Backups may predate the current format
+	progress, _, damage, err := decodeProgress(data)
+	if err != nil {
+		fatalf("Error decoding backup (%s): %v", backupPath, err)
+	}
+	if !damage.empty() {
+		fatalf("Backup %s is damaged; not restoring it.", backupPath)
+	}
+	// Answers journaled since would otherwise be replayed onto the backup
+	discardJournal()
+	saveAllProgress(progress)
+	fmt.Printf(tr("Restored progress for %d player(s) from %s.\n"), len(progress), backupPath)
+}
+
+// --- Archive Helpers ---
+
+// writeHistorySegment stores items as a gzip-compressed JSON-lines segment
+// in the player's archive directory and returns the segment path.
+func writeHistorySegment(playerID string, items []AnswerLogItem) string {
+	archiveDir := filepath.Join(getDataDir(), "archive", playerID)
+	if err := os.MkdirAll(archiveDir, 0755); err != nil {
+		fatalf("Error creating archive directory (%s): %v", archiveDir, err)
+	}
+	segmentPath := filepath.Join(archiveDir, time.Now().Format("20060102-150405.000000")+".jsonl.gz")
+
+	// Written in one go, so a crash can't leave a half segment behind
+	var buf bytes.Buffer
+	gz, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		fatalf("Error creating gzip writer: %v", err)
+	}
+	encoder := json.NewEncoder(gz)
+	for _, item := range items {
+		if err := encoder.Encode(item); err != nil {
+			fatalf("Error writing archive segment (%s): %v", segmentPath, err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		fatalf("Error finishing archive segment (%s): %v", segmentPath, err)
+	}
+	if err := writeFileAtomic(segmentPath, buf.Bytes()); err != nil {
+		fatalf("Error writing archive segment (%s): %v", segmentPath, err)
+	}
+	return segmentPath
+}
+
+// loadArchivedHistory streams every archived segment of a player back into
+// memory, oldest segment first. Players without an archive get nil.
+func loadArchivedHistory(playerID string) []AnswerLogItem {
+	archiveDir := filepath.Join(getDataDir(), "archive", playerID)
+	entries, err := ioutil.ReadDir(archiveDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		fatalf("Error reading archive directory (%s): %v", archiveDir, err)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	var history []AnswerLogItem
+	for _, entry := range entries {
+		// Dot files are leftovers of an interrupted write (see writeFileAtomic)
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		segmentPath := filepath.Join(archiveDir, entry.Name())
+		reader, err := openMaybeCompressed(segmentPath)
+		if err != nil {
+			fatalf("Error opening archive segment (%s): %v", segmentPath, err)
+		}
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			var item AnswerLogItem
+			if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+				fatalf("Error decoding archive segment (%s): %v", segmentPath, err)
+			}
+			history = append(history, item)
+		}
+		if err := scanner.Err(); err != nil {
+			fatalf("Error reading archive segment (%s): %v", segmentPath, err)
+		}
+		reader.Close()
+	}
+	return history
+}
+
+// loadFullHistory returns the archived history followed by the live history
+// kept in progress.json.
+func loadFullHistory(playerID string, player PlayerData) []AnswerLogItem {
+	archived := loadArchivedHistory(playerID)
+	if len(archived) == 0 {
+		return player.History
+	}
+	return append(archived, player.History...)
+}
+
+// --- Compression Helpers ---
+
+// writeCompressed gzips data into the file at path.
+func writeCompressed(path string, data []byte) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewWriterLevel(file, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := gz.Write(data); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// openMaybeCompressed opens path for streaming reads, transparently
+// decompressing it when it starts with a gzip header. Plain files written
+// before compression existed are returned as-is.
+func openMaybeCompressed(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	buffered := bufio.NewReader(file)
+	header, err := buffered.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		file.Close()
+		return nil, err
+	}
+	if len(header) == len(gzipMagic) && header[0] == gzipMagic[0] && header[1] == gzipMagic[1] {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &compressedFile{Reader: gz, file: file}, nil
+	}
+	return &compressedFile{Reader: buffered, file: file}, nil
+}
+
+// compressedFile closes both the decompressor (if any) and the underlying file.
+type compressedFile struct {
+	io.Reader
+	file *os.File
+}
+
+func (c *compressedFile) Close() error {
+	if gz, ok := c.Reader.(*gzip.Reader); ok {
+		gz.Close()
+	}
+	return c.file.Close()
+}
+
+func pruneBackups(backupDir string, keep int) {
+	entries, err := ioutil.ReadDir(backupDir)
+	if err != nil {
+		fatalf("Error reading backup directory (%s): %v", backupDir, err)
+	}
+	var backups []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), "progress-") {
+			backups = append(backups, entry.Name())
+		}
+	}
+	// Timestamped names sort chronologically.
+	sort.Strings(backups)
+	for len(backups) > keep {
+		if err := os.Remove(filepath.Join(backupDir, backups[0])); err != nil {
+			fatalf("Error removing old backup (%s): %v", backups[0], err)
+		}
+		backups = backups[1:]
+	}
+}
+
+// parseAge parses durations such as "90d", "2w" or anything accepted by
+// time.ParseDuration ("36h").
+func parseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	unit := s[len(s)-1]
+	if unit == 'd' || unit == 'w' {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		days := n
+		if unit == 'w' {
+			days = n * 7
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
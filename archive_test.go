@@ -0,0 +1,28 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHistorySegmentRoundTrip(t *testing.T) {
+	dataDirOverride = t.TempDir()
+	defer func() { dataDirOverride = "" }()
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	items := []AnswerLogItem{
+		{CardID: "c1", Timestamp: at, Correct: true, Box: 1},
+		{CardID: "c2", Timestamp: at.Add(time.Minute), Box: 3},
+	}
+	segmentPath := writeHistorySegment("p1", items)
+	// An interrupted write leaves a temporary file next to the segments
+	leftover := filepath.Join(filepath.Dir(segmentPath), "."+filepath.Base(segmentPath)+".tmp-1")
+	if err := ioutil.WriteFile(leftover, []byte("half a segm"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadArchivedHistory("p1"); !reflect.DeepEqual(got, items) {
+		t.Errorf("loadArchivedHistory = %+v, want %+v", got, items)
+	}
+}
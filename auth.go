@@ -0,0 +1,211 @@
+// auth.go
+//
+// API tokens for serve mode. A player token only opens that player's
+// endpoints; an admin token opens everything, including player management.
+// Tokens are shown once when created and only their SHA-256 hashes are kept
+// in tokens.json.
+//
+// As long as no token exists the server stays open, as it always was, so
+// a single-user setup needs no configuration. Once the first token is
+// created every player-scoped and admin endpoint requires one.
+
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tokenPrefix marks decouvertes tokens so they are recognizable in configs.
+const tokenPrefix = "dcv_"
+
+// APIToken is a stored token.
+type APIToken struct {
+	ID        string    `json:"id"`
+	PlayerID  string    `json:"player_id,omitempty"`
+	Admin     bool      `json:"admin,omitempty"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// newTokenSecret returns a fresh token secret from the system's secure
+// random source, along with an ID for it.
+func newTokenSecret() (id, secret string, err error) {
+	bytes := make([]byte, 36)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(bytes[:4]), tokenPrefix + hex.EncodeToString(bytes[4:]), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// findToken returns the stored token matching the given secret.
+func findToken(tokens []APIToken, secret string) (APIToken, bool) {
+	hash := hashToken(secret)
+	for _, token := range tokens {
+		if subtle.ConstantTimeCompare([]byte(token.Hash), []byte(hash)) == 1 {
+			return token, true
+		}
+	}
+	return APIToken{}, false
+}
+
+// requestToken reads the token from an "Authorization: Bearer" header, or
+// from the token query parameter for clients that can't set headers, such
+// as OBS browser sources.
+func requestToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	return r.URL.Query().Get("token")
+}
+
+// authorize checks the request's token. Admin tokens pass everywhere;
+// player tokens only for playerID. An empty playerID means admin only.
+func authorize(w http.ResponseWriter, r *http.Request, playerID string) bool {
+	tokens := loadTokens()
+	if len(tokens) == 0 {
+		return true
+	}
+	token, ok := findToken(tokens, requestToken(r))
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="decouvertes"`)
+		http.Error(w, "A valid API token is required.", http.StatusUnauthorized)
+		return false
+	}
+	if token.Admin || (playerID != "" && token.PlayerID == playerID) {
+		return true
+	}
+	http.Error(w, "This token doesn't give access here.", http.StatusForbidden)
+	return false
+}
+
+// requirePlayer wraps a handler for a player-scoped endpoint. The player
+// comes from the {id} path segment or the player-id query parameter.
+func requirePlayer(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		playerID := r.PathValue("id")
+		if playerID == "" {
+			playerID = r.URL.Query().Get("player-id")
+		}
+		if authorize(w, r, playerID) {
+			handler(w, r)
+		}
+	}
+}
+
+// requireAdmin wraps a handler for an admin endpoint.
+func requireAdmin(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if authorize(w, r, "") {
+			handler(w, r)
+		}
+	}
+}
+
+// --- Command Handlers ---
+
+func handleCreateToken(playerID string, admin bool) {
+	id, secret, err := newTokenSecret()
+	if err != nil {
+		fatalf("Error generating token: %v", err)
+	}
+	token := APIToken{ID: id, Admin: admin, CreatedAt: time.Now()}
+	if !admin {
+		player, ok := loadAllProgress()[playerID]
+		if !ok {
+			fatalf("Player with ID '%s' not found.", playerID)
+		}
+		token.PlayerID = playerID
+		fmt.Printf(tr("Token for %s:\n"), player.Name)
+	} else {
+		fmt.Println(tr("Admin token:"))
+	}
+	token.Hash = hashToken(secret)
+
+	tokens := loadTokens()
+	tokens = append(tokens, token)
+	saveTokens(tokens)
+	fmt.Printf(tr("  %s\n\nIt won't be shown again. Revoke it with 'revoke-token --id=%s'.\n"), secret, token.ID)
+}
+
+func handleListTokens() {
+	tokens := loadTokens()
+	if len(tokens) == 0 {
+		fmt.Println(tr("No tokens; serve mode is open to everyone who can reach it."))
+		return
+	}
+	allProgress := loadAllProgress()
+	for _, token := range tokens {
+		scope := tr("admin")
+		if !token.Admin {
+			scope = fmt.Sprintf(tr("player %s"), playerLabel(token.PlayerID, allProgress))
+		}
+		fmt.Printf(tr("%s  %s  created %s\n"), token.ID, scope, token.CreatedAt.Format("2006-01-02"))
+	}
+}
+
+func handleRevokeToken(id string) {
+	tokens := loadTokens()
+	kept := tokens[:0]
+	for _, token := range tokens {
+		if token.ID != id {
+			kept = append(kept, token)
+		}
+	}
+	if len(kept) == len(tokens) {
+		fatalf("Token '%s' not found.", id)
+	}
+	saveTokens(kept)
+	fmt.Printf(tr("Token %s revoked.\n"), id)
+	if len(kept) == 0 {
+		fmt.Println(tr("That was the last token; serve mode is open again."))
+	}
+}
+
+// --- Helpers ---
+
+func loadTokens() []APIToken {
+	filePath := filepath.Join(getDataDir(), "tokens.json")
+	file, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		fatalf("Error reading tokens (%s): %v", filePath, err)
+	}
+	var tokens []APIToken
+	if len(file) == 0 {
+		return tokens
+	}
+	if err := json.Unmarshal(file, &tokens); err != nil {
+		fatalf("Error unmarshalling tokens JSON: %v", err)
+	}
+	return tokens
+}
+
+func saveTokens(tokens []APIToken) {
+	filePath := filepath.Join(getDataDir(), "tokens.json")
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		fatalf("Error marshalling tokens to JSON: %v", err)
+	}
+	// Only hashes are stored, but there is no reason for others to read them
+	if err := ioutil.WriteFile(filePath, data, 0600); err != nil {
+		fatalf("Error writing tokens (%s): %v", filePath, err)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewTokenSecret(t *testing.T) {
+	id, secret, err := newTokenSecret()
+	if err != nil {
+		t.Fatalf("newTokenSecret: %v", err)
+	}
+	if len(id) != 8 {
+		t.Errorf("id %q has %d characters, want 8", id, len(id))
+	}
+	if !strings.HasPrefix(secret, tokenPrefix) || len(secret) != len(tokenPrefix)+64 {
+		t.Errorf("secret %q is not %s followed by 64 hex digits", secret, tokenPrefix)
+	}
+	_, other, err := newTokenSecret()
+	if err != nil {
+		t.Fatalf("newTokenSecret: %v", err)
+	}
+	if other == secret {
+		t.Errorf("two calls returned the same secret %q", secret)
+	}
+}
+
+func TestFindToken(t *testing.T) {
+	tokens := []APIToken{
+		{ID: "p1", PlayerID: "alice", Hash: hashToken("dcv_alice")},
+		{ID: "a1", Admin: true, Hash: hashToken("dcv_admin")},
+	}
+	tests := []struct {
+		name   string
+		secret string
+		wantID string
+		wantOK bool
+	}{
+		{"player token", "dcv_alice", "p1", true},
+		{"admin token", "dcv_admin", "a1", true},
+		{"unknown token", "dcv_bob", "", false},
+		{"empty token", "", "", false},
+		{"hash instead of secret", hashToken("dcv_alice"), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, ok := findToken(tokens, tt.secret)
+			if ok != tt.wantOK || token.ID != tt.wantID {
+				t.Errorf("findToken(%q) = %q, %v; want %q, %v", tt.secret, token.ID, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRequestToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		header string
+		want   string
+	}{
+		{"bearer header", "/api/card", "Bearer dcv_abc", "dcv_abc"},
+		{"query parameter", "/api/card?token=dcv_abc", "", "dcv_abc"},
+		{"header wins over query", "/api/card?token=dcv_query", "Bearer dcv_header", "dcv_header"},
+		{"other scheme", "/api/card", "Basic dcv_abc", ""},
+		{"no token", "/api/card", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.url, nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			if got := requestToken(r); got != tt.want {
+				t.Errorf("requestToken = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
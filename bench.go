@@ -0,0 +1,228 @@
+// bench.go
+//
+// The bench command times the operations every answer goes through on a
+// made-up dataset, so changes to the scheduler or to how progress is
+// stored can be measured instead of guessed:
+//
+//   - pick: introducing new cards and drawing one, as get-card does;
+//   - journal: grading an answer and appending it to the journal (see
+//     journal.go);
+//   - save: writing progress.json;
+//   - load: reading progress.json back, journal included.
+//
+// The dataset is a deck of synthetic cards, every one of them in a box, and
+// a history of answers spread over the past year, written to a temporary
+// directory that is removed afterwards; the real progress is never touched.
+// The scheduler and its settings come from config.json, plugins included.
+// Combine it with the global --cpuprofile and --memprofile flags to see
+// where the time goes.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Bench describes a benchmark run.
+type Bench struct {
+	Cards   int
+	History int
+	Players int
+	Picks   int
+	Saves   int
+	Seed    int64
+}
+
+// BenchResult is the timing of one operation.
+type BenchResult struct {
+	Name   string  `json:"name"`
+	Runs   int     `json:"runs"`
+	MeanMS float64 `json:"mean_ms"`
+	// MedianMS, P95MS and MaxMS are the median, 95th percentile and
+	// slowest run.
+	MedianMS float64 `json:"median_ms"`
+	P95MS    float64 `json:"p95_ms"`
+	MaxMS    float64 `json:"max_ms"`
+}
+
+// BenchReport is the output of the bench command.
+type BenchReport struct {
+	Cards   int   `json:"cards"`
+	History int   `json:"history"`
+	Players int   `json:"players"`
+	Seed    int64 `json:"seed"`
+	// ProgressBytes is the size of the progress.json written.
+	ProgressBytes int64         `json:"progress_bytes"`
+	Results       []BenchResult `json:"results"`
+}
+
+// runBench builds the dataset and times each operation on it. Progress is
+// written to the data directory, which the caller points elsewhere.
+func runBench(bench Bench, scheduler SchedulerConfig) BenchReport {
+	rng := newRand(bench.Seed)
+	next, err := activeScheduler(scheduler)
+	if err != nil {
+		fatalf("Error loading the scheduler: %v", err)
+	}
+	cards := syntheticCards(bench.Cards)
+	now := time.Now()
+	player := benchPlayer(cards, bench.History, now, bench.Seed)
+
+	report := BenchReport{Cards: bench.Cards, History: bench.History, Players: bench.Players, Seed: bench.Seed}
+	picks := make([]time.Duration, 0, bench.Picks)
+	for i := 0; i < bench.Picks; i++ {
+		start := time.Now()
+		pick, ok, err := next.NextCard(player, cards, false, now, rng)
+		picks = append(picks, time.Since(start))
+		if err != nil {
+			fatalf("Scheduler '%s' failed: %v", scheduler.Plugin, err)
+		}
+		if !ok {
+			break
+		}
+		player.RecentCards = rememberPick(player.RecentCards, pick.Card.ID, scheduler.historyLimit())
+	}
+	report.Results = append(report.Results, benchResult("pick", picks))
+
+	// The other players are copies of the first, there for their size
+	progress := make(map[string]PlayerData, bench.Players)
+	for i := 1; i <= bench.Players; i++ {
+		progress[fmt.Sprintf("bench-%d", i)] = player
+	}
+
+	// One journal's worth of answers, then it is thrown away
+	journal := make([]time.Duration, 0, journalFoldAnswers)
+	for i := 0; i < journalFoldAnswers; i++ {
+		card := cards[rng.Intn(len(cards))]
+		correct := rng.Intn(2) == 0
+		start := time.Now()
+		progress, err := next.OnAnswer(player, card, correct, now)
+		if err != nil {
+			fatalf("Scheduler '%s' failed: %v", scheduler.Plugin, err)
+		}
+		appendJournal(JournalEntry{
+			PlayerID: "bench-1",
+			Answer:   AnswerLogItem{CardID: card.ID, Timestamp: now.Add(time.Duration(i) * time.Second), Correct: correct, Box: 1},
+			Card:     progress,
+			Logged:   now,
+		})
+		journal = append(journal, time.Since(start))
+	}
+	discardJournal()
+	report.Results = append(report.Results, benchResult("journal", journal))
+
+	saves := make([]time.Duration, 0, bench.Saves)
+	loads := make([]time.Duration, 0, bench.Saves)
+	for i := 0; i < bench.Saves; i++ {
+		start := time.Now()
+		writeProgressFile(progress)
+		saves = append(saves, time.Since(start))
+
+		start = time.Now()
+		loaded := readProgressFile()
+		loads = append(loads, time.Since(start))
+		if len(loaded) != len(progress) {
+			fatalf("The benchmark read back %d player(s) instead of %d.", len(loaded), len(progress))
+		}
+	}
+	report.Results = append(report.Results, benchResult("save", saves), benchResult("load", loads))
+	report.ProgressBytes = statFile(progressPath()).Size
+	return report
+}
+
+// --- Command Handlers ---
+
+func handleBench(bench Bench, asJSON bool) {
+	// Read before progress moves to the temporary directory
+	scheduler := loadConfig().Scheduler
+
+	dataDir, err := os.MkdirTemp("", "decouvertes-bench-")
+	if err != nil {
+		fatalf("Error creating benchmark directory: %v", err)
+	}
+	defer os.RemoveAll(dataDir)
+	previous := dataDirOverride
+	dataDirOverride = dataDir
+	report := runBench(bench, scheduler)
+	dataDirOverride = previous
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fatalf("Error writing benchmark JSON: %v", err)
+		}
+		return
+	}
+	fmt.Printf(tr("%d card(s), %d history entries, %d player(s), seed %d; progress.json is %.1f MB.\n\n"),
+		report.Cards, report.History, report.Players, report.Seed, float64(report.ProgressBytes)/(1<<20))
+	fmt.Printf("%-8s %6s %10s %10s %10s %10s\n", "", "Runs", "Mean", "Median", "95th", "Max")
+	for _, result := range report.Results {
+		fmt.Printf("%-8s %6d %10s %10s %10s %10s\n", result.Name, result.Runs,
+			formatMS(result.MeanMS), formatMS(result.MedianMS), formatMS(result.P95MS), formatMS(result.MaxMS))
+	}
+}
+
+// --- Helpers ---
+
+// benchPlayer makes a player who has met every card and answered history
+// times over the past year.
+func benchPlayer(cards []Card, history int, now time.Time, seed int64) PlayerData {
+	rng := newRand(seed)
+	player := PlayerData{Name: "bench", Cards: make(map[string]CardProgress, len(cards))}
+	for _, card := range cards {
+		// Most cards sit in the lower boxes
+		box := 1
+		for box < topBox && rng.Intn(2) == 0 {
+			box++
+		}
+		player.Cards[card.ID] = CardProgress{
+			Box:          box,
+			Streak:       rng.Intn(box),
+			Passed:       box - 1 + rng.Intn(3),
+			Failed:       rng.Intn(3),
+			LastReviewed: now.Add(-time.Duration(rng.Int63n(int64(60 * 24 * time.Hour)))),
+		}
+	}
+	player.History = make([]AnswerLogItem, history)
+	for i := range player.History {
+		player.History[i] = AnswerLogItem{
+			CardID:    cards[rng.Intn(len(cards))].ID,
+			Timestamp: now.Add(-time.Duration(history-i) * 365 * 24 * time.Hour / time.Duration(history)),
+			Correct:   rng.Intn(4) != 0,
+			Box:       1 + rng.Intn(topBox),
+		}
+	}
+	player.TotalAnswered = history
+	return player
+}
+
+func benchResult(name string, runs []time.Duration) BenchResult {
+	result := BenchResult{Name: name, Runs: len(runs)}
+	if len(runs) == 0 {
+		return result
+	}
+	sorted := append([]time.Duration(nil), runs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total time.Duration
+	for _, run := range sorted {
+		total += run
+	}
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	result.MeanMS = ms(total / time.Duration(len(sorted)))
+	result.MedianMS = ms(sorted[len(sorted)/2])
+	result.P95MS = ms(sorted[(len(sorted)*95+99)/100-1])
+	result.MaxMS = ms(sorted[len(sorted)-1])
+	return result
+}
+
+func formatMS(ms float64) string {
+	if ms < 1 {
+		return fmt.Sprintf("%.0f µs", ms*1000)
+	}
+	return fmt.Sprintf("%.1f ms", ms)
+}
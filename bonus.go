@@ -0,0 +1,403 @@
+// bonus.go
+//
+// The bonus game: a reward for a full week of daily practice. It builds a
+// small crossword, or a word-association puzzle when the solutions don't
+// cross well, from the player's mastered cards, so that old material comes
+// back once in a while. Puzzles are exported as plain text or a standalone
+// HTML page for printing.
+
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// bonusStreakDays is the daily streak that unlocks the bonus game.
+const bonusStreakDays = 7
+
+// Crossword entries are limited to single words of this length.
+const (
+	minCrosswordWord = 3
+	maxCrosswordWord = 12
+)
+
+// CrosswordEntry is a word placed in the grid.
+type CrosswordEntry struct {
+	Number int
+	Across bool
+	Row    int
+	Col    int
+	Word   []rune
+	Clue   string
+}
+
+// Crossword is a generated puzzle. Cells holds the letters, with 0 for
+// blocked cells.
+type Crossword struct {
+	Cells   [][]rune
+	Numbers map[[2]int]int
+	Entries []CrosswordEntry
+}
+
+// AssociationPuzzle asks to match prompts with shuffled solutions.
+type AssociationPuzzle struct {
+	Prompts   []string
+	Solutions []string
+	// Key maps each prompt index to the index of its solution.
+	Key []int
+}
+
+// --- Command Handlers ---
+
+func handleBonus(playerID, game, format, outPath string) {
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	if current, _ := dailyStreaks(loadFullHistory(playerID, player), time.Now()); current < bonusStreakDays {
+		fatalf("The bonus game unlocks after a %d-day streak (current streak: %d).", bonusStreakDays, current)
+	}
+
+	var mastered []Card
+	for _, card := range loadCards() {
+		if player.Cards[card.ID].Retired {
+			mastered = append(mastered, card)
+		}
+	}
+	if len(mastered) < 2 {
+		fatal("Master at least two cards to unlock the bonus game.")
+	}
+
+	out := io.Writer(os.Stdout)
+	if outPath != "" {
+		file, err := os.Create(outPath)
+		if err != nil {
+			fatalf("Error creating bonus file (%s): %v", outPath, err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	if game == "crossword" {
+		if puzzle, ok := buildCrossword(mastered, rng); ok {
+			writeCrossword(out, puzzle, format)
+			return
+		}
+		fmt.Fprintln(os.Stderr, "Too few mastered single-word solutions cross; making a word-association puzzle instead.")
+	}
+	writeAssociation(out, buildAssociation(mastered, rng), format)
+}
+
+// --- Crossword ---
+
+// crosswordWord returns the solution as upper-case letters if it is a
+// single word that fits in a crossword.
+func crosswordWord(solution string) ([]rune, bool) {
+	word := []rune(strings.ToUpper(strings.TrimSpace(solution)))
+	if len(word) < minCrosswordWord || len(word) > maxCrosswordWord {
+		return nil, false
+	}
+	for _, r := range word {
+		if !unicode.IsLetter(r) {
+			return nil, false
+		}
+	}
+	return word, true
+}
+
+// buildCrossword places as many words as it can, longest first, each
+// crossing an already placed word. It fails if fewer than three fit.
+func buildCrossword(cards []Card, rng *rand.Rand) (Crossword, bool) {
+	var candidates []CrosswordEntry
+	seen := make(map[string]bool)
+	for _, card := range cards {
+		word, ok := crosswordWord(card.Solution)
+		if !ok || seen[string(word)] {
+			continue
+		}
+		seen[string(word)] = true
+		candidates = append(candidates, CrosswordEntry{Word: word, Clue: card.Prompt})
+	}
+	rng.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	sort.SliceStable(candidates, func(i, j int) bool { return len(candidates[i].Word) > len(candidates[j].Word) })
+	if len(candidates) < 3 {
+		return Crossword{}, false
+	}
+
+	grid := make(map[[2]int]rune)
+	var placed []CrosswordEntry
+	place := func(entry CrosswordEntry) {
+		for i, r := range entry.Word {
+			grid[entryCell(entry, i)] = r
+		}
+		placed = append(placed, entry)
+	}
+
+	first := candidates[0]
+	first.Across = true
+	place(first)
+	for _, candidate := range candidates[1:] {
+		if entry, ok := findCrossing(grid, placed, candidate); ok {
+			place(entry)
+		}
+	}
+	if len(placed) < 3 {
+		return Crossword{}, false
+	}
+	return layoutCrossword(grid, placed), true
+}
+
+func entryCell(entry CrosswordEntry, i int) [2]int {
+	if entry.Across {
+		return [2]int{entry.Row, entry.Col + i}
+	}
+	return [2]int{entry.Row + i, entry.Col}
+}
+
+// findCrossing looks for a spot where candidate crosses a placed word
+// without touching any other letter.
+func findCrossing(grid map[[2]int]rune, placed []CrosswordEntry, candidate CrosswordEntry) (CrosswordEntry, bool) {
+	for _, other := range placed {
+		for i, r := range other.Word {
+			for j, c := range candidate.Word {
+				if r != c {
+					continue
+				}
+				cell := entryCell(other, i)
+				entry := candidate
+				entry.Across = !other.Across
+				if entry.Across {
+					entry.Row, entry.Col = cell[0], cell[1]-j
+				} else {
+					entry.Row, entry.Col = cell[0]-j, cell[1]
+				}
+				if fitsCrossword(grid, entry) {
+					return entry, true
+				}
+			}
+		}
+	}
+	return candidate, false
+}
+
+func fitsCrossword(grid map[[2]int]rune, entry CrosswordEntry) bool {
+	// The cells just before and after the word must stay empty
+	if _, ok := grid[entryCell(entry, -1)]; ok {
+		return false
+	}
+	if _, ok := grid[entryCell(entry, len(entry.Word))]; ok {
+		return false
+	}
+	for i, r := range entry.Word {
+		cell := entryCell(entry, i)
+		if existing, ok := grid[cell]; ok {
+			if existing != r {
+				return false
+			}
+			continue
+		}
+		// A new letter must not sit next to a parallel word
+		var sides [2][2]int
+		if entry.Across {
+			sides = [2][2]int{{cell[0] - 1, cell[1]}, {cell[0] + 1, cell[1]}}
+		} else {
+			sides = [2][2]int{{cell[0], cell[1] - 1}, {cell[0], cell[1] + 1}}
+		}
+		for _, side := range sides {
+			if _, ok := grid[side]; ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// layoutCrossword moves the grid to start at (0, 0) and numbers the entries
+// in reading order.
+func layoutCrossword(grid map[[2]int]rune, placed []CrosswordEntry) Crossword {
+	minRow, minCol, maxRow, maxCol := 1<<30, 1<<30, -1<<30, -1<<30
+	for cell := range grid {
+		minRow, maxRow = min(minRow, cell[0]), max(maxRow, cell[0])
+		minCol, maxCol = min(minCol, cell[1]), max(maxCol, cell[1])
+	}
+	puzzle := Crossword{Numbers: make(map[[2]int]int)}
+	puzzle.Cells = make([][]rune, maxRow-minRow+1)
+	for row := range puzzle.Cells {
+		puzzle.Cells[row] = make([]rune, maxCol-minCol+1)
+	}
+	for cell, r := range grid {
+		puzzle.Cells[cell[0]-minRow][cell[1]-minCol] = r
+	}
+
+	for i := range placed {
+		placed[i].Row -= minRow
+		placed[i].Col -= minCol
+	}
+	sort.Slice(placed, func(i, j int) bool {
+		if placed[i].Row != placed[j].Row {
+			return placed[i].Row < placed[j].Row
+		}
+		return placed[i].Col < placed[j].Col
+	})
+	for i := range placed {
+		start := [2]int{placed[i].Row, placed[i].Col}
+		number, ok := puzzle.Numbers[start]
+		if !ok {
+			number = len(puzzle.Numbers) + 1
+			puzzle.Numbers[start] = number
+		}
+		placed[i].Number = number
+	}
+	puzzle.Entries = placed
+	return puzzle
+}
+
+// --- Word Association ---
+
+// maxAssociationPairs keeps the association puzzle to one screen.
+const maxAssociationPairs = 8
+
+func buildAssociation(cards []Card, rng *rand.Rand) AssociationPuzzle {
+	shuffled := append([]Card(nil), cards...)
+	rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	if len(shuffled) > maxAssociationPairs {
+		shuffled = shuffled[:maxAssociationPairs]
+	}
+
+	order := rng.Perm(len(shuffled))
+	puzzle := AssociationPuzzle{
+		Prompts:   make([]string, len(shuffled)),
+		Solutions: make([]string, len(shuffled)),
+		Key:       make([]int, len(shuffled)),
+	}
+	for i, card := range shuffled {
+		puzzle.Prompts[i] = card.Prompt
+		puzzle.Solutions[order[i]] = card.Solution
+		puzzle.Key[i] = order[i]
+	}
+	return puzzle
+}
+
+// --- Output ---
+
+func writeCrossword(out io.Writer, puzzle Crossword, format string) {
+	if format == "html" {
+		renderBonusHTML(out, crosswordTemplate, puzzle)
+		return
+	}
+	fmt.Fprintln(out, "BONUS CROSSWORD")
+	fmt.Fprintln(out)
+	for row, cells := range puzzle.Cells {
+		var line strings.Builder
+		for col, r := range cells {
+			switch {
+			case r == 0:
+				line.WriteString("    ")
+			case puzzle.Numbers[[2]int{row, col}] > 0:
+				fmt.Fprintf(&line, "%2d_ ", puzzle.Numbers[[2]int{row, col}])
+			default:
+				line.WriteString("  _ ")
+			}
+		}
+		fmt.Fprintln(out, strings.TrimRight(line.String(), " "))
+	}
+	for _, across := range []bool{true, false} {
+		fmt.Fprintln(out)
+		if across {
+			fmt.Fprintln(out, "Across")
+		} else {
+			fmt.Fprintln(out, "Down")
+		}
+		for _, entry := range puzzle.Entries {
+			if entry.Across == across {
+				fmt.Fprintf(out, "%3d. %s (%d)\n", entry.Number, entry.Clue, len(entry.Word))
+			}
+		}
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Solutions")
+	for _, entry := range puzzle.Entries {
+		direction := "down"
+		if entry.Across {
+			direction = "across"
+		}
+		fmt.Fprintf(out, "%3d %s: %s\n", entry.Number, direction, string(entry.Word))
+	}
+}
+
+func writeAssociation(out io.Writer, puzzle AssociationPuzzle, format string) {
+	if format == "html" {
+		renderBonusHTML(out, associationTemplate, puzzle)
+		return
+	}
+	fmt.Fprintln(out, "BONUS: MATCH EACH PROMPT WITH ITS ANSWER")
+	fmt.Fprintln(out)
+	for i, prompt := range puzzle.Prompts {
+		fmt.Fprintf(out, "%2d. %s\n", i+1, prompt)
+	}
+	fmt.Fprintln(out)
+	for i, solution := range puzzle.Solutions {
+		fmt.Fprintf(out, " %c. %s\n", 'A'+i, solution)
+	}
+	fmt.Fprintln(out)
+	var key []string
+	for i, j := range puzzle.Key {
+		key = append(key, fmt.Sprintf("%d-%c", i+1, 'A'+j))
+	}
+	fmt.Fprintf(out, "Solutions: %s\n", strings.Join(key, " "))
+}
+
+func renderBonusHTML(out io.Writer, tmpl *template.Template, data interface{}) {
+	if err := tmpl.Execute(out, data); err != nil {
+		fatalf("Error writing bonus game: %v", err)
+	}
+}
+
+var bonusFuncs = template.FuncMap{
+	"letter": func(i int) string { return string(rune('A' + i)) },
+	"inc":    func(i int) int { return i + 1 },
+	"str":    func(word []rune) string { return string(word) },
+	"number": func(numbers map[[2]int]int, row, col int) int { return numbers[[2]int{row, col}] },
+}
+
+const bonusStyle = `<style>
+body { font-family: sans-serif; max-width: 50em; margin: 2em auto; }
+table.grid { border-collapse: collapse; }
+table.grid td { width: 2em; height: 2em; vertical-align: top; font-size: 0.7em; }
+table.grid td.cell { border: 1px solid #333; }
+details { margin-top: 2em; }
+</style>`
+
+var crosswordTemplate = template.Must(template.New("crossword").Funcs(bonusFuncs).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Bonus Crossword</title>` + bonusStyle + `</head><body>
+<h1>Bonus Crossword</h1>
+<table class="grid">
+{{- $numbers := .Numbers}}
+{{- range $row, $cells := .Cells}}
+<tr>{{range $col, $r := $cells}}{{if $r}}<td class="cell">{{with number $numbers $row $col}}{{.}}{{end}}</td>{{else}}<td></td>{{end}}{{end}}</tr>
+{{- end}}
+</table>
+<h2>Across</h2><ol>{{range .Entries}}{{if .Across}}<li value="{{.Number}}">{{.Clue}} ({{len .Word}})</li>{{end}}{{end}}</ol>
+<h2>Down</h2><ol>{{range .Entries}}{{if not .Across}}<li value="{{.Number}}">{{.Clue}} ({{len .Word}})</li>{{end}}{{end}}</ol>
+<details><summary>Solutions</summary><ul>{{range .Entries}}<li>{{.Number}} {{if .Across}}across{{else}}down{{end}}: {{str .Word}}</li>{{end}}</ul></details>
+</body></html>
+`))
+
+var associationTemplate = template.Must(template.New("association").Funcs(bonusFuncs).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Bonus: Match the Answers</title>` + bonusStyle + `</head><body>
+<h1>Match each prompt with its answer</h1>
+<ol>{{range .Prompts}}<li>{{.}}</li>{{end}}</ol>
+<ol type="A">{{range .Solutions}}<li><code>{{.}}</code></li>{{end}}</ol>
+<details><summary>Solutions</summary><p>{{range $i, $j := .Key}}{{inc $i}}-{{letter $j}} {{end}}</p></details>
+</body></html>
+`))
@@ -0,0 +1,257 @@
+// boost.go
+//
+// Temporary boosts, for steering the scheduler for a while, such as before
+// a test on one chapter. A boost multiplies the chance of drawing a card, or
+// every card with a tag, relative to the other cards of its box: a factor
+// of 3 draws it three times as often, and deprioritize-card divides by the
+// factor instead. Boosts that apply to the same card multiply. A boost ends
+// after its number of days, or once the cards it covers have been answered
+// its number of times, whichever comes first; practice answers don't count.
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultBoostFactor is the factor boost-card and deprioritize-card apply
+// unless --factor says otherwise.
+const defaultBoostFactor = 3
+
+// Boost is a temporary change to the chance of drawing a card or the cards
+// with a tag.
+type Boost struct {
+	CardID string `json:"card_id,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+	// Factor multiplies the card's weight; below 1 for deprioritized cards.
+	Factor float64 `json:"factor"`
+	// ReviewsLeft is the number of answers after which the boost ends; 0
+	// means no limit.
+	ReviewsLeft int `json:"reviews_left,omitempty"`
+	// Until is when the boost ends; nil means no limit.
+	Until     *time.Time `json:"until,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// applies reports whether the boost covers card.
+func (b Boost) applies(card Card) bool {
+	if b.CardID != "" {
+		return card.ID == b.CardID
+	}
+	for _, tag := range card.Tags {
+		if tag == b.Tag {
+			return true
+		}
+	}
+	return false
+}
+
+// active reports whether the boost still holds at now.
+func (b Boost) active(now time.Time) bool {
+	return b.Until == nil || now.Before(*b.Until)
+}
+
+// target describes what the boost covers.
+func (b Boost) target() string {
+	if b.CardID != "" {
+		return "card " + b.CardID
+	}
+	return "tag " + b.Tag
+}
+
+// boostMultipliers returns the weight multiplier of every boosted card
+// among cards; cards without an active boost are left out.
+func boostMultipliers(cards []Card, player PlayerData, now time.Time) map[string]float64 {
+	if len(player.Boosts) == 0 {
+		return nil
+	}
+	multipliers := make(map[string]float64)
+	for _, boost := range player.Boosts {
+		if !boost.active(now) {
+			continue
+		}
+		for _, card := range cards {
+			if boost.applies(card) {
+				if m, ok := multipliers[card.ID]; ok {
+					multipliers[card.ID] = m * boost.Factor
+				} else {
+					multipliers[card.ID] = boost.Factor
+				}
+			}
+		}
+	}
+	return multipliers
+}
+
+// boostedWeight is the weight of one card in a box of n cards with the
+// given box weight.
+func boostedWeight(boxWeight, n int, multipliers map[string]float64, cardID string) float64 {
+	weight := float64(boxWeight) / float64(n)
+	if m, ok := multipliers[cardID]; ok {
+		weight *= m
+	}
+	return weight
+}
+
+// boostedPick draws a card from boxes by weight. Each card gets its box's
+// weight shared among the box's cards, times its multiplier, so that
+// without boosts the chances are those of drawing a box and then a card.
+func boostedPick(boxes map[int][]Card, weights []int, multipliers map[string]float64, rng *rand.Rand) (Card, int) {
+	total := 0.0
+	for box := 1; box <= topBox; box++ {
+		for _, card := range boxes[box] {
+			total += boostedWeight(weights[box-1], len(boxes[box]), multipliers, card.ID)
+		}
+	}
+	r := rng.Float64() * total
+	var last Card
+	lastBox := 0
+	for box := 1; box <= topBox; box++ {
+		for _, card := range boxes[box] {
+			r -= boostedWeight(weights[box-1], len(boxes[box]), multipliers, card.ID)
+			if r < 0 {
+				return card, box
+			}
+			last, lastBox = card, box
+		}
+	}
+	// Rounding can leave a sliver at the end
+	return last, lastBox
+}
+
+// countBoostedAnswer counts an answer to card against the boosts covering
+// it and returns the boosts still running.
+func countBoostedAnswer(boosts []Boost, card Card, now time.Time) []Boost {
+	if len(boosts) == 0 {
+		return boosts
+	}
+	kept := boosts[:0:0]
+	for _, boost := range boosts {
+		if boost.applies(card) && boost.ReviewsLeft > 0 {
+			boost.ReviewsLeft--
+			if boost.ReviewsLeft == 0 {
+				continue
+			}
+		}
+		if boost.active(now) {
+			kept = append(kept, boost)
+		}
+	}
+	return kept
+}
+
+// --- Command Handlers ---
+
+// handleBoost adds a boost for a card or tag, replacing any earlier one for
+// the same card or tag. factor is below 1 for deprioritize-card.
+func handleBoost(playerID, cardID, tag string, factor float64, reviews, days int) {
+	cards := loadCards()
+	if cardID != "" {
+		if _, ok := findCard(cards, cardID); !ok {
+			fatalf("Card with ID '%s' not found in deck.", cardID)
+		}
+	} else {
+		used := false
+		for _, card := range cards {
+			used = used || hasAnyTag(card, map[string]bool{tag: true})
+		}
+		if !used {
+			fatalf("No card in the deck has the tag '%s'.", tag)
+		}
+	}
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+
+	now := time.Now()
+	boost := Boost{CardID: cardID, Tag: tag, Factor: factor, ReviewsLeft: reviews, CreatedAt: now}
+	if days > 0 {
+		until := now.AddDate(0, 0, days)
+		boost.Until = &until
+	}
+	player.Boosts = append(withoutBoost(player.Boosts, cardID, tag), boost)
+	allProgress[playerID] = player
+	saveAllProgress(allProgress)
+
+	loc := resolveLocale(player.Locale)
+	format := tr("Boosted %s by a factor of %s %s.\n")
+	if factor < 1 {
+		format = tr("Deprioritized %s by a factor of %s %s.\n")
+	}
+	fmt.Printf(format, boost.target(), loc.Float(max(factor, 1/factor), 1), boostLimits(boost, loc))
+}
+
+func handleClearBoost(playerID, cardID, tag string) {
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	kept := withoutBoost(player.Boosts, cardID, tag)
+	if len(kept) == len(player.Boosts) {
+		fatalf("No boost on %s.", Boost{CardID: cardID, Tag: tag}.target())
+	}
+	player.Boosts = kept
+	allProgress[playerID] = player
+	saveAllProgress(allProgress)
+	fmt.Printf(tr("Removed the boost on %s.\n"), Boost{CardID: cardID, Tag: tag}.target())
+}
+
+func handleListBoosts(playerID string) {
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	now := time.Now()
+	var boosts []Boost
+	for _, boost := range player.Boosts {
+		if boost.active(now) {
+			boosts = append(boosts, boost)
+		}
+	}
+	if len(boosts) == 0 {
+		fmt.Println(tr("No boosts."))
+		return
+	}
+	sort.SliceStable(boosts, func(i, j int) bool { return boosts[i].Factor > boosts[j].Factor })
+	loc := resolveLocale(player.Locale)
+	for _, boost := range boosts {
+		factor := "x" + loc.Float(boost.Factor, 1)
+		if boost.Factor < 1 {
+			factor = "/" + loc.Float(1/boost.Factor, 1)
+		}
+		fmt.Printf("%-6s %s %s\n", factor, boost.target(), boostLimits(boost, loc))
+	}
+}
+
+// --- Helpers ---
+
+// withoutBoost returns boosts without the one for cardID or tag.
+func withoutBoost(boosts []Boost, cardID, tag string) []Boost {
+	kept := boosts[:0:0]
+	for _, boost := range boosts {
+		if boost.CardID != cardID || boost.Tag != tag {
+			kept = append(kept, boost)
+		}
+	}
+	return kept
+}
+
+// boostLimits describes when a boost ends.
+func boostLimits(boost Boost, loc Locale) string {
+	var limits []string
+	if boost.ReviewsLeft > 0 {
+		limits = append(limits, fmt.Sprintf("%s more answer(s)", loc.Number(boost.ReviewsLeft)))
+	}
+	if boost.Until != nil {
+		limits = append(limits, loc.DateTime(*boost.Until))
+	}
+	return "until " + strings.Join(limits, " or ")
+}
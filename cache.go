@@ -0,0 +1,357 @@
+// cache.go
+//
+// In-memory state for serve mode. The CLI reads and rewrites progress.json
+// for every command, which is fine once per invocation but not for a
+// server answering many requests. While serving, the deck and progress are
+// read once and kept in memory; changes are written back in batches, at
+// most flushDelay after the first unsaved one, and on shutdown.
+//
+// progress.json, the answer journal and the deck are watched by polling
+// their modification times, so edits to the deck and commands run next to
+// the server are picked up, like a goal set with set-goal. A file is only
+// reloaded once it has stopped changing, so a half-written file isn't read,
+// and never while a request is changing progress (see progressLock). A
+// changed file that can't be read is reported and the progress in memory
+// kept. If progress.json changes on disk while the server has unsaved
+// changes of its own, the next flush merges the two: players the server
+// didn't change take the file's version, the others keep the server's.
+
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// flushDelay is how long changes may stay in memory only.
+	flushDelay = 2 * time.Second
+	// watchInterval is how often the files are checked for changes.
+	watchInterval = time.Second
+)
+
+// cache is the in-memory state; nil outside serve mode.
+var cache *stateCache
+
+// fileStamp identifies a version of a file on disk.
+type fileStamp struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// stateCache holds the deck and progress for serve mode.
+type stateCache struct {
+	mu      sync.RWMutex
+	players map[string]PlayerData
+	cards   Deck
+	dirty   bool
+	timer   *time.Timer
+
+	// base is the progress as last read from or written to disk, to tell
+	// the server's changes from outside ones.
+	base map[string]PlayerData
+
+	// flushMu keeps two flushes from writing at the same time.
+	flushMu sync.Mutex
+
+	// Stamps of the files as last read or written, and as seen by the
+	// previous poll.
+	progressStamp, progressSeen fileStamp
+	journalStamp, journalSeen   fileStamp
+	deckStamp, deckSeen         fileStamp
+	vaultStamp, vaultSeen       fileStamp
+}
+
+// enableCache reads the deck and progress and serves them from memory from
+// then on.
+func enableCache() *stateCache {
+	c := &stateCache{
+		progressStamp: statFile(progressPath()),
+		journalStamp:  statFile(journalPath()),
+		deckStamp:     statFile(deckPath()),
+		vaultStamp:    statFile(vaultCardsPath()),
+	}
+	c.players = readProgressFile()
+	c.base = c.players
+	c.cards = readDeckFile()
+	cache = c
+	go c.watch()
+	return c
+}
+
+// progress returns a copy of the cached progress.
+func (c *stateCache) progress() map[string]PlayerData {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return cloneProgress(c.players)
+}
+
+// deck returns a copy of the cached deck.
+func (c *stateCache) deck() Deck {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	deck := c.cards
+	deck.Cards = slices.Clone(c.cards.Cards)
+	return deck
+}
+
+// saveProgress replaces the cached progress and schedules a flush.
+func (c *stateCache) saveProgress(progress map[string]PlayerData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.players = cloneProgress(progress)
+	c.dirty = true
+	if c.timer == nil {
+		c.timer = time.AfterFunc(flushDelay, c.flush)
+	}
+}
+
+// flush writes unsaved progress to disk, merging in changes made to the
+// file from outside since the server last read or wrote it.
+func (c *stateCache) flush() {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	c.mu.Lock()
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	if !c.dirty {
+		c.mu.Unlock()
+		return
+	}
+	outside := statFile(progressPath()) != c.progressStamp
+	c.mu.Unlock()
+	if outside {
+		// The merged progress replaces the cached one; hold requests off
+		// until it does
+		progressLock.Lock()
+		defer progressLock.Unlock()
+	}
+
+	c.mu.Lock()
+	players, base := cloneProgress(c.players), c.base
+	c.dirty = false
+	c.mu.Unlock()
+	if outside {
+		if err := progressReadable(); err != nil {
+			warnf("progress.json was changed on disk but can't be read (%v); writing the server's progress over it.", err)
+			outside = false
+		} else {
+			players = mergeOutsideChanges(players, base, readProgressFile())
+		}
+	}
+
+	writeProgressFile(players)
+	stamp := statFile(progressPath())
+	c.mu.Lock()
+	if outside {
+		c.players = cloneProgress(players)
+	}
+	c.base = players
+	c.progressStamp, c.progressSeen = stamp, stamp
+	// Answers other commands journaled meanwhile are still in the journal;
+	// forgetting its stamp has the next polls read them in
+	c.journalStamp, c.journalSeen = fileStamp{}, fileStamp{}
+	c.mu.Unlock()
+}
+
+// watch polls the files for changes made outside the server.
+func (c *stateCache) watch() {
+	for range time.Tick(watchInterval) {
+		c.checkProgress()
+		c.checkDeck()
+	}
+}
+
+func (c *stateCache) checkProgress() {
+	stamp, journal := statFile(progressPath()), statFile(journalPath())
+	c.mu.Lock()
+	if c.dirty {
+		// The flush comes first and merges in any outside change; the
+		// stamps are left alone so the files are checked again after it
+		c.mu.Unlock()
+		return
+	}
+	changed := c.settled(stamp, &c.progressStamp, &c.progressSeen)
+	// The server's own answers are in the journal too; reading them back
+	// does no harm
+	changed = c.settled(journal, &c.journalStamp, &c.journalSeen) || changed
+	c.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	// A half-edited file would stop the server; keep the old progress
+	if err := progressReadable(); err != nil {
+		warnf("progress.json was changed but can't be read (%v); still using the previous progress.", err)
+		return
+	}
+	// A request in the middle of changing progress would save its copy
+	// over the reloaded one; wait for it, and hold the next one off
+	progressLock.Lock()
+	defer progressLock.Unlock()
+	players := readProgressFile()
+	c.mu.Lock()
+	// An answer may have come in while waiting; the flush merges instead
+	if !c.dirty {
+		c.players, c.base = players, players
+	}
+	c.mu.Unlock()
+	slog.Info("Reloaded progress.json after an outside change", "players", len(players))
+}
+
+func (c *stateCache) checkDeck() {
+	filePath := deckPath()
+	stamp, vault := statFile(filePath), statFile(vaultCardsPath())
+	c.mu.Lock()
+	changed := c.settled(stamp, &c.deckStamp, &c.deckSeen)
+	// Cards synced from a notes folder are part of the deck too
+	changed = c.settled(vault, &c.vaultStamp, &c.vaultSeen) || changed
+	c.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	// A deck with a typo would stop the server; keep the old one instead
+	data, err := ioutil.ReadFile(filePath)
+	if err == nil {
+		_, err = decodeDeck(filePath, data)
+	}
+	if err != nil {
+		warnf("%s was changed but can't be read (%v); still using the previous deck.", filepath.Base(filePath), err)
+		return
+	}
+	deck := readDeckFile()
+	c.mu.Lock()
+	c.cards = deck
+	c.mu.Unlock()
+	slog.Info("Reloaded the deck after a change", "path", filePath, "cards", len(deck.Cards))
+}
+
+// settled reports whether a file has changed since known and has stayed
+// the same since the previous poll, and records the new stamp as known if
+// so. The caller holds c.mu.
+func (c *stateCache) settled(stamp fileStamp, known, seen *fileStamp) bool {
+	if stamp == *known {
+		*seen = stamp
+		return false
+	}
+	if stamp != *seen {
+		*seen = stamp
+		return false
+	}
+	*known = stamp
+	return true
+}
+
+// --- Helpers ---
+
+// mergeOutsideChanges brings the changes made to progress.json from
+// outside (disk) into the server's progress (players), both descended from
+// base. Players the server didn't change take the file's version, which
+// also adds and removes players; players both changed keep the server's.
+func mergeOutsideChanges(players, base, disk map[string]PlayerData) map[string]PlayerData {
+	var conflicts []string
+	for id, onDisk := range disk {
+		mine, ok := players[id]
+		old, known := base[id]
+		switch {
+		case !ok && !known:
+			players[id] = onDisk
+		case !ok:
+			// Deleted by the server
+		case reflect.DeepEqual(mine, old):
+			players[id] = onDisk
+		case !reflect.DeepEqual(onDisk, old):
+			conflicts = append(conflicts, mine.Name)
+		}
+	}
+	for id, mine := range players {
+		if _, ok := disk[id]; !ok {
+			if old, known := base[id]; known && reflect.DeepEqual(mine, old) {
+				delete(players, id)
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		warnf("progress.json was changed on disk while the server had unsaved answers of %s; keeping the server's version of them.", strings.Join(conflicts, ", "))
+	}
+	return players
+}
+
+// progressReadable returns why progress.json can't be reloaded, if it
+// can't: it can't be read or decoded, or is damaged.
+func progressReadable() error {
+	data, err := ioutil.ReadFile(progressPath())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	_, _, damage, err := decodeProgress(data)
+	if err != nil {
+		return err
+	}
+	if !damage.empty() {
+		return errors.New("it is damaged; see 'repair-progress'")
+	}
+	return nil
+}
+
+func progressPath() string {
+	return filepath.Join(getDataDir(), "progress.json")
+}
+
+// deckPath returns the deck file: cards.json, or cards.md if there is only
+// a Markdown deck.
+func deckPath() string {
+	configDir := getConfigDir()
+	filePath := filepath.Join(configDir, "cards.json")
+	if markdown := filepath.Join(configDir, "cards.md"); !fileExists(filePath) && fileExists(markdown) {
+		return markdown
+	}
+	return filePath
+}
+
+// statFile returns the stamp of a file, or the zero stamp if it is missing.
+func statFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{ModTime: info.ModTime(), Size: info.Size()}
+}
+
+// cloneProgress copies progress deeply enough that the copy can be changed
+// without touching the original.
+func cloneProgress(progress map[string]PlayerData) map[string]PlayerData {
+	clone := make(map[string]PlayerData, len(progress))
+	for id, player := range progress {
+		player.Cards = maps.Clone(player.Cards)
+		player.History = slices.Clone(player.History)
+		player.Practice = slices.Clone(player.Practice)
+		player.Achievements = slices.Clone(player.Achievements)
+		player.RecentCards = slices.Clone(player.RecentCards)
+		player.Writing = slices.Clone(player.Writing)
+		player.Notes = maps.Clone(player.Notes)
+		player.Skipped = maps.Clone(player.Skipped)
+		player.Challenges = maps.Clone(player.Challenges)
+		player.Boosts = slices.Clone(player.Boosts)
+		clone[id] = player
+	}
+	return clone
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMergeOutsideChanges(t *testing.T) {
+	a := PlayerData{Name: "a", XP: 1}
+	b := PlayerData{Name: "b", XP: 1}
+	tests := []struct {
+		name    string
+		players map[string]PlayerData
+		base    map[string]PlayerData
+		disk    map[string]PlayerData
+		want    map[string]PlayerData
+	}{
+		{
+			name:    "outside change to an untouched player",
+			players: map[string]PlayerData{"a": {Name: "a", XP: 2}, "b": b},
+			base:    map[string]PlayerData{"a": a, "b": b},
+			disk:    map[string]PlayerData{"a": a, "b": {Name: "b", XP: 5}},
+			want:    map[string]PlayerData{"a": {Name: "a", XP: 2}, "b": {Name: "b", XP: 5}},
+		},
+		{
+			name:    "both changed one player",
+			players: map[string]PlayerData{"a": {Name: "a", XP: 2}},
+			base:    map[string]PlayerData{"a": a},
+			disk:    map[string]PlayerData{"a": {Name: "a", XP: 5}},
+			want:    map[string]PlayerData{"a": {Name: "a", XP: 2}},
+		},
+		{
+			name:    "player created outside",
+			players: map[string]PlayerData{"a": {Name: "a", XP: 2}},
+			base:    map[string]PlayerData{"a": a},
+			disk:    map[string]PlayerData{"a": a, "b": b},
+			want:    map[string]PlayerData{"a": {Name: "a", XP: 2}, "b": b},
+		},
+		{
+			name:    "untouched player deleted outside",
+			players: map[string]PlayerData{"a": {Name: "a", XP: 2}, "b": b},
+			base:    map[string]PlayerData{"a": a, "b": b},
+			disk:    map[string]PlayerData{"a": a},
+			want:    map[string]PlayerData{"a": {Name: "a", XP: 2}},
+		},
+		{
+			name:    "changed player deleted outside",
+			players: map[string]PlayerData{"a": {Name: "a", XP: 2}},
+			base:    map[string]PlayerData{"a": a},
+			disk:    map[string]PlayerData{},
+			want:    map[string]PlayerData{"a": {Name: "a", XP: 2}},
+		},
+		{
+			name:    "player deleted by the server",
+			players: map[string]PlayerData{},
+			base:    map[string]PlayerData{"a": a},
+			disk:    map[string]PlayerData{"a": a},
+			want:    map[string]PlayerData{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeOutsideChanges(tt.players, tt.base, tt.disk); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeOutsideChanges = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckProgressKeepsProgressOnUnreadableFile(t *testing.T) {
+	dataDirOverride = t.TempDir()
+	defer func() { dataDirOverride = "" }()
+	players := map[string]PlayerData{"a": {Name: "a", Cards: map[string]CardProgress{}}}
+	c := &stateCache{players: players, base: players}
+	if err := ioutil.WriteFile(progressPath(), []byte(`{"version": 3, "players": {"a": {"na`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A change is only read once it has stayed the same for a poll
+	c.checkProgress()
+	c.checkProgress()
+	if !reflect.DeepEqual(c.players, players) {
+		t.Errorf("players = %+v after reading a damaged file, want %+v", c.players, players)
+	}
+}
+
+func TestFlushMergesOutsideChanges(t *testing.T) {
+	dataDirOverride = t.TempDir()
+	defer func() { dataDirOverride = "" }()
+	base := map[string]PlayerData{
+		"a": {Name: "a", XP: 1, Cards: map[string]CardProgress{}},
+		"b": {Name: "b", XP: 1, Cards: map[string]CardProgress{}},
+	}
+	writeProgressFile(base)
+	c := &stateCache{base: base, progressStamp: statFile(progressPath()), dirty: true}
+	c.players = map[string]PlayerData{"a": {Name: "a", XP: 2, Cards: map[string]CardProgress{}}, "b": base["b"]}
+
+	// Another command changes b while the server has unsaved answers of a;
+	// the stamp check is skipped until the flush
+	outside := map[string]PlayerData{"a": base["a"], "b": {Name: "b", XP: 7, Cards: map[string]CardProgress{}}}
+	data := `{"version": 3, "players": {"a": {"name": "a", "xp": 1, "cards": {}}, "b": {"name": "b", "xp": 7, "cards": {}}, "c": {"name": "c", "cards": {}}}}`
+	if err := ioutil.WriteFile(progressPath(), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stamp := c.progressStamp
+	c.checkProgress()
+	c.checkProgress()
+	if c.progressStamp != stamp {
+		t.Fatalf("checkProgress recorded the new stamp with unsaved answers")
+	}
+
+	c.flush()
+	want := map[string]PlayerData{
+		"a": {Name: "a", XP: 2, Cards: map[string]CardProgress{}},
+		"b": outside["b"],
+		"c": {Name: "c", Cards: map[string]CardProgress{}},
+	}
+	if got := readProgressFile(); !reflect.DeepEqual(got, want) {
+		t.Errorf("progress.json = %+v, want %+v", got, want)
+	}
+	if !reflect.DeepEqual(c.players, want) {
+		t.Errorf("cached players = %+v, want %+v", c.players, want)
+	}
+	if c.progressStamp != statFile(filepath.Join(dataDirOverride, "progress.json")) {
+		t.Errorf("flush didn't record the stamp of its own write")
+	}
+}
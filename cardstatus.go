@@ -0,0 +1,118 @@
+// cardstatus.go
+//
+// The card-status command: everything known about one card for one player,
+// from its box and counters to its recent attempts. decouvertes has no due
+// dates as such, since cards are drawn at random by box weight, so the
+// projected next review is worked out from the card's chance of being drawn
+// and the player's recent pace.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// paceDays is the window over which a player's answers per day are
+// averaged for projections.
+const paceDays = 14
+
+// --- Command Handlers ---
+
+func handleCardStatus(playerID, cardID string, attempts int) {
+	player, ok := loadAllProgress()[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	cards := loadCards()
+	card, ok := findCard(cards, cardID)
+	if !ok {
+		fatalf("Card with ID '%s' not found.", cardID)
+	}
+	config := loadConfig()
+	loc := resolveLocale(player.Locale)
+	now := time.Now()
+
+	fmt.Printf(tr("Card %s [%s]\n"), card.ID, card.Language)
+	fmt.Printf(tr("  Prompt:   %s\n"), firstLine(card.Prompt))
+	fmt.Printf(tr("  Solution: %s\n"), firstLine(card.Solution))
+	if note, ok := player.Notes[card.ID]; ok {
+		fmt.Printf(tr("  Note:     %s\n"), firstLine(note))
+	}
+
+	fmt.Printf(tr("\nProgress of %s\n"), player.Name)
+	progress, seen := player.Cards[card.ID]
+	skippedAt, skipped := player.Skipped[card.ID]
+	switch {
+	case skipped:
+		fmt.Printf(tr("  Skipped since %s; 'unskip-card' brings it back.\n"), loc.Date(skippedAt))
+	case !seen:
+		fmt.Println(tr("  Not in rotation yet; it enters box 1 when there is room for new cards."))
+	case progress.Retired:
+		fmt.Println(tr("  Retired; 'reactivate-card' brings it back."))
+	default:
+		fmt.Printf(tr("  Box:           %d of %d\n"), progress.Box, topBox)
+	}
+	if seen {
+		fmt.Printf(tr("  Streak:        %s\n"), loc.Number(progress.Streak))
+		fmt.Printf(tr("  Passed:        %s\n"), loc.Number(progress.Passed))
+		fmt.Printf(tr("  Failed:        %s\n"), loc.Number(progress.Failed))
+		if progress.Box == topBox && progress.TopBoxPasses > 0 {
+			fmt.Printf(tr("  Box 5 passes:  %d of %d to retire\n"), progress.TopBoxPasses, config.Scheduler.retireAfter())
+		}
+		if !progress.LastReviewed.IsZero() {
+			fmt.Printf(tr("  Last reviewed: %s (%s ago)\n"), loc.DateTime(progress.LastReviewed), loc.Duration(now.Sub(progress.LastReviewed)))
+		}
+	}
+
+	if chance := pickChance(withoutSkipped(cards, player), player, config.Scheduler, now, card.ID); chance > 0 && !skipped {
+		fmt.Printf(tr("  Next pick:     %s chance, about 1 in %s picks\n"), loc.Percent(chance), loc.Number(int(math.Round(1/chance))))
+		history := loadFullHistory(playerID, player)
+		if pace := answersPerDay(history, now); pace > 0 {
+			due := now.Add(time.Duration(float64(24*time.Hour) / (chance * pace)))
+			fmt.Printf(tr("  Projected:     around %s at %s answers a day\n"), loc.Date(due), loc.Float(pace, 1))
+		} else {
+			fmt.Printf(tr("  Projected:     no answers in the last %d days to project from\n"), paceDays)
+		}
+		if after := config.Decay.AfterDays; after > 0 && progress.Box > 1 {
+			drop := progress.LastReviewed.AddDate(0, 0, after*(progress.Decayed+1))
+			fmt.Printf(tr("  Decay:         drops to box %d on %s unless reviewed\n"), progress.Box-1, loc.Date(drop))
+		}
+	}
+
+	var recent []AnswerLogItem
+	for _, item := range loadFullHistory(playerID, player) {
+		if item.CardID == card.ID {
+			recent = append(recent, item)
+		}
+	}
+	if len(recent) == 0 {
+		fmt.Println(tr("\nNo attempts yet."))
+		return
+	}
+	recent = recent[max(len(recent)-attempts, 0):]
+	fmt.Printf(tr("\nLast %d attempt(s)\n"), len(recent))
+	for _, item := range recent {
+		mark := tr("wrong")
+		if item.Correct {
+			mark = tr("right")
+		}
+		fmt.Printf("  %s  %s\n", loc.DateTime(item.Timestamp), mark)
+	}
+}
+
+// --- Helpers ---
+
+// answersPerDay is the player's average number of answers a day over the
+// last paceDays days.
+func answersPerDay(history []AnswerLogItem, now time.Time) float64 {
+	since := now.AddDate(0, 0, -paceDays)
+	count := 0
+	for _, item := range history {
+		if item.Timestamp.After(since) && !isFutureDated(item.Timestamp, now) {
+			count++
+		}
+	}
+	return float64(count) / paceDays
+}
@@ -0,0 +1,164 @@
+// cardtype.go
+//
+// Community card types. A card with "type": "music-interval" is handled by a
+// plugin: an executable named decouvertes-cardtype-music-interval on PATH, or
+// whatever path config.json maps the type to:
+//
+//	{ "card_types": { "music-interval": "/opt/plugins/intervals" } }
+//
+// Plugins are called once per action with a JSON request on stdin and answer
+// with a JSON response on stdout:
+//
+//	{"action": "render", "card": {...}}                 -> {"prompt": "..."}
+//	{"action": "grade", "card": {...}, "answer": "..."} -> {"correct": true, "feedback": "..."}
+//
+// Type-specific content (a formula, a pair of notes) goes in the card's
+// "data" object, which the plugin receives as part of the card.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// cardTypePrefix is the executable name prefix plugins are discovered by.
+const cardTypePrefix = "decouvertes-cardtype-"
+
+// cardTypeRequest is what a plugin receives on stdin.
+type cardTypeRequest struct {
+	Action string `json:"action"`
+	Card   Card   `json:"card"`
+	Answer string `json:"answer,omitempty"`
+}
+
+// cardTypePlugin grades answers by calling the plugin, so it can stand in
+// for a Checker.
+type cardTypePlugin struct {
+	path string
+}
+
+func (p cardTypePlugin) Check(card Card, answer string) (Verdict, error) {
+	var verdict Verdict
+	err := p.call(cardTypeRequest{Action: "grade", Card: card, Answer: answer}, &verdict)
+	return verdict, err
+}
+
+// render returns the prompt the plugin wants shown for card.
+func (p cardTypePlugin) render(card Card) (string, error) {
+	var response struct {
+		Prompt string `json:"prompt"`
+	}
+	if err := p.call(cardTypeRequest{Action: "render", Card: card}, &response); err != nil {
+		return "", err
+	}
+	return response.Prompt, nil
+}
+
+func (p cardTypePlugin) call(request cardTypeRequest, response interface{}) error {
+	input, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), externalCheckerTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(output, response); err != nil {
+		return fmt.Errorf("invalid %s response: %v", request.Action, err)
+	}
+	return nil
+}
+
+// findCardType returns the plugin for a card type, preferring the registry
+// in config.json over discovery on PATH.
+func findCardType(name string) (cardTypePlugin, error) {
+	if path, ok := loadConfig().CardTypes[name]; ok {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(getConfigDir(), path)
+		}
+		return cardTypePlugin{path: path}, nil
+	}
+	path, err := exec.LookPath(cardTypePrefix + name)
+	if err != nil {
+		return cardTypePlugin{}, fmt.Errorf("no plugin for card type '%s'; install %s%s on your PATH or register it under \"card_types\" in config.json", name, cardTypePrefix, name)
+	}
+	return cardTypePlugin{path: path}, nil
+}
+
+// renderCard returns card with its prompt as the card type's plugin renders
+// it. Cards without a type are returned unchanged. It fails if the plugin is
+// missing or fails, and the caller skips or reports the card.
+func renderCard(card Card) (Card, error) {
+	if card.Type == "" {
+		return card, nil
+	}
+	plugin, err := findCardType(card.Type)
+	if err != nil {
+		return card, err
+	}
+	prompt, err := plugin.render(card)
+	if err != nil {
+		return card, fmt.Errorf("card type '%s' failed to render card '%s': %v", card.Type, card.ID, err)
+	}
+	card.Prompt = prompt
+	return card, nil
+}
+
+// discoverCardTypes lists the card types available from PATH and the
+// registry, mapped to their executables. Registry entries win.
+func discoverCardTypes() map[string]string {
+	found := make(map[string]string)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasPrefix(name, cardTypePrefix) || entry.Mode()&0111 == 0 {
+				continue
+			}
+			cardType := strings.TrimSuffix(strings.TrimPrefix(name, cardTypePrefix), ".exe")
+			// Earlier PATH entries shadow later ones, like the shell does
+			if _, ok := found[cardType]; !ok {
+				found[cardType] = filepath.Join(dir, name)
+			}
+		}
+	}
+	for cardType, path := range loadConfig().CardTypes {
+		found[cardType] = path
+	}
+	return found
+}
+
+// --- Command Handlers ---
+
+func handleCardTypes() {
+	types := discoverCardTypes()
+	if len(types) == 0 {
+		fmt.Printf(tr("No card type plugins found. Install %s<type> executables on your PATH.\n"), cardTypePrefix)
+		return
+	}
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%-20s %s\n", name, types[name])
+	}
+}
@@ -0,0 +1,154 @@
+// challenge.go
+//
+// The timed challenge: as many correct answers as possible before the
+// countdown runs out. Cards come from the whole deck in random order, and
+// like daily challenges and exams it leaves the Leitner boxes alone. The
+// best score for each length of challenge is kept with the player and
+// shown in get-stats.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChallengeBest is a player's best result for one challenge length.
+type ChallengeBest struct {
+	Score    int       `json:"score"`
+	Answered int       `json:"answered"`
+	PlayedAt time.Time `json:"played_at"`
+}
+
+// --- Command Handlers ---
+
+func handleChallenge(playerID string, seconds int, seed int64) {
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	cards := withoutSkipped(loadCards(), player)
+	if len(cards) == 0 {
+		fatal("The deck has no cards for a challenge.")
+	}
+
+	lines := readLines(os.Stdin)
+	window := time.Duration(seconds) * time.Second
+	fmt.Printf(tr("Challenge: as many correct answers as you can in %d seconds. Press Enter to start."), seconds)
+	if _, ok := <-lines; !ok {
+		fmt.Println()
+		return
+	}
+
+	rng := newRand(seed)
+	order := rng.Perm(len(cards))
+	deadline := time.Now().Add(window)
+	timer := time.NewTimer(window)
+	score, answered := 0, 0
+	// Cards that can't be shown are left out for the rest of the challenge
+	broken := make(map[string]bool)
+play:
+	for i := 0; ; i++ {
+		if i > 0 && i%len(cards) == 0 {
+			order = rng.Perm(len(cards))
+		}
+		card := cards[order[i%len(cards)]]
+		if broken[card.ID] {
+			continue
+		}
+		card, err := renderCard(card)
+		if err != nil {
+			warnf("Skipping card '%s': %v", card.ID, err)
+			broken[card.ID] = true
+			if len(broken) == len(cards) {
+				fmt.Println(tr("\nNo card left that can be shown, ending the challenge."))
+				break
+			}
+			continue
+		}
+		fmt.Printf(tr("\n[%ds] [%s] %s\n> "), int(time.Until(deadline).Seconds()+0.5), card.Language, card.Prompt)
+		select {
+		case <-timer.C:
+			fmt.Println(tr("\nTime's up!"))
+			break play
+		case answer, ok := <-lines:
+			if !ok {
+				fmt.Println(tr("\nInput closed, ending the challenge."))
+				break play
+			}
+			answered++
+			answer = typedAnswer(card.Language, answer)
+			correct, err := isAnswerCorrect(card, answer)
+			if err != nil {
+				warnf("Could not check the answer to card '%s': %v", card.ID, err)
+			}
+			if correct {
+				score++
+				fmt.Println(tr("Correct!"))
+			} else {
+				fmt.Printf(tr("Incorrect. The answer was: %s\n"), card.Solution)
+			}
+		}
+	}
+
+	fmt.Printf(tr("\n%d correct of %d answered in %d seconds.\n"), score, answered, seconds)
+	key := strconv.Itoa(seconds)
+	best, played := player.Challenges[key]
+	if played && best.Score >= score {
+		fmt.Printf(tr("Your best for %d seconds is %d.\n"), seconds, best.Score)
+		return
+	}
+	if player.Challenges == nil {
+		player.Challenges = make(map[string]ChallengeBest)
+	}
+	player.Challenges[key] = ChallengeBest{Score: score, Answered: answered, PlayedAt: time.Now()}
+	allProgress[playerID] = player
+	saveAllProgress(allProgress)
+	if played {
+		fmt.Printf(tr("New best for %d seconds, up from %d!\n"), seconds, best.Score)
+	} else {
+		fmt.Printf(tr("That's your first %d-second challenge; beat it next time.\n"), seconds)
+	}
+}
+
+// --- Helpers ---
+
+// readLines delivers the lines of input on a channel, so reading them can
+// be combined with a timer. The channel is closed at the end of input.
+func readLines(file *os.File) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		reader := bufio.NewReader(file)
+		for {
+			line, ok := readAnswer(reader)
+			if !ok {
+				return
+			}
+			lines <- line
+		}
+	}()
+	return lines
+}
+
+// challengeBests formats a player's best scores, shortest challenge first.
+func challengeBests(bests map[string]ChallengeBest) string {
+	seconds := make([]int, 0, len(bests))
+	for key := range bests {
+		if n, err := strconv.Atoi(key); err == nil {
+			seconds = append(seconds, n)
+		}
+	}
+	sort.Ints(seconds)
+	parts := make([]string, len(seconds))
+	for i, n := range seconds {
+		parts[i] = fmt.Sprintf("%d in %ds", bests[strconv.Itoa(n)].Score, n)
+	}
+	return strings.Join(parts, ", ")
+}
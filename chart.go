@@ -0,0 +1,206 @@
+// chart.go
+//
+// Terminal charts for progress-chart: sparklines of reviews and accuracy per
+// day and stacked bars showing how the cards spread over the boxes as time
+// went on. Box positions in the past aren't stored, so they are replayed
+// from the answer history with the same rules as check-answer.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// chartWidth is the width of the box distribution bars.
+const chartWidth = 40
+
+// chartRows is the most rows the box distribution chart shows.
+const chartRows = 8
+
+// chartGlyphs are the characters a chart is drawn with.
+type chartGlyphs struct {
+	// Levels go from lowest to highest; the first is for zero.
+	Levels []string
+	// Boxes fill the stacked bars for boxes 1 to 5 and retired cards.
+	Boxes []string
+	None  string
+}
+
+var unicodeGlyphs = chartGlyphs{
+	Levels: []string{"▁", "▂", "▃", "▄", "▅", "▆", "▇", "█"},
+	Boxes:  []string{"█", "▓", "▒", "░", "·", "✓"},
+	None:   " ",
+}
+
+var asciiGlyphs = chartGlyphs{
+	Levels: []string{"_", ".", ":", "-", "=", "+", "*", "#"},
+	Boxes:  []string{"#", "=", "+", "-", ".", "*"},
+	None:   " ",
+}
+
+// --- Command Handlers ---
+
+func handleProgressChart(playerID string, days int, ascii bool) {
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	history := loadFullHistory(playerID, player)
+	if len(history) == 0 {
+		fmt.Println(tr("No historical data to chart yet."))
+		return
+	}
+	glyphs := unicodeGlyphs
+	if ascii {
+		glyphs = asciiGlyphs
+	}
+	loc := resolveLocale(player.Locale)
+
+	today := calendarDay(time.Now())
+	first := today.AddDate(0, 0, -(days - 1))
+	answered := make([]int, days)
+	correct := make([]int, days)
+	for _, item := range history {
+		day := int(calendarDay(item.Timestamp).Sub(first).Hours()/24 + 0.5)
+		if day < 0 || day >= days {
+			continue
+		}
+		answered[day]++
+		if item.Correct {
+			correct[day]++
+		}
+	}
+
+	fmt.Printf(tr("Progress of %s, %s - %s\n"), player.Name, loc.Date(first), loc.Date(today))
+
+	maxAnswered := 0
+	for _, n := range answered {
+		maxAnswered = max(maxAnswered, n)
+	}
+	fmt.Printf(tr("\nReviews per day (max %s)\n"), loc.Number(maxAnswered))
+	reviews := make([]float64, days)
+	for i, n := range answered {
+		reviews[i] = float64(n)
+		if maxAnswered > 0 {
+			reviews[i] /= float64(maxAnswered)
+		}
+	}
+	fmt.Println(sparkline(reviews, answered, glyphs))
+
+	fmt.Println(tr("\nAccuracy per day (0% - 100%)"))
+	accuracy := make([]float64, days)
+	for i := range answered {
+		if answered[i] > 0 {
+			accuracy[i] = float64(correct[i]) / float64(answered[i])
+		}
+	}
+	fmt.Println(sparkline(accuracy, answered, glyphs))
+
+	fmt.Println(tr("\nBox distribution of answered cards"))
+	for _, sample := range boxSamples(history, first, today, loadConfig().Scheduler.retireAfter()) {
+		fmt.Printf("%-12s %s %s\n", loc.Date(sample.Day), stackedBar(sample.Counts, glyphs), formatBoxCounts(sample.Counts, loc))
+	}
+	var legend []string
+	for box := 1; box <= 5; box++ {
+		legend = append(legend, fmt.Sprintf("%s box %d", glyphs.Boxes[box-1], box))
+	}
+	legend = append(legend, glyphs.Boxes[5]+" retired")
+	fmt.Printf("\n%s\n", strings.Join(legend, "  "))
+}
+
+// --- Helpers ---
+
+// sparkline renders values between 0 and 1, one character per day. Days
+// without any answers stay blank so they can't be mistaken for zero.
+func sparkline(values []float64, answered []int, glyphs chartGlyphs) string {
+	var b strings.Builder
+	top := len(glyphs.Levels) - 1
+	for i, v := range values {
+		if answered[i] == 0 {
+			b.WriteString(glyphs.None)
+			continue
+		}
+		level := int(v*float64(top) + 0.5)
+		b.WriteString(glyphs.Levels[min(max(level, 0), top)])
+	}
+	return b.String()
+}
+
+// BoxSample is the number of cards per box (index 0 to 4) and retired
+// (index 5) at the end of a day.
+type BoxSample struct {
+	Day    time.Time
+	Counts [6]int
+}
+
+// boxSamples replays history and takes up to chartRows evenly spaced
+// snapshots between first and last. Decay isn't replayed.
+func boxSamples(history []AnswerLogItem, first, last time.Time, retireAfter int) []BoxSample {
+	days := int(last.Sub(first).Hours()/24+0.5) + 1
+	step := max((days+chartRows-1)/chartRows, 1)
+	var sampleDays []time.Time
+	for day := last; !day.Before(first); day = day.AddDate(0, 0, -step) {
+		sampleDays = append([]time.Time{day}, sampleDays...)
+	}
+
+	cards := make(map[string]CardProgress)
+	var samples []BoxSample
+	next := 0
+	take := func(day time.Time) {
+		sample := BoxSample{Day: day}
+		for _, progress := range cards {
+			if progress.Retired {
+				sample.Counts[5]++
+			} else {
+				sample.Counts[progress.Box-1]++
+			}
+		}
+		samples = append(samples, sample)
+	}
+	for _, item := range history {
+		for next < len(sampleDays) && !item.Timestamp.Before(sampleDays[next].AddDate(0, 0, 1)) {
+			take(sampleDays[next])
+			next++
+		}
+		// get-card puts cards in box 1 before they are first answered
+		progress := cards[item.CardID]
+		progress.Box = max(progress.Box, 1)
+		cards[item.CardID] = applyAnswer(progress, item.Correct, retireAfter)
+	}
+	for ; next < len(sampleDays); next++ {
+		take(sampleDays[next])
+	}
+	return samples
+}
+
+// stackedBar draws the box counts as one bar of chartWidth characters.
+func stackedBar(counts [6]int, glyphs chartGlyphs) string {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	if total == 0 {
+		return strings.Repeat(glyphs.None, chartWidth)
+	}
+	var b strings.Builder
+	drawn, cumulative := 0, 0
+	for i, n := range counts {
+		cumulative += n
+		// Round the running total so the segments always add up to the width
+		end := (cumulative*chartWidth + total/2) / total
+		b.WriteString(strings.Repeat(glyphs.Boxes[i], end-drawn))
+		drawn = end
+	}
+	return b.String()
+}
+
+func formatBoxCounts(counts [6]int, loc Locale) string {
+	parts := make([]string, len(counts))
+	for i, n := range counts {
+		parts[i] = loc.Number(n)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,167 @@
+// checker.go
+//
+// Answer checkers. The built-in validation modes are Checkers, and a card can
+// name an external one instead ("checker": "conjugation") for grading the
+// built-ins can't do, such as a conjugation table or an LLM grader.
+//
+// External checkers are executables looked up in the checkers/ directory
+// next to cards.json, then on PATH as decouvertes-checker-<name>. They get
+// one JSON object on stdin,
+//
+//	{"card": {...the card...}, "answer": "..."}
+//
+// and must print one JSON object on stdout,
+//
+//	{"correct": true, "feedback": "optional text shown to the player"}
+//
+// A non-zero exit status or malformed output is an error.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Verdict is the outcome of checking one answer.
+type Verdict struct {
+	Correct  bool   `json:"correct"`
+	Feedback string `json:"feedback,omitempty"`
+}
+
+// Checker grades an answer to a card.
+type Checker interface {
+	Check(card Card, answer string) (Verdict, error)
+}
+
+// CheckerFunc adapts a plain function to the Checker interface.
+type CheckerFunc func(card Card, answer string) (Verdict, error)
+
+func (f CheckerFunc) Check(card Card, answer string) (Verdict, error) {
+	return f(card, answer)
+}
+
+// checkers holds the registered checkers by name. The built-in validation
+// modes are registered under their "validation" names.
+var checkers = map[string]Checker{}
+
+// registerChecker makes a checker available to cards under name.
+func registerChecker(name string, checker Checker) {
+	checkers[name] = checker
+}
+
+// externalCheckerTimeout bounds how long an external checker may take.
+const externalCheckerTimeout = 30 * time.Second
+
+// checkerPrefix is the executable name prefix external checkers on PATH
+// must have, e.g. decouvertes-checker-conjugation.
+const checkerPrefix = "decouvertes-checker-"
+
+// invalidCardError is a problem with the card being graded itself, like a
+// validation mode that doesn't exist, rather than with its checker.
+type invalidCardError struct {
+	cardID string
+	reason string
+}
+
+func (e invalidCardError) Error() string {
+	return fmt.Sprintf("card '%s' %s", e.cardID, e.reason)
+}
+
+// checkAnswer grades answer with the card's checker, its card type plugin or
+// its validation mode, in that order. It fails if that checker is missing
+// or fails, leaving it to the caller whether to give up or skip the card.
+func checkAnswer(card Card, answer string) (Verdict, error) {
+	name, checker := card.Validation, checkers[card.Validation]
+	if card.Type != "" {
+		plugin, err := findCardType(card.Type)
+		if err != nil {
+			return Verdict{}, err
+		}
+		name, checker = card.Type, plugin
+	}
+	if card.Checker != "" {
+		name, checker = card.Checker, checkers[card.Checker]
+		if checker == nil {
+			path, err := findExternalChecker(card.Checker)
+			if err != nil {
+				return Verdict{}, err
+			}
+			checker = externalChecker{path: path}
+		}
+	}
+	if checker == nil {
+		return Verdict{}, invalidCardError{card.ID, fmt.Sprintf("has unknown validation mode '%s'", card.Validation)}
+	}
+	verdict, err := checker.Check(card, answer)
+	var invalid invalidCardError
+	if errors.As(err, &invalid) {
+		return Verdict{}, err
+	}
+	if err != nil {
+		return Verdict{}, fmt.Errorf("checker '%s' failed on card '%s': %v", name, card.ID, err)
+	}
+	return verdict, nil
+}
+
+// externalChecker runs an executable speaking the stdin/stdout protocol
+// described at the top of this file.
+type externalChecker struct {
+	path string
+}
+
+func (c externalChecker) Check(card Card, answer string) (Verdict, error) {
+	var verdict Verdict
+	input, err := json.Marshal(struct {
+		Card   Card   `json:"card"`
+		Answer string `json:"answer"`
+	}{card, answer})
+	if err != nil {
+		return verdict, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), externalCheckerTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, c.path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return verdict, err
+	}
+	if err := json.Unmarshal(output, &verdict); err != nil {
+		return verdict, fmt.Errorf("invalid output: %v", err)
+	}
+	return verdict, nil
+}
+
+// findExternalChecker resolves a checker name to an executable in the
+// checkers/ directory, or to checkerPrefix+name on PATH. Cards come from
+// decks people share, so a name can't reach any other program: absolute
+// paths and ".." are refused.
+func findExternalChecker(name string) (string, error) {
+	checkersDir := filepath.Join(getConfigDir(), "checkers")
+	if name == "" || filepath.IsAbs(name) || strings.Contains(name, "..") {
+		return "", fmt.Errorf("invalid checker '%s'; name an executable in %s or %s<name> on PATH", name, checkersDir, checkerPrefix)
+	}
+	local := filepath.Join(checkersDir, name)
+	if info, err := os.Stat(local); err == nil && !info.IsDir() {
+		return local, nil
+	}
+	if strings.ContainsRune(name, filepath.Separator) || strings.ContainsRune(name, '/') {
+		return "", fmt.Errorf("checker '%s' not found in %s", name, checkersDir)
+	}
+	path, err := exec.LookPath(checkerPrefix + name)
+	if err != nil {
+		return "", fmt.Errorf("checker '%s' not found in %s, and no %s%s on PATH", name, checkersDir, checkerPrefix, name)
+	}
+	return path, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindExternalChecker(t *testing.T) {
+	configDir := t.TempDir()
+	pathDir := t.TempDir()
+	dataDirOverride = configDir
+	defer func() { dataDirOverride = "" }()
+	t.Setenv("PATH", pathDir)
+
+	checkersDir := filepath.Join(configDir, "checkers")
+	for _, file := range []string{
+		filepath.Join(checkersDir, "conjugation"),
+		filepath.Join(checkersDir, "llm", "grade"),
+		filepath.Join(configDir, "outside"),
+		filepath.Join(pathDir, checkerPrefix+"intervals"),
+		filepath.Join(pathDir, "ls"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		checker string
+		want    string
+		wantErr bool
+	}{
+		{"in checkers/", "conjugation", filepath.Join(checkersDir, "conjugation"), false},
+		{"in a subdirectory of checkers/", "llm/grade", filepath.Join(checkersDir, "llm", "grade"), false},
+		{"prefixed on PATH", "intervals", filepath.Join(pathDir, checkerPrefix+"intervals"), false},
+		{"unprefixed on PATH", "ls", "", true},
+		{"missing", "spelling", "", true},
+		{"absolute path", filepath.Join(configDir, "outside"), "", true},
+		{"parent directory", "../outside", "", true},
+		{"parent directory inside", "llm/../../outside", "", true},
+		{"empty", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findExternalChecker(tt.checker)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findExternalChecker(%q) error = %v, want error %v", tt.checker, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("findExternalChecker(%q) = %q, want %q", tt.checker, got, tt.want)
+			}
+		})
+	}
+}
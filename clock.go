@@ -0,0 +1,126 @@
+// clock.go
+//
+// Guards against system clock jumps (timezone misconfiguration, VM restores)
+// and the `doctor` command that finds and repairs records the clock left
+// behind in the future or out of order.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// clockSkewTolerance is how far a timestamp may lie in the future before it
+// is treated as produced by a wrong clock rather than ordinary jitter.
+const clockSkewTolerance = 5 * time.Minute
+
+// latestTimestamp returns the most recent moment recorded anywhere in a
+// player's data, or the zero time for a fresh player.
+func latestTimestamp(player PlayerData) time.Time {
+	var latest time.Time
+	for _, item := range player.History {
+		if item.Timestamp.After(latest) {
+			latest = item.Timestamp
+		}
+	}
+	for _, cardProgress := range player.Cards {
+		if cardProgress.LastReviewed.After(latest) {
+			latest = cardProgress.LastReviewed
+		}
+	}
+	return latest
+}
+
+// reviewTime returns the timestamp to record for a new answer. A record up
+// to clockSkewTolerance ahead of the system clock is reused, so that no
+// review precedes an earlier one and intervals never go negative. A record
+// further ahead was written by a wrong clock: carrying it forward would push
+// every later review into the future too, so the answer gets the current
+// time and doctor is left to repair the record.
+func reviewTime(player PlayerData) time.Time {
+	return reviewTimeAt(player, time.Now())
+}
+
+// reviewTimeAt is reviewTime with the system clock reading now.
+func reviewTimeAt(player PlayerData, now time.Time) time.Time {
+	latest := latestTimestamp(player)
+	if isFutureDated(latest, now) {
+		warnf("The latest recorded review is %s in the future; run 'doctor --repair' to fix it.", latest.Sub(now).Round(time.Second))
+		return now
+	}
+	if now.Before(latest) {
+		return latest
+	}
+	return now
+}
+
+// isFutureDated reports whether t lies beyond the tolerated clock skew.
+func isFutureDated(t, now time.Time) bool {
+	return t.After(now.Add(clockSkewTolerance))
+}
+
+// --- Command Handlers ---
+
+func handleDoctor(repair bool) {
+	allProgress := loadAllProgress()
+	now := time.Now()
+	issues := 0
+
+	for id, player := range allProgress {
+		futureHistory := 0
+		for i, item := range player.History {
+			if isFutureDated(item.Timestamp, now) {
+				futureHistory++
+				player.History[i].Timestamp = now
+			}
+		}
+		futureCards := 0
+		for cardID, cardProgress := range player.Cards {
+			if isFutureDated(cardProgress.LastReviewed, now) {
+				futureCards++
+				cardProgress.LastReviewed = now
+				player.Cards[cardID] = cardProgress
+			}
+		}
+		outOfOrder := !sort.SliceIsSorted(player.History, func(i, j int) bool {
+			return player.History[i].Timestamp.Before(player.History[j].Timestamp)
+		})
+		if outOfOrder {
+			sort.SliceStable(player.History, func(i, j int) bool {
+				return player.History[i].Timestamp.Before(player.History[j].Timestamp)
+			})
+		}
+
+		if futureHistory == 0 && futureCards == 0 && !outOfOrder {
+			continue
+		}
+		fmt.Printf(tr("Player %s (%s):\n"), player.Name, id)
+		if futureHistory > 0 {
+			fmt.Printf(tr("  future-dated history entries: %d\n"), futureHistory)
+		}
+		if futureCards > 0 {
+			fmt.Printf(tr("  cards reviewed in the future: %d\n"), futureCards)
+		}
+		if outOfOrder {
+			fmt.Println(tr("  history entries out of chronological order"))
+		}
+		issues += futureHistory + futureCards
+		if outOfOrder {
+			issues++
+		}
+		allProgress[id] = player
+	}
+
+	if issues == 0 {
+		fmt.Println(tr("No problems found."))
+		return
+	}
+	if !repair {
+		fmt.Printf(tr("\nFound %d problem(s). Run 'doctor --repair' to fix them.\n"), issues)
+		return
+	}
+	saveAllProgress(allProgress)
+	fmt.Printf(tr("\nRepaired %d problem(s).\n"), issues)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReviewTimeAt(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		latest time.Time
+		want   time.Time
+	}{
+		{"fresh player", time.Time{}, now},
+		{"latest in the past", now.Add(-time.Hour), now},
+		{"latest is now", now, now},
+		{"latest within the skew tolerance", now.Add(time.Minute), now.Add(time.Minute)},
+		{"latest at the skew tolerance", now.Add(clockSkewTolerance), now.Add(clockSkewTolerance)},
+		{"latest past the skew tolerance", now.Add(clockSkewTolerance + time.Second), now},
+		{"latest a year ahead", now.AddDate(1, 0, 0), now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var player PlayerData
+			if !tt.latest.IsZero() {
+				player.History = []AnswerLogItem{{CardID: "c1", Timestamp: tt.latest}}
+			}
+			if got := reviewTimeAt(player, now); !got.Equal(tt.want) {
+				t.Errorf("reviewTimeAt = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReviewTimeAtCards(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	ahead := now.Add(2 * time.Minute)
+	player := PlayerData{
+		History: []AnswerLogItem{{CardID: "c1", Timestamp: now.Add(-time.Hour)}},
+		Cards:   map[string]CardProgress{"c1": {Box: 2, LastReviewed: ahead}},
+	}
+	if got := reviewTimeAt(player, now); !got.Equal(ahead) {
+		t.Errorf("reviewTimeAt = %v, want the card's last review %v", got, ahead)
+	}
+}
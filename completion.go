@@ -0,0 +1,168 @@
+// completion.go
+//
+// What get-card answers when it has nothing to serve. Instead of a card it
+// returns {"status": "done", ...} with a message, when the next card comes
+// due and a summary of the day's reviews, so a frontend can say "back in
+// 3h" instead of just stopping.
+//
+// By default get-card is done once every card is retired. With
+// {"scheduler": {"due_only": true}} it is done for the day once no card has
+// gone its box's interval without a review; cards never answered are always
+// due. get-card --allow-review-ahead (?review_ahead=true on the API) keeps
+// going after that: first with cards that aren't due yet, then with retired
+// cards. Answers to retired cards count as practice, so they stay retired.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Messages of the done response.
+const (
+	masteredPrompt = "Congratulations, you have mastered all cards!"
+	doneForToday   = "All done for today!"
+	nothingToServe = "No cards left in rotation."
+)
+
+// StatusDone is the status of the done response.
+const StatusDone = "done"
+
+// DoneView is what get-card returns when there is no card to serve.
+type DoneView struct {
+	Status string `json:"status"`
+	// Message is in English, like all JSON output; terminal output
+	// translates it.
+	Message string `json:"message"`
+	// DueIn is how long until the next card comes due, like "3h" or "2d",
+	// and NextDue when that is; both are left out if no card will.
+	DueIn   string     `json:"due_in,omitempty"`
+	NextDue *time.Time `json:"next_due,omitempty"`
+	// Mastered is set when every card is retired.
+	Mastered bool         `json:"mastered,omitempty"`
+	Today    TodaySummary `json:"today"`
+}
+
+// TodaySummary counts the player's answers since midnight.
+type TodaySummary struct {
+	Reviews int `json:"reviews"`
+	Correct int `json:"correct"`
+	// Cards is the number of different cards answered.
+	Cards    int `json:"cards"`
+	Practice int `json:"practice,omitempty"`
+}
+
+// dueCards returns the cards in rotation that are due at now, new cards
+// included.
+func dueCards(cards []Card, player PlayerData, config SchedulerConfig, now time.Time) []Card {
+	var due []Card
+	for _, card := range cards {
+		progress := player.Cards[card.ID]
+		if inRotation(progress) && (progress.Passed+progress.Failed == 0 || isDue(progress, config, now)) {
+			due = append(due, card)
+		}
+	}
+	return due
+}
+
+// reviewAheadCard picks a card to serve when none is due: one not due yet,
+// or else a retired one, drawn as if it were back in box 5.
+func reviewAheadCard(cards []Card, player PlayerData, recent []string, config SchedulerConfig, now time.Time, rng *rand.Rand) (Card, int, bool) {
+	if config.DueOnly {
+		if card, box, ok := selectCard(cards, player, recent, config, now, rng); ok {
+			return card, box, true
+		}
+	}
+	mastered := player
+	mastered.Cards = make(map[string]CardProgress, len(player.Cards))
+	for id, progress := range player.Cards {
+		if progress.Retired {
+			progress.Retired = false
+			progress.Box = topBox
+			mastered.Cards[id] = progress
+		}
+	}
+	return selectCard(cards, mastered, recent, config, now, rng)
+}
+
+// doneView describes why there is nothing to serve to player from cards,
+// the deck without the player's skipped cards.
+func doneView(player PlayerData, cards []Card, config SchedulerConfig, now time.Time) DoneView {
+	view := DoneView{Status: StatusDone, Message: nothingToServe, Mastered: len(cards) > 0}
+	var next time.Time
+	for _, card := range cards {
+		progress := player.Cards[card.ID]
+		if !progress.Retired {
+			view.Mastered = false
+		}
+		if !inRotation(progress) {
+			continue
+		}
+		dueOn := calendarDay(progress.LastReviewed).AddDate(0, 0, config.boxIntervalDays(progress.Box))
+		if next.IsZero() || dueOn.Before(next) {
+			next = dueOn
+		}
+	}
+	switch {
+	case view.Mastered:
+		view.Message = masteredPrompt
+	case !next.IsZero():
+		// next is a calendar date; the card comes due at its local midnight
+		due := midnight(next, now.Location())
+		view.Message = doneForToday
+		view.NextDue = &due
+		view.DueIn = formatDueIn(due.Sub(now))
+	}
+	view.Today = todaySummary(player, now)
+	return view
+}
+
+// --- Helpers ---
+
+func todaySummary(player PlayerData, now time.Time) TodaySummary {
+	var summary TodaySummary
+	start := midnight(now, now.Location())
+	answered := make(map[string]bool)
+	for _, item := range player.History {
+		if item.Timestamp.Before(start) || isFutureDated(item.Timestamp, now) {
+			continue
+		}
+		summary.Reviews++
+		if item.Correct {
+			summary.Correct++
+		}
+		answered[item.CardID] = true
+	}
+	summary.Cards = len(answered)
+	for _, item := range player.Practice {
+		if !item.Timestamp.Before(start) && !isFutureDated(item.Timestamp, now) {
+			summary.Practice++
+		}
+	}
+	return summary
+}
+
+// midnight returns the start of day's calendar date in loc. Unlike
+// calendarDay, whose dates are only good for comparing with each other, it
+// is a real instant.
+func midnight(day time.Time, loc *time.Location) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+}
+
+// formatDueIn rounds a wait up to whole minutes, hours or, from two days
+// on, days.
+func formatDueIn(wait time.Duration) string {
+	switch {
+	case wait <= 0:
+		return "0m"
+	case wait < time.Hour:
+		return fmt.Sprintf("%dm", int(math.Ceil(wait.Minutes())))
+	case wait < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(math.Ceil(wait.Hours())))
+	default:
+		return fmt.Sprintf("%dd", int(math.Ceil(wait.Hours()/24)))
+	}
+}
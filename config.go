@@ -0,0 +1,61 @@
+// config.go
+//
+// Optional user settings read from config.json next to cards.json. Every
+// field has a sensible zero value, so a missing file means defaults.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Config holds the user-editable settings from config.json.
+type Config struct {
+	// Locale overrides the locale detected from the environment for
+	// messages and for formatting numbers, dates and durations (e.g.
+	// "de_DE", "fr").
+	Locale string `json:"locale,omitempty"`
+	// Telemetry is strictly opt-in; see telemetry.go for what is collected.
+	Telemetry TelemetryConfig `json:"telemetry,omitempty"`
+	// CardTypes registers card type plugins by name, overriding the
+	// decouvertes-cardtype-* executables found on PATH.
+	CardTypes map[string]string `json:"card_types,omitempty"`
+	// Scheduler tunes how get-card picks cards.
+	Scheduler SchedulerConfig `json:"scheduler,omitempty"`
+	// Writing configures writing practice.
+	Writing WritingConfig `json:"writing,omitempty"`
+	// Decay demotes cards that haven't been reviewed for a long time.
+	Decay DecayConfig `json:"decay,omitempty"`
+	// Report configures how the report command sends mail.
+	Report ReportConfig `json:"report,omitempty"`
+	// Webhooks are notified of milestones and other events.
+	Webhooks []Webhook `json:"webhooks,omitempty"`
+	// Vault is the notes folder watch takes flashcards from.
+	Vault VaultConfig `json:"vault,omitempty"`
+	// Speech sets up spoken answers.
+	Speech SpeechConfig `json:"speech,omitempty"`
+	// Input sets up typing helpers per card language, like "fr" or "ja".
+	Input map[string]InputConfig `json:"input,omitempty"`
+}
+
+func loadConfig() Config {
+	var config Config
+	filePath := filepath.Join(getConfigDir(), "config.json")
+	file, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config
+		}
+		fatalf("Error reading config file (%s): %v", filePath, err)
+	}
+	if len(file) == 0 {
+		return config
+	}
+	if err := json.Unmarshal(file, &config); err != nil {
+		fatalf("Error unmarshalling config JSON: %v", err)
+	}
+	return config
+}
@@ -0,0 +1,201 @@
+// daily.go
+//
+// The daily challenge: every player of the same deck gets the same cards on
+// the same day, picked deterministically from the date and a hash of the
+// deck, and competes on a per-day leaderboard. Like exams and duels it
+// leaves the Leitner boxes alone.
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DailyEntry is one player's result on a daily challenge.
+type DailyEntry struct {
+	PlayerID string        `json:"player_id"`
+	Name     string        `json:"name"`
+	Score    int           `json:"score"`
+	Total    int           `json:"total"`
+	Duration time.Duration `json:"duration"`
+	PlayedAt time.Time     `json:"played_at"`
+}
+
+// --- Command Handlers ---
+
+func handleDaily(playerID string, count int) {
+	cards := loadCards()
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	if len(cards) == 0 {
+		fatal("The deck has no cards for a daily challenge.")
+	}
+
+	date := time.Now().Format("2006-01-02")
+	deckHash := hashDeck(cards)
+	key := dailyKey(date, deckHash, count)
+	leaderboards := loadDailyLeaderboards()
+	for _, entry := range leaderboards[key] {
+		if entry.PlayerID == playerID {
+			fmt.Printf(tr("%s already played today's challenge (%d/%d).\n\n"), player.Name, entry.Score, entry.Total)
+			printDailyLeaderboard(date, leaderboards[key], resolveLocale(player.Locale))
+			return
+		}
+	}
+
+	challenge := dailyCards(cards, date, deckHash, count)
+	reader := bufio.NewReader(os.Stdin)
+	entry := DailyEntry{
+		PlayerID: playerID,
+		Name:     player.Name,
+		Total:    len(challenge),
+		PlayedAt: time.Now(),
+	}
+
+	fmt.Printf(tr("Daily challenge %s: %d card(s), same for everyone on this deck.\n"), date, len(challenge))
+	for i, card := range challenge {
+		fmt.Printf(tr("\nCard %d/%d\n"), i+1, len(challenge))
+		answer, ok, err := askCard(reader, card)
+		if err != nil {
+			warnf("Skipping card '%s': %v", card.ID, err)
+			continue
+		}
+		if !ok {
+			fmt.Println(tr("\nInput closed, remaining cards count as wrong."))
+			break
+		}
+		correct, err := isAnswerCorrect(card, answer)
+		if err != nil {
+			warnf("Could not check the answer to card '%s': %v", card.ID, err)
+		}
+		if correct {
+			entry.Score++
+			fmt.Println(tr("Correct!"))
+		} else {
+			fmt.Printf(tr("Incorrect. The correct answer was: %s\n"), card.Solution)
+		}
+	}
+	entry.Duration = time.Since(entry.PlayedAt)
+
+	leaderboards[key] = append(leaderboards[key], entry)
+	saveDailyLeaderboards(leaderboards)
+
+	fmt.Printf(tr("\nYou scored %d/%d.\n\n"), entry.Score, entry.Total)
+	printDailyLeaderboard(date, leaderboards[key], resolveLocale(player.Locale))
+}
+
+func handleDailyLeaderboard(date string, count int) {
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		fatalf("Invalid date '%s', expected YYYY-MM-DD.", date)
+	}
+	key := dailyKey(date, hashDeck(loadCards()), count)
+	printDailyLeaderboard(date, loadDailyLeaderboards()[key], resolveLocale(""))
+}
+
+// --- Helpers ---
+
+// dailyCards deterministically picks count cards for date. The deck is put
+// in ID order first so the selection doesn't depend on file order.
+func dailyCards(cards []Card, date, deckHash string, count int) []Card {
+	sorted := make([]Card, len(cards))
+	copy(sorted, cards)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	sum := sha256.Sum256([]byte(date + "/" + deckHash))
+	rng := rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(sum[:8]))))
+	if count > len(sorted) {
+		count = len(sorted)
+	}
+	challenge := make([]Card, count)
+	for i, idx := range rng.Perm(len(sorted))[:count] {
+		challenge[i] = sorted[idx]
+	}
+	return challenge
+}
+
+// hashDeck fingerprints the deck content independently of card order, so
+// everyone with the same cards shares a leaderboard.
+func hashDeck(cards []Card) string {
+	sorted := make([]Card, len(cards))
+	copy(sorted, cards)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	data, err := json.Marshal(sorted)
+	if err != nil {
+		fatalf("Error marshalling deck for hashing: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// dailyKey identifies a challenge; different lengths are ranked separately.
+func dailyKey(date, deckHash string, count int) string {
+	return fmt.Sprintf("%s/%s/%d", date, deckHash, count)
+}
+
+func printDailyLeaderboard(date string, entries []DailyEntry, loc Locale) {
+	fmt.Printf(tr("Daily Leaderboard %s\n"), date)
+	fmt.Println("-------------------------")
+	if len(entries) == 0 {
+		fmt.Println(tr("Nobody has played this challenge yet."))
+		return
+	}
+	ranked := make([]DailyEntry, len(entries))
+	copy(ranked, entries)
+	// Best score first, faster time breaks ties
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Duration < ranked[j].Duration
+	})
+	for i, entry := range ranked {
+		fmt.Printf("%2d. %-16s %d/%d  %s\n", i+1, entry.Name, entry.Score, entry.Total, loc.Duration(entry.Duration))
+	}
+}
+
+func loadDailyLeaderboards() map[string][]DailyEntry {
+	leaderboards := make(map[string][]DailyEntry)
+	filePath := filepath.Join(getDataDir(), "daily.json")
+	file, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return leaderboards
+		}
+		fatalf("Error reading daily leaderboard (%s): %v", filePath, err)
+	}
+	if len(file) == 0 {
+		return leaderboards
+	}
+	if err := json.Unmarshal(file, &leaderboards); err != nil {
+		fatalf("Error unmarshalling daily leaderboard JSON: %v", err)
+	}
+	return leaderboards
+}
+
+func saveDailyLeaderboards(leaderboards map[string][]DailyEntry) {
+	filePath := filepath.Join(getDataDir(), "daily.json")
+	data, err := json.MarshalIndent(leaderboards, "", "  ")
+	if err != nil {
+		fatalf("Error marshalling daily leaderboard to JSON: %v", err)
+	}
+	if err := ioutil.WriteFile(filePath, data, 0644); err != nil {
+		fatalf("Error writing daily leaderboard (%s): %v", filePath, err)
+	}
+}
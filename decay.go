@@ -0,0 +1,125 @@
+// decay.go
+//
+// Demotion of stale cards. A card that sits in a high box for months after
+// its last review says more about the absence than about the player's
+// memory, so with decay enabled every full period without a review drops
+// it one box (never below box 1). Retired cards are left alone; they are
+// out of rotation and have no review to go stale.
+//
+// Decay is applied by the decay command, or on every load with on_load.
+// CardProgress.Decayed records how many periods have already been applied,
+// so applying it again is harmless.
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// DecayConfig is the "decay" block of config.json.
+type DecayConfig struct {
+	// AfterDays is how long a card may go unreviewed before it drops a box;
+	// each further AfterDays drops another. 0 turns decay off.
+	AfterDays int `json:"after_days,omitempty"`
+	// OnLoad applies decay whenever progress is read, instead of only when
+	// the decay command runs.
+	OnLoad bool `json:"on_load,omitempty"`
+}
+
+// Demotion is one card moved down by decay.
+type Demotion struct {
+	CardID   string
+	From, To int
+}
+
+// decayPlayer demotes the player's stale cards as of now.
+func decayPlayer(player *PlayerData, afterDays int, now time.Time) []Demotion {
+	if afterDays <= 0 {
+		return nil
+	}
+	period := time.Duration(afterDays) * 24 * time.Hour
+	var demotions []Demotion
+	for id, progress := range player.Cards {
+		if progress.Box < 2 || progress.Retired {
+			continue
+		}
+		due := int(now.Sub(progress.LastReviewed)/period) - progress.Decayed
+		if due <= 0 {
+			continue
+		}
+		demotions = append(demotions, Demotion{CardID: id, From: progress.Box, To: max(progress.Box-due, 1)})
+		progress.Box = max(progress.Box-due, 1)
+		progress.Decayed += due
+		progress.TopBoxPasses = 0
+		player.Cards[id] = progress
+	}
+	sort.Slice(demotions, func(i, j int) bool { return demotions[i].CardID < demotions[j].CardID })
+	return demotions
+}
+
+// applyDecayOnLoad runs decay on freshly loaded progress if on_load is set.
+func applyDecayOnLoad(allProgress map[string]PlayerData) {
+	config := loadConfig().Decay
+	if !config.OnLoad || config.AfterDays <= 0 {
+		return
+	}
+	now := time.Now()
+	for id, player := range allProgress {
+		if demotions := decayPlayer(&player, config.AfterDays, now); len(demotions) > 0 {
+			slog.Debug("Demoted stale cards", "player", id, "cards", len(demotions))
+			allProgress[id] = player
+		}
+	}
+}
+
+// --- Command Handlers ---
+
+func handleDecay(playerID string, afterDays int, dryRun bool) {
+	if afterDays <= 0 {
+		afterDays = loadConfig().Decay.AfterDays
+	}
+	if afterDays <= 0 {
+		fatal("no decay period set; pass --after-days or set decay.after_days in config.json")
+	}
+
+	allProgress := readAllProgress()
+	ids := make([]string, 0, len(allProgress))
+	for id := range allProgress {
+		if playerID == "" || id == playerID {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 && playerID != "" {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	sort.Strings(ids)
+
+	now := time.Now()
+	total := 0
+	for _, id := range ids {
+		player := allProgress[id]
+		demotions := decayPlayer(&player, afterDays, now)
+		if len(demotions) == 0 {
+			continue
+		}
+		fmt.Printf(tr("Player %s (%s):\n"), player.Name, id)
+		for _, d := range demotions {
+			fmt.Printf(tr("  %s: box %d -> %d\n"), d.CardID, d.From, d.To)
+		}
+		total += len(demotions)
+		allProgress[id] = player
+	}
+
+	switch {
+	case total == 0:
+		fmt.Printf(tr("No cards have gone %d days without a review.\n"), afterDays)
+	case dryRun:
+		fmt.Printf(tr("\n%d card(s) would be demoted.\n"), total)
+	default:
+		saveAllProgress(allProgress)
+		fmt.Printf(tr("\nDemoted %d card(s).\n"), total)
+	}
+}
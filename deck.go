@@ -0,0 +1,224 @@
+// deck.go
+//
+// Deck files. cards.json is either a plain array of cards or an object that
+// carries deck-wide options next to the cards:
+//
+//	{ "normalization": { "case_sensitive": true }, "cards": [ ... ] }
+//
+// A deck can also be written in Markdown as cards.md (see markdowndeck.go),
+// which is read when there is no cards.json.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// Whitespace handling modes for NormalizationOptions.Whitespace.
+const (
+	WhitespaceRemove   = "" // default: drop all whitespace ("a = 1" == "a=1")
+	WhitespaceCollapse = "collapse"
+	WhitespaceKeep     = "keep"
+)
+
+// Unicode normalization forms for NormalizationOptions.Unicode.
+const (
+	UnicodeNFKC = "" // default: also folds full-width and other compatibility forms
+	UnicodeNFC  = "nfc"
+)
+
+// NormalizationOptions controls how answers are normalized before they are
+// compared with the solution. The zero value is the historical behavior:
+// case-insensitive, whitespace removed, trailing semicolons ignored, with
+// Unicode compatibility forms folded (NFKC).
+type NormalizationOptions struct {
+	// Exact disables normalization altogether.
+	Exact            bool `json:"exact,omitempty"`
+	CaseSensitive    bool `json:"case_sensitive,omitempty"`
+	StripPunctuation bool `json:"strip_punctuation,omitempty"`
+	// OptionalArticles are words that may be left out, e.g. ["le", "la",
+	// "les", "l'"]. Entries ending in an apostrophe match elided prefixes.
+	OptionalArticles []string `json:"optional_articles,omitempty"`
+	Whitespace       string   `json:"whitespace,omitempty"`
+	KeepSemicolon    bool     `json:"keep_semicolon,omitempty"`
+	// Unicode selects the normalization form. "nfc" only unifies composed
+	// and decomposed accents and keeps full-width characters distinct.
+	Unicode string `json:"unicode,omitempty"`
+}
+
+// Deck is the parsed content of a deck file.
+type Deck struct {
+	// Schema is the URL of the JSON Schema editors check the deck against
+	// (see schema.go); it's otherwise ignored.
+	Schema        string               `json:"$schema,omitempty"`
+	Version       int                  `json:"version,omitempty"`
+	Normalization NormalizationOptions `json:"normalization"`
+	// GenerateReverse adds a solution-to-prompt card for every card (see
+	// reverse.go).
+	GenerateReverse bool   `json:"generate_reverse,omitempty"`
+	Cards           []Card `json:"cards"`
+
+	// deleted are the tombstones of deleted cards, taken out of Cards.
+	deleted []Card
+}
+
+func loadDeck() Deck {
+	if cache != nil {
+		return cache.deck()
+	}
+	return readDeckFile()
+}
+
+func readDeckFile() Deck {
+	configDir := getConfigDir()
+	filePath := deckPath()
+	if _, err := os.Stat(configDir); os.IsNotExist(err) {
+		fatalf("Config directory not found at %s. Run 'decouvertes init' to create it with a starter deck.", configDir)
+	}
+	file, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		fatalf("Error reading file (%s): %v.", filePath, err)
+	}
+	deck, err := decodeDeck(filePath, file)
+	if err != nil {
+		fatalf("Error reading deck: %v", err)
+	}
+	// Cards synced from a notes folder by watch (see vault.go)
+	deck.Cards = append(deck.Cards, readVaultCards()...)
+
+	if deck.Cards, err = expandTemplates(deck.Cards); err != nil {
+		fatalf("Error in deck template: %v", err)
+	}
+	if deck.GenerateReverse {
+		if deck.Cards, err = addReverseCards(deck.Cards); err != nil {
+			fatalf("Error generating reverse cards: %v", err)
+		}
+	}
+
+	switch deck.Normalization.Whitespace {
+	case WhitespaceRemove, WhitespaceCollapse, WhitespaceKeep:
+	default:
+		fatalf("Unknown whitespace mode '%s' in deck normalization options.", deck.Normalization.Whitespace)
+	}
+	switch deck.Normalization.Unicode {
+	case UnicodeNFKC, UnicodeNFC:
+	default:
+		fatalf("Unknown Unicode form '%s' in deck normalization options.", deck.Normalization.Unicode)
+	}
+	for i := range deck.Cards {
+		deck.Cards[i].normalization = deck.Normalization
+	}
+	slog.Debug("Read deck", "path", filePath, "cards", len(deck.Cards))
+	return deck
+}
+
+// decodeDeck parses a deck file as it is written: templates are not
+// expanded and no reverse cards are added. Markdown decks are told apart by
+// their extension.
+func decodeDeck(filePath string, data []byte) (Deck, error) {
+	if isMarkdownDeck(filePath) {
+		deck, err := parseMarkdownDeck(data)
+		if err != nil {
+			return Deck{}, fmt.Errorf("%s: %w", filepath.Base(filePath), err)
+		}
+		return deck, nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return Deck{}, fmt.Errorf("%s: %w", filepath.Base(filePath), jsonSyntaxError(data, err))
+	}
+	// Deck files are the user's own, so upgrades only happen in memory
+	doc, err := migrate(filePath, doc, deckVersion(doc), deckFormat, deckMigrations)
+	if err != nil {
+		return Deck{}, err
+	}
+	deck, err := decodeDeckStrict(doc)
+	if err != nil {
+		return Deck{}, fmt.Errorf("%s: %w", filepath.Base(filePath), err)
+	}
+	live := deck.Cards[:0]
+	for _, card := range deck.Cards {
+		if card.DeletedAt != nil {
+			deck.deleted = append(deck.deleted, card)
+		} else {
+			live = append(live, card)
+		}
+	}
+	deck.Cards = live
+	return deck, nil
+}
+
+// rawDeck is a JSON deck file read for rewriting. Cards and the fields
+// around them are kept as they were written, except for the cards that are
+// changed.
+type rawDeck struct {
+	path string
+	// object holds the other fields of a deck object; nil for a bare list
+	// of cards.
+	object map[string]json.RawMessage
+	cards  []json.RawMessage
+}
+
+func readRawDeck(filePath string) rawDeck {
+	if isMarkdownDeck(filePath) {
+		fatalf("%s is a Markdown deck; change its cards in the file itself.", filePath)
+	}
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		fatalf("Error reading file (%s): %v.", filePath, err)
+	}
+	deck := rawDeck{path: filePath}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(data, &deck.cards); err != nil {
+			fatalf("Error unmarshalling cards JSON: %v", err)
+		}
+		return deck
+	}
+	if err := json.Unmarshal(data, &deck.object); err != nil {
+		fatalf("Error unmarshalling cards JSON: %v", err)
+	}
+	if raw, ok := deck.object["cards"]; ok {
+		if err := json.Unmarshal(raw, &deck.cards); err != nil {
+			fatalf("Error unmarshalling cards JSON: %v", err)
+		}
+	}
+	return deck
+}
+
+// index returns the position of the card with the given ID, or -1.
+func (d rawDeck) index(id string) int {
+	for i, raw := range d.cards {
+		var card struct {
+			ID string `json:"id"`
+		}
+		if json.Unmarshal(raw, &card) == nil && card.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (d rawDeck) write() {
+	var out interface{} = d.cards
+	if d.object != nil {
+		encoded, err := json.Marshal(d.cards)
+		if err != nil {
+			fatalf("Error marshalling cards to JSON: %v", err)
+		}
+		d.object["cards"] = encoded
+		out = d.object
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		fatalf("Error marshalling cards to JSON: %v", err)
+	}
+	if err := ioutil.WriteFile(d.path, append(data, '\n'), 0644); err != nil {
+		fatalf("Error writing deck (%s): %v", d.path, err)
+	}
+}
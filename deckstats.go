@@ -0,0 +1,266 @@
+// deckstats.go
+//
+// Deck statistics for deck authors, independent of any player: what the
+// deck holds per language and tag, how long prompts and solutions are, and
+// what looks wrong with it. Prompts are compared within a language after
+// folding case and accents; near-duplicates are prompts at most a tenth
+// apart by edit distance. A tag is a coverage gap for a language when at
+// least half of the deck's languages have cards with it and this one has
+// none.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// minSimilarLength keeps short prompts, where one letter is a real
+	// difference, out of the near-duplicate check.
+	minSimilarLength = 12
+	// maxSimilarLength bounds the cost of comparing long prompts.
+	maxSimilarLength = 300
+)
+
+// DeckStats is the report of deck-stats.
+type DeckStats struct {
+	Cards             int         `json:"cards"`
+	Languages         []DeckCount `json:"languages"`
+	Tags              []DeckCount `json:"tags"`
+	AvgPromptLength   float64     `json:"avg_prompt_length"`
+	AvgSolutionLength float64     `json:"avg_solution_length"`
+	// Problems and hints; every list holds card IDs unless noted.
+	DuplicateIDs     []string      `json:"duplicate_ids"`
+	DuplicatePrompts [][]string    `json:"duplicate_prompts"`
+	SimilarPrompts   [][2]string   `json:"similar_prompts"`
+	EmptySolutions   []string      `json:"empty_solutions"`
+	Untagged         []string      `json:"untagged"`
+	SingleUseTags    []string      `json:"single_use_tags"` // tag names
+	CoverageGaps     []CoverageGap `json:"coverage_gaps"`
+}
+
+// DeckCount is the number of cards with a language or tag.
+type DeckCount struct {
+	Name  string `json:"name"`
+	Cards int    `json:"cards"`
+}
+
+// CoverageGap is a tag most languages have cards for, and the languages
+// that don't.
+type CoverageGap struct {
+	Tag     string   `json:"tag"`
+	Missing []string `json:"missing"`
+}
+
+// buildDeckStats analyzes cards.
+func buildDeckStats(cards []Card) DeckStats {
+	stats := DeckStats{Cards: len(cards)}
+	languages := make(map[string]int)
+	tags := make(map[string]int)
+	tagLanguages := make(map[string]map[string]bool)
+	ids := make(map[string]int)
+	prompts := make(map[[2]string][]string)
+	byLanguage := make(map[string][]Card)
+	promptLength, solutionLength := 0, 0
+
+	for _, card := range cards {
+		languages[card.Language]++
+		byLanguage[card.Language] = append(byLanguage[card.Language], card)
+		for _, tag := range card.Tags {
+			tags[tag]++
+			if tagLanguages[tag] == nil {
+				tagLanguages[tag] = make(map[string]bool)
+			}
+			tagLanguages[tag][card.Language] = true
+		}
+		if ids[card.ID]++; ids[card.ID] == 2 {
+			stats.DuplicateIDs = append(stats.DuplicateIDs, card.ID)
+		}
+		key := [2]string{card.Language, strings.Join(strings.Fields(searchKey(card.Prompt)), " ")}
+		prompts[key] = append(prompts[key], card.ID)
+		if strings.TrimSpace(card.Solution) == "" && card.Type == "" && card.Checker == "" {
+			stats.EmptySolutions = append(stats.EmptySolutions, card.ID)
+		}
+		if len(card.Tags) == 0 {
+			stats.Untagged = append(stats.Untagged, card.ID)
+		}
+		promptLength += utf8.RuneCountInString(card.Prompt)
+		solutionLength += utf8.RuneCountInString(card.Solution)
+	}
+	if len(cards) > 0 {
+		stats.AvgPromptLength = float64(promptLength) / float64(len(cards))
+		stats.AvgSolutionLength = float64(solutionLength) / float64(len(cards))
+	}
+	stats.Languages = sortedCounts(languages)
+	stats.Tags = sortedCounts(tags)
+
+	for _, ids := range prompts {
+		if len(ids) > 1 {
+			stats.DuplicatePrompts = append(stats.DuplicatePrompts, ids)
+		}
+	}
+	sort.Slice(stats.DuplicatePrompts, func(i, j int) bool { return stats.DuplicatePrompts[i][0] < stats.DuplicatePrompts[j][0] })
+	for _, language := range slices.Sorted(maps.Keys(byLanguage)) {
+		stats.SimilarPrompts = append(stats.SimilarPrompts, similarPrompts(byLanguage[language])...)
+	}
+
+	for _, tag := range stats.Tags {
+		if tag.Cards == 1 {
+			stats.SingleUseTags = append(stats.SingleUseTags, tag.Name)
+		}
+		covered := tagLanguages[tag.Name]
+		if len(languages) < 2 || len(covered) < 2 || len(covered)*2 < len(languages) || len(covered) == len(languages) {
+			continue
+		}
+		gap := CoverageGap{Tag: tag.Name}
+		for _, language := range stats.Languages {
+			if !covered[language.Name] {
+				gap.Missing = append(gap.Missing, language.Name)
+			}
+		}
+		stats.CoverageGaps = append(stats.CoverageGaps, gap)
+	}
+	return stats
+}
+
+// similarPrompts returns the pairs of cards whose prompts differ, but by
+// no more than a tenth of their length.
+func similarPrompts(cards []Card) [][2]string {
+	keys := make([][]rune, len(cards))
+	for i, card := range cards {
+		keys[i] = []rune(strings.Join(strings.Fields(searchKey(card.Prompt)), " "))
+	}
+	var pairs [][2]string
+	for i := range cards {
+		for j := i + 1; j < len(cards); j++ {
+			a, b := keys[i], keys[j]
+			longest := max(len(a), len(b))
+			if min(len(a), len(b)) < minSimilarLength || longest > maxSimilarLength || longest-min(len(a), len(b)) > longest/10 {
+				continue
+			}
+			if d := editDistance(a, b); d > 0 && d <= longest/10 {
+				pairs = append(pairs, [2]string{cards[i].ID, cards[j].ID})
+			}
+		}
+	}
+	return pairs
+}
+
+// --- Command Handlers ---
+
+func handleDeckStats(asJSON bool) {
+	stats := buildDeckStats(loadCards())
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(stats); err != nil {
+			fatalf("Error writing deck stats JSON: %v", err)
+		}
+		return
+	}
+
+	loc := resolveLocale("")
+	fmt.Printf(tr("Deck: %s cards in %s language(s) with %s tag(s)\n"), loc.Number(stats.Cards), loc.Number(len(stats.Languages)), loc.Number(len(stats.Tags)))
+	fmt.Printf(tr("Average length: prompt %s, solution %s characters\n"), loc.Float(stats.AvgPromptLength, 1), loc.Float(stats.AvgSolutionLength, 1))
+	fmt.Println(tr("\nLanguages:"))
+	printDeckCounts(stats.Languages, loc)
+	if len(stats.Tags) > 0 {
+		fmt.Println(tr("\nTags:"))
+		printDeckCounts(stats.Tags, loc)
+	}
+
+	problems := 0
+	section := func(title string, n int) bool {
+		if n == 0 {
+			return false
+		}
+		problems++
+		fmt.Printf("\n%s (%s):\n", tr(title), loc.Number(n))
+		return true
+	}
+	if section("Duplicate IDs", len(stats.DuplicateIDs)) {
+		fmt.Printf("  %s\n", strings.Join(stats.DuplicateIDs, ", "))
+	}
+	if section("Duplicate prompts", len(stats.DuplicatePrompts)) {
+		for _, ids := range stats.DuplicatePrompts {
+			fmt.Printf("  %s\n", strings.Join(ids, ", "))
+		}
+	}
+	if section("Similar prompts", len(stats.SimilarPrompts)) {
+		for _, pair := range stats.SimilarPrompts {
+			fmt.Printf("  %s ~ %s\n", pair[0], pair[1])
+		}
+	}
+	if section("Cards without a solution", len(stats.EmptySolutions)) {
+		fmt.Printf("  %s\n", strings.Join(stats.EmptySolutions, ", "))
+	}
+	if section("Cards without tags", len(stats.Untagged)) {
+		fmt.Printf("  %s\n", strings.Join(stats.Untagged, ", "))
+	}
+	if section("Tags on a single card, maybe typos", len(stats.SingleUseTags)) {
+		fmt.Printf("  %s\n", strings.Join(stats.SingleUseTags, ", "))
+	}
+	if section("Coverage gaps", len(stats.CoverageGaps)) {
+		for _, gap := range stats.CoverageGaps {
+			fmt.Printf(tr("  %s: none in %s\n"), gap.Tag, strings.Join(gap.Missing, ", "))
+		}
+	}
+	if problems == 0 {
+		fmt.Println(tr("\nNo problems found."))
+	}
+}
+
+func printDeckCounts(counts []DeckCount, loc Locale) {
+	width := 0
+	for _, count := range counts {
+		width = max(width, utf8.RuneCountInString(count.Name))
+	}
+	for _, count := range counts {
+		fmt.Printf("  %-*s %s\n", width, count.Name, loc.Number(count.Cards))
+	}
+}
+
+// --- Helpers ---
+
+// sortedCounts returns counts, most cards first.
+func sortedCounts(counts map[string]int) []DeckCount {
+	list := make([]DeckCount, 0, len(counts))
+	for name, n := range counts {
+		list = append(list, DeckCount{Name: name, Cards: n})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Cards != list[j].Cards {
+			return list[i].Cards > list[j].Cards
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
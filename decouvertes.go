@@ -12,13 +12,17 @@ import (
 	"flag"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"math/rand"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"time"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
 )
 
 // --- Structs for Data Modeling ---
@@ -30,6 +34,33 @@ type Card struct {
 	Tags     []string `json:"tags"`
 	Prompt   string   `json:"prompt"`
 	Solution string   `json:"solution"`
+	// Validation selects how answers are checked: "" (normalized match),
+	// "exact", "regex", "numeric" or "words".
+	Validation string `json:"validation,omitempty"`
+	// Pattern is the regular expression for "regex" validation; the
+	// solution itself is used when it's empty.
+	Pattern string `json:"pattern,omitempty"`
+	// Tolerance is the allowed deviation for "numeric" validation.
+	Tolerance float64 `json:"tolerance,omitempty"`
+	// Checker names a registered or external checker that replaces the
+	// validation mode (see checker.go).
+	Checker string `json:"checker,omitempty"`
+	// Type names a card type plugin that renders and grades the card
+	// (see cardtype.go); Data carries whatever that plugin needs.
+	Type string                 `json:"type,omitempty"`
+	Data map[string]interface{} `json:"data,omitempty"`
+	// Order ranks new cards when the scheduler introduces them by "order".
+	Order *int `json:"order,omitempty"`
+	// Variants makes the card a template that stands for one card per
+	// entry, with its {{placeholders}} filled in (see template.go).
+	Variants []map[string]string `json:"variants,omitempty"`
+	// DeletedAt marks a card deleted with delete-card. It stays in the deck
+	// file as a tombstone, so restore-card can bring it back (see
+	// revisions.go), but is left out of the game.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// normalization is inherited from the deck the card was loaded from.
+	normalization NormalizationOptions
 }
 
 // CardProgress represents the user's progress on a single card.
@@ -39,6 +70,15 @@ type CardProgress struct {
 	Passed       int       `json:"passed"`
 	Failed       int       `json:"failed"`
 	LastReviewed time.Time `json:"last_reviewed"`
+	// Decayed counts the boxes lost to decay since the last review.
+	Decayed int `json:"decayed,omitempty"`
+	// TopBoxPasses counts the passes in a row in box 5; enough of them
+	// retire the card.
+	TopBoxPasses int  `json:"top_box_passes,omitempty"`
+	Retired      bool `json:"retired,omitempty"`
+	// SchedulerState is kept for a scheduler plugin (see
+	// externalscheduler.go).
+	SchedulerState pluginState `json:"scheduler_state,omitempty"`
 }
 
 // AnswerLogItem records a single answer event.
@@ -46,14 +86,57 @@ type AnswerLogItem struct {
 	CardID    string    `json:"card_id"`
 	Timestamp time.Time `json:"timestamp"`
 	Correct   bool      `json:"correct"`
+	// Box is the box the card was in when answered; 0 in older records.
+	Box int `json:"box,omitempty"`
+	// Spoken marks answers given with check-answer --spoken (see spoken.go).
+	Spoken bool `json:"spoken,omitempty"`
 }
 
 // PlayerData holds all data for a single player.
 type PlayerData struct {
 	Name          string                  `json:"name"`
+	Locale        string                  `json:"locale,omitempty"`
 	TotalAnswered int                     `json:"total_answered"`
 	Cards         map[string]CardProgress `json:"cards"`
 	History       []AnswerLogItem         `json:"history"`
+	// Practice logs answers given with --practice; they never move cards between boxes.
+	Practice     []AnswerLogItem `json:"practice,omitempty"`
+	XP           int             `json:"xp,omitempty"`
+	Achievements []Achievement   `json:"achievements,omitempty"`
+	// RecentCards holds the last cards get-card served, newest last.
+	RecentCards []string `json:"recent_cards,omitempty"`
+	// Writing holds sentences from writing practice.
+	Writing []WritingEntry `json:"writing,omitempty"`
+	// Notes holds the player's own notes on cards, by card ID.
+	Notes map[string]string `json:"notes,omitempty"`
+	// Skipped holds the cards taken out of rotation and when.
+	Skipped map[string]time.Time `json:"skipped,omitempty"`
+	// Challenges holds the best timed challenge scores, by length in seconds.
+	Challenges map[string]ChallengeBest `json:"challenges,omitempty"`
+	// Goals are the player's daily and weekly study goals.
+	Goals Goals `json:"goals,omitempty"`
+	// Boosts are temporary changes to how often cards are drawn.
+	Boosts []Boost `json:"boosts,omitempty"`
+}
+
+// CardView is a card as get-card returns it, with the player's progress on
+// it so frontends can show context without another call.
+type CardView struct {
+	Card
+	Box       int `json:"box"`
+	Streak    int `json:"streak"`
+	TimesSeen int `json:"times_seen"`
+	Passed    int `json:"passed"`
+	Failed    int `json:"failed"`
+	// LastReviewed is omitted for cards that have never been answered.
+	LastReviewed *time.Time `json:"last_reviewed,omitempty"`
+	// Note is the player's own note on the card, see annotate-card.
+	Note string `json:"note,omitempty"`
+	// Explanation is why the card was picked, with get-card --explain.
+	Explanation *PickExplanation `json:"explanation,omitempty"`
+	// ReviewAhead is set for cards served with --allow-review-ahead once
+	// nothing was left to serve.
+	ReviewAhead bool `json:"review_ahead,omitempty"`
 }
 
 // CheckResult is the structure returned as JSON after checking an answer.
@@ -61,6 +144,40 @@ type CheckResult struct {
 	Correct  bool   `json:"correct"`
 	NewBox   int    `json:"new_box"`
 	Solution string `json:"solution"`
+	Practice bool   `json:"practice,omitempty"`
+	// Transcript is what the speech-to-text command heard, for spoken
+	// answers.
+	Transcript string `json:"transcript,omitempty"`
+	// Retired is set when this answer retired the card.
+	Retired bool `json:"retired,omitempty"`
+	// Diff shows where a wrong answer deviates from the solution.
+	Diff []DiffSegment `json:"diff,omitempty"`
+	// Feedback is an explanation from the card's checker, if it gave one.
+	Feedback        string   `json:"feedback,omitempty"`
+	XPGained        int      `json:"xp_gained,omitempty"`
+	NewAchievements []string `json:"new_achievements,omitempty"`
+	// DailyGoalRemaining and WeeklyNewRemaining are the reviews and new
+	// cards still missing for the player's goals, if they set any.
+	DailyGoalRemaining *int `json:"daily_goal_remaining,omitempty"`
+	WeeklyNewRemaining *int `json:"weekly_new_remaining,omitempty"`
+}
+
+// commands lists every subcommand, in the order they are offered in usage
+// messages.
+var commands = []string{
+	"get-card", "check-answer", "create-player", "list-players",
+	"delete-player", "get-stats", "archive-history", "backup",
+	"restore-backup", "doctor", "duel", "match-history", "set-locale",
+	"exam", "list-exams", "events", "serve", "daily", "seasons",
+	"telemetry", "card-types", "bonus", "writing", "progress-chart",
+	"repair-progress", "annotate-card", "skip-card", "unskip-card",
+	"list-skipped", "decay", "reactivate-card", "search-cards", "study",
+	"simulate", "create-token", "list-tokens", "revoke-token", "init",
+	"challenge", "history", "card-status", "report", "set-goal", "due",
+	"import", "convert-deck", "watch", "deck-stats",
+	"merge-progress", "boost-card", "deprioritize-card", "list-boosts",
+	"scheduler-state", "schema", "edit-card", "delete-card", "restore-card",
+	"card-history", "overview", "bench", "schedulers",
 }
 
 // --- Main Function: Entry Point ---
@@ -68,6 +185,17 @@ type CheckResult struct {
 func main() {
 	// Note: rand.Seed() is not needed in Go 1.20+
 
+	// Global flags come before the subcommand
+	globalFlags := flag.NewFlagSet("decouvertes", flag.ExitOnError)
+	verbose := globalFlags.Bool("verbose", false, "Show debug output such as files read and scheduling decisions.")
+	quiet := globalFlags.Bool("quiet", false, "Only show errors.")
+	dataDir := globalFlags.String("data-dir", "", "Keep config, deck and progress in this directory (default $DECOUVERTES_HOME, or the XDG directories).")
+	lang := globalFlags.String("lang", "", "Language of the output, e.g. fr, de or es (default from LC_ALL, LC_MESSAGES or LANG).")
+	cpuProfile := globalFlags.String("cpuprofile", "", "Write a CPU profile of the command to this file.")
+	memProfile := globalFlags.String("memprofile", "", "Write a memory profile to this file when the command ends.")
+	globalFlags.Parse(os.Args[1:])
+	os.Args = append(os.Args[:1], globalFlags.Args()...)
+
 	// Define our subcommands
 	getCardCmd := flag.NewFlagSet("get-card", flag.ExitOnError)
 	checkAnswerCmd := flag.NewFlagSet("check-answer", flag.ExitOnError)
@@ -75,40 +203,252 @@ func main() {
 	listPlayersCmd := flag.NewFlagSet("list-players", flag.ExitOnError)
 	deletePlayerCmd := flag.NewFlagSet("delete-player", flag.ExitOnError)
 	getStatsCmd := flag.NewFlagSet("get-stats", flag.ExitOnError)
+	archiveHistoryCmd := flag.NewFlagSet("archive-history", flag.ExitOnError)
+	backupCmd := flag.NewFlagSet("backup", flag.ExitOnError)
+	restoreBackupCmd := flag.NewFlagSet("restore-backup", flag.ExitOnError)
+	doctorCmd := flag.NewFlagSet("doctor", flag.ExitOnError)
+	duelCmd := flag.NewFlagSet("duel", flag.ExitOnError)
+	matchHistoryCmd := flag.NewFlagSet("match-history", flag.ExitOnError)
+	setLocaleCmd := flag.NewFlagSet("set-locale", flag.ExitOnError)
+	examCmd := flag.NewFlagSet("exam", flag.ExitOnError)
+	eventsCmd := flag.NewFlagSet("events", flag.ExitOnError)
+	listExamsCmd := flag.NewFlagSet("list-exams", flag.ExitOnError)
+	serveCmd := flag.NewFlagSet("serve", flag.ExitOnError)
+	dailyCmd := flag.NewFlagSet("daily", flag.ExitOnError)
+	seasonsCmd := flag.NewFlagSet("seasons", flag.ExitOnError)
+	telemetryCmd := flag.NewFlagSet("telemetry", flag.ExitOnError)
+	cardTypesCmd := flag.NewFlagSet("card-types", flag.ExitOnError)
+	bonusCmd := flag.NewFlagSet("bonus", flag.ExitOnError)
+	writingCmd := flag.NewFlagSet("writing", flag.ExitOnError)
+	progressChartCmd := flag.NewFlagSet("progress-chart", flag.ExitOnError)
+	repairProgressCmd := flag.NewFlagSet("repair-progress", flag.ExitOnError)
+	annotateCardCmd := flag.NewFlagSet("annotate-card", flag.ExitOnError)
+	skipCardCmd := flag.NewFlagSet("skip-card", flag.ExitOnError)
+	unskipCardCmd := flag.NewFlagSet("unskip-card", flag.ExitOnError)
+	listSkippedCmd := flag.NewFlagSet("list-skipped", flag.ExitOnError)
+	decayCmd := flag.NewFlagSet("decay", flag.ExitOnError)
+	reactivateCardCmd := flag.NewFlagSet("reactivate-card", flag.ExitOnError)
+	searchCardsCmd := flag.NewFlagSet("search-cards", flag.ExitOnError)
+	studyCmd := flag.NewFlagSet("study", flag.ExitOnError)
+	simulateCmd := flag.NewFlagSet("simulate", flag.ExitOnError)
+	createTokenCmd := flag.NewFlagSet("create-token", flag.ExitOnError)
+	listTokensCmd := flag.NewFlagSet("list-tokens", flag.ExitOnError)
+	revokeTokenCmd := flag.NewFlagSet("revoke-token", flag.ExitOnError)
+	initCmd := flag.NewFlagSet("init", flag.ExitOnError)
+	challengeCmd := flag.NewFlagSet("challenge", flag.ExitOnError)
+	historyCmd := flag.NewFlagSet("history", flag.ExitOnError)
+	cardStatusCmd := flag.NewFlagSet("card-status", flag.ExitOnError)
+	reportCmd := flag.NewFlagSet("report", flag.ExitOnError)
+	setGoalCmd := flag.NewFlagSet("set-goal", flag.ExitOnError)
+	dueCmd := flag.NewFlagSet("due", flag.ExitOnError)
+	importCmd := flag.NewFlagSet("import", flag.ExitOnError)
+	convertDeckCmd := flag.NewFlagSet("convert-deck", flag.ExitOnError)
+	watchCmd := flag.NewFlagSet("watch", flag.ExitOnError)
+	deckStatsCmd := flag.NewFlagSet("deck-stats", flag.ExitOnError)
+	mergeProgressCmd := flag.NewFlagSet("merge-progress", flag.ExitOnError)
+	boostCardCmd := flag.NewFlagSet("boost-card", flag.ExitOnError)
+	deprioritizeCardCmd := flag.NewFlagSet("deprioritize-card", flag.ExitOnError)
+	listBoostsCmd := flag.NewFlagSet("list-boosts", flag.ExitOnError)
+	schedulerStateCmd := flag.NewFlagSet("scheduler-state", flag.ExitOnError)
+	schemaCmd := flag.NewFlagSet("schema", flag.ExitOnError)
+	editCardCmd := flag.NewFlagSet("edit-card", flag.ExitOnError)
+	deleteCardCmd := flag.NewFlagSet("delete-card", flag.ExitOnError)
+	restoreCardCmd := flag.NewFlagSet("restore-card", flag.ExitOnError)
+	cardHistoryCmd := flag.NewFlagSet("card-history", flag.ExitOnError)
+	overviewCmd := flag.NewFlagSet("overview", flag.ExitOnError)
+	benchCmd := flag.NewFlagSet("bench", flag.ExitOnError)
+	schedulersCmd := flag.NewFlagSet("schedulers", flag.ExitOnError)
 
 	// Flags for commands that require a player ID
 	playerIDGet := getCardCmd.String("player-id", "", "The ID of the player (required).")
 	playerIDCheck := checkAnswerCmd.String("player-id", "", "The ID of the player (required).")
+	practiceGet := getCardCmd.Bool("practice", false, "Practice mode: don't add new cards to the player's boxes.")
+	reviewAheadGet := getCardCmd.Bool("allow-review-ahead", false, "Once nothing is due, keep serving cards that aren't due yet and retired cards.")
+	seedGet := getCardCmd.Int64("seed", 0, "Seed for card selection, to reproduce a pick (default random).")
+	explainGet := getCardCmd.Bool("explain", false, "Include why the scheduler picked the card.")
+	practiceCheck := checkAnswerCmd.Bool("practice", false, "Practice mode: log the answer separately and leave boxes and streaks unchanged.")
 	playerIDDelete := deletePlayerCmd.String("player-id", "", "The ID of the player to delete (required).")
 	playerIDStats := getStatsCmd.String("player-id", "", "The ID of the player to get stats for (required).")
+	playerIDArchive := archiveHistoryCmd.String("player-id", "", "The ID of the player whose history to archive (required).")
+	playerIDDuelA := duelCmd.String("player-a", "", "The ID of the first player (required).")
+	playerIDDuelB := duelCmd.String("player-b", "", "The ID of the second player (required).")
+	playerIDMatches := matchHistoryCmd.String("player-id", "", "Only show duels this player took part in.")
+	playerIDLocale := setLocaleCmd.String("player-id", "", "The ID of the player (required).")
+	playerIDExam := examCmd.String("player-id", "", "The ID of the player taking the exam (required).")
+	playerIDExams := listExamsCmd.String("player-id", "", "The ID of the player whose exams to list (required).")
+	playerIDEvents := eventsCmd.String("player-id", "", "Only show events of this player.")
+	playerIDDaily := dailyCmd.String("player-id", "", "The ID of the player (required unless --leaderboard is given).")
+	playerIDBonus := bonusCmd.String("player-id", "", "The ID of the player (required).")
+	playerIDWriting := writingCmd.String("player-id", "", "The ID of the player (required).")
+	playerIDChart := progressChartCmd.String("player-id", "", "The ID of the player (required).")
+	playerIDAnnotate := annotateCardCmd.String("player-id", "", "The ID of the player (required).")
+	playerIDSkip := skipCardCmd.String("player-id", "", "The ID of the player (required).")
+	playerIDUnskip := unskipCardCmd.String("player-id", "", "The ID of the player (required).")
+	playerIDSkipped := listSkippedCmd.String("player-id", "", "The ID of the player (required).")
+	playerIDDecay := decayCmd.String("player-id", "", "Only demote this player's cards.")
+	playerIDReactivate := reactivateCardCmd.String("player-id", "", "The ID of the player (required).")
+	playerIDSearch := searchCardsCmd.String("player-id", "", "Only show this player's notes and progress.")
+	playerIDToken := createTokenCmd.String("player-id", "", "The player the token is for (required unless --admin is given).")
+	playerIDStudy := studyCmd.String("player-id", "", "The ID of the player (required unless --guest is given).")
+	playerIDChallenge := challengeCmd.String("player-id", "", "The ID of the player (required).")
+	playerIDHistory := historyCmd.String("player-id", "", "The ID of the player (required).")
+	playerIDCardStatus := cardStatusCmd.String("player-id", "", "The ID of the player (required).")
+	playerIDReport := reportCmd.String("player-id", "", "The ID of the player (required).")
+	playerIDGoal := setGoalCmd.String("player-id", "", "The ID of the player (required).")
+	playerIDDue := dueCmd.String("player-id", "", "The ID of the player (required).")
+	practiceStudy := studyCmd.Bool("practice", false, "Practice mode: leave boxes and streaks unchanged.")
 
 	// Flags for specific commands
 	cardID := checkAnswerCmd.String("id", "", "The ID of the card being answered (required).")
-	userAnswer := checkAnswerCmd.String("answer", "", "The user's answer (required).")
+	userAnswer := checkAnswerCmd.String("answer", "", "The user's answer (required unless --spoken is given).")
+	spokenCheck := checkAnswerCmd.Bool("spoken", false, "Spoken answer: record it with the speech-to-text command, or take --answer as its transcript, and match it loosely.")
 	playerName := createPlayerCmd.String("name", "", "The name for the new player (required).")
+	statsExport := getStatsCmd.String("export", "", "Write a full breakdown to this .csv or .md file instead of printing the summary.")
+	archiveOlderThan := archiveHistoryCmd.String("older-than", "90d", "Archive history entries older than this (e.g. 90d, 2w, 36h).")
+	backupKeep := backupCmd.Int("keep", 0, "Number of backups to keep; older ones are removed (0 keeps all).")
+	restoreFile := restoreBackupCmd.String("file", "", "Path of the backup to restore (required).")
+	doctorRepair := doctorCmd.Bool("repair", false, "Fix the problems found instead of only reporting them.")
+	duelRounds := duelCmd.Int("rounds", 10, "Number of cards each player answers.")
+	localeTag := setLocaleCmd.String("locale", "", "Locale for messages and formatting, e.g. de_DE or fr (empty resets to default).")
+	examCount := examCmd.Int("count", 20, "Number of questions.")
+	examLanguage := examCmd.String("language", "", "Only ask cards of this language.")
+	examTags := examCmd.String("tags", "", "Comma-separated tags; only ask cards with at least one of them.")
+	eventsFollow := eventsCmd.Bool("follow", false, "Keep running and stream new events as they happen.")
+	serveAddr := serveCmd.String("addr", "127.0.0.1:8080", "Address to listen on.")
+	dailyCount := dailyCmd.Int("count", 5, "Number of cards in the daily challenge.")
+	dailyLeaderboard := dailyCmd.Bool("leaderboard", false, "Only show the leaderboard.")
+	dailyDate := dailyCmd.String("date", "", "Leaderboard date as YYYY-MM-DD (default today).")
+	bonusGame := bonusCmd.String("game", "crossword", "Puzzle to build: crossword or association.")
+	bonusFormat := bonusCmd.String("format", "text", "Output format: text or html.")
+	bonusOut := bonusCmd.String("out", "", "Write the puzzle to this file instead of stdout.")
+	writingCount := writingCmd.Int("count", 3, "Number of new sentences to write.")
+	chartDays := progressChartCmd.Int("days", 30, "Number of days to chart, ending today.")
+	chartASCII := progressChartCmd.Bool("ascii", false, "Draw with plain ASCII characters for terminals without Unicode.")
+	repairDryRun := repairProgressCmd.Bool("dry-run", false, "Only report the problems found, don't save anything.")
+	annotateCardID := annotateCardCmd.String("id", "", "The ID of the card (required).")
+	annotateNote := annotateCardCmd.String("note", "", "The note; leave empty to remove the card's note.")
+	skipCardID := skipCardCmd.String("id", "", "The ID of the card to skip (required).")
+	unskipCardID := unskipCardCmd.String("id", "", "The ID of the card to bring back (required).")
+	decayAfterDays := decayCmd.Int("after-days", 0, "Days without a review before a card drops a box (default decay.after_days from config.json).")
+	decayDryRun := decayCmd.Bool("dry-run", false, "Only show which cards would be demoted.")
+	reactivateCardID := reactivateCardCmd.String("id", "", "The ID of the retired card (required).")
+	reactivateBox := reactivateCardCmd.Int("box", topBox, "Box to put the card back into (1-5).")
+	studyCount := studyCmd.Int("count", 10, "Number of cards in the session.")
+	studySeed := studyCmd.Int64("seed", 0, "Seed for card selection, to reproduce a session (default random).")
+	studyReplay := studyCmd.String("replay", "", "Reuse the seed of this recorded session.")
+	studyResume := studyCmd.Bool("resume", false, "Continue the player's paused study session.")
+	examResume := examCmd.Bool("resume", false, "Continue the player's paused exam.")
+	studyGuest := studyCmd.Bool("guest", false, "Study as a guest whose answers are forgotten when the session ends.")
+	studyGuestName := studyCmd.String("name", "Guest", "The name of the guest, with --guest.")
+	simulateDays := simulateCmd.Int("days", 30, "Number of days to simulate.")
+	simulatePerDay := simulateCmd.Int("per-day", 20, "Reviews per day.")
+	simulateAccuracy := simulateCmd.String("accuracy", "0.6,0.7,0.8,0.9,0.95", "Chance of a correct answer in boxes 1 to 5.")
+	simulateCards := simulateCmd.Int("cards", 0, "Simulate a deck of this many cards instead of cards.json.")
+	simulateWeights := simulateCmd.String("weights", "", "Box weights to try, e.g. 16,8,4,2,1 (default from config.json).")
+	simulateRetire := simulateCmd.Int("retire-after", 0, "Passes in box 5 that retire a card (default from config.json).")
+	simulateSeed := simulateCmd.Int64("seed", 0, "Seed for the simulation (default random).")
+	tokenAdmin := createTokenCmd.Bool("admin", false, "Create an admin token, which can also manage players.")
+	revokeTokenID := revokeTokenCmd.String("id", "", "The ID of the token, as shown by list-tokens (required).")
+	challengeSeconds := challengeCmd.Int("seconds", 60, "Length of the challenge in seconds.")
+	challengeSeed := challengeCmd.Int64("seed", 0, "Seed for the card order (default random).")
+	historySince := historyCmd.String("since", "", "Only answers from this long ago on (e.g. 7d, 2w, 36h).")
+	historyCard := historyCmd.String("card", "", "Only answers to the card with this ID.")
+	historyOnlyWrong := historyCmd.Bool("only-wrong", false, "Only wrong answers.")
+	historySpoken := historyCmd.Bool("spoken", false, "Only spoken answers.")
+	historyJSON := historyCmd.Bool("json", false, "Print the answers as JSON.")
+	cardStatusID := cardStatusCmd.String("id", "", "The ID of the card (required).")
+	cardStatusAttempts := cardStatusCmd.Int("attempts", 10, "Number of recent attempts to show.")
+	reportPeriod := reportCmd.String("period", "weekly", "Period to summarize: weekly or monthly.")
+	reportFormat := reportCmd.String("format", "markdown", "Output format: markdown or html.")
+	reportOut := reportCmd.String("out", "", "Write the report to this file instead of stdout.")
+	reportSend := reportCmd.Bool("send", false, "Mail the report through report.smtp in config.json.")
+	goalDailyReviews := setGoalCmd.Int("daily-reviews", 0, "Reviews to do each day (0 removes the goal).")
+	goalWeeklyNew := setGoalCmd.Int("weekly-new", 0, "New cards to learn each week (0 removes the goal).")
+	dueSummary := dueCmd.Bool("summary", false, "Print a one-line summary instead of just the count.")
+	importFile := importCmd.String("file", "", "Path of the Quizlet or Memrise export (required).")
+	importFormat := importCmd.String("format", "", "Format of the export: quizlet or memrise (default detected from the file).")
+	importLanguage := importCmd.String("language", "", "Language of the imported cards (required).")
+	importTags := importCmd.String("tags", "", "Comma-separated tags to add to every imported card.")
+	importDryRun := importCmd.Bool("dry-run", false, "Only show the cards that would be imported.")
+	convertIn := convertDeckCmd.String("in", "", "Deck to convert (default the current deck).")
+	convertOut := convertDeckCmd.String("out", "", "File to write; .md gives a Markdown deck, anything else JSON (required).")
+	convertForce := convertDeckCmd.Bool("force", false, "Overwrite --out if it exists.")
+	watchFolder := watchCmd.String("folder", "", "Notes folder to take flashcards from (default vault.folder from config.json).")
+	watchOnce := watchCmd.Bool("once", false, "Sync once and exit instead of watching.")
+	deckStatsJSON := deckStatsCmd.Bool("json", false, "Print the statistics as JSON.")
+	mergeFile := mergeProgressCmd.String("file", "", "The other progress.json to merge in (required).")
+	mergeDryRun := mergeProgressCmd.Bool("dry-run", false, "Only show what the merge would change.")
+	boostPlayerID := boostCardCmd.String("player-id", "", "The ID of the player (required).")
+	boostCardID := boostCardCmd.String("id", "", "The ID of the card to boost.")
+	boostTag := boostCardCmd.String("tag", "", "Boost every card with this tag instead.")
+	boostFactor := boostCardCmd.Float64("factor", defaultBoostFactor, "How many times as often the cards are drawn.")
+	boostReviews := boostCardCmd.Int("reviews", 0, "End the boost after this many answers to its cards (0 for no limit).")
+	boostDays := boostCardCmd.Int("days", 7, "End the boost after this many days (0 for no limit).")
+	boostClear := boostCardCmd.Bool("clear", false, "Remove the boost or deprioritization on the card or tag.")
+	deprioritizePlayerID := deprioritizeCardCmd.String("player-id", "", "The ID of the player (required).")
+	deprioritizeCardID := deprioritizeCardCmd.String("id", "", "The ID of the card to deprioritize.")
+	deprioritizeTag := deprioritizeCardCmd.String("tag", "", "Deprioritize every card with this tag instead.")
+	deprioritizeFactor := deprioritizeCardCmd.Float64("factor", defaultBoostFactor, "How many times less often the cards are drawn.")
+	deprioritizeReviews := deprioritizeCardCmd.Int("reviews", 0, "End after this many answers to its cards (0 for no limit).")
+	deprioritizeDays := deprioritizeCardCmd.Int("days", 7, "End after this many days (0 for no limit).")
+	playerIDBoosts := listBoostsCmd.String("player-id", "", "The ID of the player (required).")
+	playerIDSchedulerState := schedulerStateCmd.String("player-id", "", "The ID of the player (required).")
+	schedulerStateJSON := schedulerStateCmd.Bool("json", false, "Print the box weights as JSON.")
+	schemaName := schemaCmd.String("name", "", "The schema to print, such as deck (lists them when empty).")
+	editCardID := editCardCmd.String("id", "", "The ID of the card to change (required).")
+	editPrompt := editCardCmd.String("prompt", "", "The new prompt.")
+	editSolution := editCardCmd.String("solution", "", "The new solution.")
+	editLanguage := editCardCmd.String("language", "", "The new language.")
+	editTags := editCardCmd.String("tags", "", "The new comma-separated tags, replacing the old ones.")
+	deleteCardID := deleteCardCmd.String("id", "", "The ID of the card to delete (required).")
+	restoreCardID := restoreCardCmd.String("id", "", "The ID of the card to restore (required).")
+	restoreRevision := restoreCardCmd.Int("revision", 0, "Put this revision of the card back (default: undo the deletion).")
+	cardHistoryID := cardHistoryCmd.String("id", "", "The ID of the card (required).")
+	cardHistoryRevision := cardHistoryCmd.Int("revision", 0, "Print this revision of the card.")
+	cardHistoryJSON := cardHistoryCmd.Bool("json", false, "Print the revisions as JSON.")
+	overviewTop := overviewCmd.Int("top", defaultHardestCards, "Number of hardest cards to list.")
+	overviewJSON := overviewCmd.Bool("json", false, "Print the overview as JSON.")
+	benchCards := benchCmd.Int("cards", 50000, "Number of synthetic cards.")
+	benchHistory := benchCmd.Int("history", 100000, "Number of history entries of the player.")
+	benchPlayers := benchCmd.Int("players", 1, "Number of players in progress.json.")
+	benchPicks := benchCmd.Int("picks", 200, "Number of cards to pick.")
+	benchSaves := benchCmd.Int("saves", 10, "Number of times to write and read progress.json.")
+	benchSeed := benchCmd.Int64("seed", 1, "Seed for the dataset and the picks.")
+	benchJSON := benchCmd.Bool("json", false, "Print the timings as JSON.")
 
+	setDataDir(*dataDir)
+	setupLogging(*verbose, *quiet)
+	setLanguage(*lang)
+	setupTelemetry()
+	startProfiles(*cpuProfile, *memProfile)
 	if len(os.Args) < 2 {
-		log.Fatal("Expected 'get-card', 'check-answer', 'create-player', 'list-players', 'delete-player', or 'get-stats' subcommands.")
+		fatalf("Expected one of these subcommands: %s.", strings.Join(commands, ", "))
 	}
+	for _, command := range commands {
+		if os.Args[1] == command {
+			recordFeatureUsage(command)
+		}
+	}
+	slog.Debug("Running command", "command", os.Args[1], "args", os.Args[2:])
 
 	// Route to the correct handler
 	switch os.Args[1] {
 	case "get-card":
 		getCardCmd.Parse(os.Args[2:])
 		if *playerIDGet == "" {
-			log.Fatal("--player-id flag is required")
+			fatal("--player-id flag is required")
 		}
-		handleGetCard(*playerIDGet)
+		handleGetCard(*playerIDGet, *practiceGet, *reviewAheadGet, *explainGet, chooseSeed(getCardCmd, *seedGet))
 	case "check-answer":
 		checkAnswerCmd.Parse(os.Args[2:])
-		if *playerIDCheck == "" || *cardID == "" || *userAnswer == "" {
-			log.Fatal("--player-id, --id, and --answer flags are required")
+		if *playerIDCheck == "" || *cardID == "" || (*userAnswer == "" && !*spokenCheck) {
+			fatal("--player-id, --id, and --answer flags are required")
 		}
-		handleCheckAnswer(*playerIDCheck, *cardID, *userAnswer)
+		handleCheckAnswer(*playerIDCheck, *cardID, *userAnswer, *practiceCheck, *spokenCheck)
 	case "create-player":
 		createPlayerCmd.Parse(os.Args[2:])
 		if *playerName == "" {
-			log.Fatal("--name flag is required")
+			fatal("--name flag is required")
 		}
 		handleCreatePlayer(*playerName)
 	case "list-players":
@@ -117,146 +457,699 @@ func main() {
 	case "delete-player":
 		deletePlayerCmd.Parse(os.Args[2:])
 		if *playerIDDelete == "" {
-			log.Fatal("--player-id flag is required")
+			fatal("--player-id flag is required")
 		}
 		handleDeletePlayer(*playerIDDelete)
 	case "get-stats":
 		getStatsCmd.Parse(os.Args[2:])
 		if *playerIDStats == "" {
-			log.Fatal("--player-id flag is required")
+			fatal("--player-id flag is required")
+		}
+		handleGetStats(*playerIDStats, *statsExport)
+	case "archive-history":
+		archiveHistoryCmd.Parse(os.Args[2:])
+		if *playerIDArchive == "" {
+			fatal("--player-id flag is required")
+		}
+		olderThan, err := parseAge(*archiveOlderThan)
+		if err != nil {
+			fatalf("Invalid --older-than value: %v", err)
+		}
+		handleArchiveHistory(*playerIDArchive, olderThan)
+	case "backup":
+		backupCmd.Parse(os.Args[2:])
+		handleBackup(*backupKeep)
+	case "restore-backup":
+		restoreBackupCmd.Parse(os.Args[2:])
+		if *restoreFile == "" {
+			fatal("--file flag is required")
+		}
+		handleRestoreBackup(*restoreFile)
+	case "doctor":
+		doctorCmd.Parse(os.Args[2:])
+		handleDoctor(*doctorRepair)
+	case "duel":
+		duelCmd.Parse(os.Args[2:])
+		if *playerIDDuelA == "" || *playerIDDuelB == "" {
+			fatal("--player-a and --player-b flags are required")
+		}
+		if *duelRounds < 1 {
+			fatal("--rounds must be at least 1")
+		}
+		handleDuel(*playerIDDuelA, *playerIDDuelB, *duelRounds)
+	case "match-history":
+		matchHistoryCmd.Parse(os.Args[2:])
+		handleMatchHistory(*playerIDMatches)
+	case "set-locale":
+		setLocaleCmd.Parse(os.Args[2:])
+		if *playerIDLocale == "" {
+			fatal("--player-id flag is required")
+		}
+		handleSetLocale(*playerIDLocale, *localeTag)
+	case "exam":
+		examCmd.Parse(os.Args[2:])
+		if *playerIDExam == "" {
+			fatal("--player-id flag is required")
+		}
+		if *examResume {
+			handleResumeExam(*playerIDExam)
+			break
+		}
+		if *examCount < 1 {
+			fatal("--count must be at least 1")
+		}
+		handleExam(*playerIDExam, *examCount, CardFilter{Language: *examLanguage, Tags: splitList(*examTags)})
+	case "list-exams":
+		listExamsCmd.Parse(os.Args[2:])
+		if *playerIDExams == "" {
+			fatal("--player-id flag is required")
+		}
+		handleListExams(*playerIDExams)
+	case "events":
+		eventsCmd.Parse(os.Args[2:])
+		handleEvents(*playerIDEvents, *eventsFollow)
+	case "serve":
+		serveCmd.Parse(os.Args[2:])
+		handleServe(*serveAddr)
+	case "daily":
+		dailyCmd.Parse(os.Args[2:])
+		if *dailyLeaderboard {
+			handleDailyLeaderboard(*dailyDate, *dailyCount)
+			return
+		}
+		if *playerIDDaily == "" {
+			fatal("--player-id flag is required")
+		}
+		if *dailyCount < 1 {
+			fatal("--count must be at least 1")
+		}
+		handleDaily(*playerIDDaily, *dailyCount)
+	case "seasons":
+		seasonsCmd.Parse(os.Args[2:])
+		handleSeasons()
+	case "telemetry":
+		telemetryCmd.Parse(os.Args[2:])
+		if telemetryCmd.NArg() != 1 {
+			fatal("Expected 'telemetry preview', 'telemetry send' or 'telemetry reset'")
+		}
+		handleTelemetry(telemetryCmd.Arg(0))
+	case "card-types":
+		cardTypesCmd.Parse(os.Args[2:])
+		handleCardTypes()
+	case "bonus":
+		bonusCmd.Parse(os.Args[2:])
+		if *playerIDBonus == "" {
+			fatal("--player-id flag is required")
+		}
+		if *bonusGame != "crossword" && *bonusGame != "association" {
+			fatalf("Unknown bonus game '%s', expected 'crossword' or 'association'.", *bonusGame)
+		}
+		if *bonusFormat != "text" && *bonusFormat != "html" {
+			fatalf("Unknown format '%s', expected 'text' or 'html'.", *bonusFormat)
+		}
+		handleBonus(*playerIDBonus, *bonusGame, *bonusFormat, *bonusOut)
+	case "writing":
+		writingCmd.Parse(os.Args[2:])
+		if *playerIDWriting == "" {
+			fatal("--player-id flag is required")
+		}
+		handleWriting(*playerIDWriting, *writingCount)
+	case "progress-chart":
+		progressChartCmd.Parse(os.Args[2:])
+		if *playerIDChart == "" {
+			fatal("--player-id flag is required")
+		}
+		if *chartDays < 1 {
+			fatal("--days must be at least 1")
+		}
+		handleProgressChart(*playerIDChart, *chartDays, *chartASCII)
+	case "repair-progress":
+		repairProgressCmd.Parse(os.Args[2:])
+		handleRepairProgress(*repairDryRun)
+	case "annotate-card":
+		annotateCardCmd.Parse(os.Args[2:])
+		if *playerIDAnnotate == "" || *annotateCardID == "" {
+			fatal("--player-id and --id flags are required")
+		}
+		handleAnnotateCard(*playerIDAnnotate, *annotateCardID, *annotateNote)
+	case "skip-card":
+		skipCardCmd.Parse(os.Args[2:])
+		if *playerIDSkip == "" || *skipCardID == "" {
+			fatal("--player-id and --id flags are required")
+		}
+		handleSkipCard(*playerIDSkip, *skipCardID)
+	case "unskip-card":
+		unskipCardCmd.Parse(os.Args[2:])
+		if *playerIDUnskip == "" || *unskipCardID == "" {
+			fatal("--player-id and --id flags are required")
+		}
+		handleUnskipCard(*playerIDUnskip, *unskipCardID)
+	case "list-skipped":
+		listSkippedCmd.Parse(os.Args[2:])
+		if *playerIDSkipped == "" {
+			fatal("--player-id flag is required")
+		}
+		handleListSkipped(*playerIDSkipped)
+	case "decay":
+		decayCmd.Parse(os.Args[2:])
+		handleDecay(*playerIDDecay, *decayAfterDays, *decayDryRun)
+	case "reactivate-card":
+		reactivateCardCmd.Parse(os.Args[2:])
+		if *playerIDReactivate == "" || *reactivateCardID == "" {
+			fatal("--player-id and --id flags are required")
+		}
+		if *reactivateBox < 1 || *reactivateBox > topBox {
+			fatal("--box must be between 1 and 5")
+		}
+		handleReactivateCard(*playerIDReactivate, *reactivateCardID, *reactivateBox)
+	case "search-cards":
+		searchCardsCmd.Parse(os.Args[2:])
+		if searchCardsCmd.NArg() == 0 {
+			fatal("a search query is required, e.g. search-cards \"météo\"")
+		}
+		// Allow flags after the query as well
+		query := searchCardsCmd.Arg(0)
+		searchCardsCmd.Parse(searchCardsCmd.Args()[1:])
+		if searchCardsCmd.NArg() > 0 {
+			fatal("search-cards takes one query; quote it if it has several words")
+		}
+		handleSearchCards(query, *playerIDSearch)
+	case "study":
+		studyCmd.Parse(os.Args[2:])
+		if *studyGuest {
+			if *playerIDStudy != "" {
+				fatal("--guest and --player-id can't be used together")
+			}
+			*playerIDStudy = createGuest(*studyGuestName)
+		}
+		if *playerIDStudy == "" {
+			fatal("--player-id flag is required (or --guest)")
+		}
+		if *studyResume {
+			handleResumeStudy(*playerIDStudy)
+			break
+		}
+		if *studyCount < 1 {
+			fatal("--count must be at least 1")
+		}
+		seed := chooseSeed(studyCmd, *studySeed)
+		if *studyReplay != "" {
+			seed = replaySeed(*studyReplay)
+		}
+		handleStudy(*playerIDStudy, *studyCount, seed, *practiceStudy)
+	case "simulate":
+		simulateCmd.Parse(os.Args[2:])
+		if *simulateDays < 1 || *simulatePerDay < 1 {
+			fatal("--days and --per-day must be at least 1")
+		}
+		if *simulateCards < 0 {
+			fatal("--cards must not be negative")
+		}
+		handleSimulate(Simulation{
+			Days:     *simulateDays,
+			PerDay:   *simulatePerDay,
+			Accuracy: parseAccuracy(*simulateAccuracy),
+			Seed:     chooseSeed(simulateCmd, *simulateSeed),
+		}, *simulateCards, *simulateWeights, *simulateRetire)
+	case "create-token":
+		createTokenCmd.Parse(os.Args[2:])
+		if (*playerIDToken == "") == !*tokenAdmin {
+			fatal("exactly one of --player-id and --admin is required")
+		}
+		handleCreateToken(*playerIDToken, *tokenAdmin)
+	case "list-tokens":
+		listTokensCmd.Parse(os.Args[2:])
+		handleListTokens()
+	case "revoke-token":
+		revokeTokenCmd.Parse(os.Args[2:])
+		if *revokeTokenID == "" {
+			fatal("--id flag is required")
+		}
+		handleRevokeToken(*revokeTokenID)
+	case "init":
+		initCmd.Parse(os.Args[2:])
+		handleInit()
+	case "challenge":
+		challengeCmd.Parse(os.Args[2:])
+		if *playerIDChallenge == "" {
+			fatal("--player-id flag is required")
+		}
+		if *challengeSeconds < 1 {
+			fatal("--seconds must be at least 1")
+		}
+		handleChallenge(*playerIDChallenge, *challengeSeconds, chooseSeed(challengeCmd, *challengeSeed))
+	case "history":
+		historyCmd.Parse(os.Args[2:])
+		if *playerIDHistory == "" {
+			fatal("--player-id flag is required")
+		}
+		filter := HistoryFilter{CardID: *historyCard, OnlyWrong: *historyOnlyWrong, OnlySpoken: *historySpoken}
+		if *historySince != "" {
+			since, err := parseAge(*historySince)
+			if err != nil {
+				fatalf("Invalid --since value: %v", err)
+			}
+			filter.Since = time.Now().Add(-since)
+		}
+		handleHistory(*playerIDHistory, filter, *historyJSON)
+	case "card-status":
+		cardStatusCmd.Parse(os.Args[2:])
+		if *playerIDCardStatus == "" || *cardStatusID == "" {
+			fatal("--player-id and --id flags are required")
+		}
+		handleCardStatus(*playerIDCardStatus, *cardStatusID, max(*cardStatusAttempts, 0))
+	case "report":
+		reportCmd.Parse(os.Args[2:])
+		if *playerIDReport == "" {
+			fatal("--player-id flag is required")
+		}
+		handleReport(*playerIDReport, *reportPeriod, *reportFormat, *reportOut, *reportSend)
+	case "set-goal":
+		setGoalCmd.Parse(os.Args[2:])
+		if *playerIDGoal == "" {
+			fatal("--player-id flag is required")
+		}
+		if setGoalCmd.NFlag() < 2 {
+			fatal("give --daily-reviews, --weekly-new or both")
 		}
-		handleGetStats(*playerIDStats)
+		if *goalDailyReviews < 0 || *goalWeeklyNew < 0 {
+			fatal("goals can't be negative")
+		}
+		handleSetGoal(setGoalCmd, *playerIDGoal, *goalDailyReviews, *goalWeeklyNew)
+	case "due":
+		dueCmd.Parse(os.Args[2:])
+		if *playerIDDue == "" {
+			fatal("--player-id flag is required")
+		}
+		handleDue(*playerIDDue, *dueSummary)
+	case "import":
+		importCmd.Parse(os.Args[2:])
+		if *importFile == "" || *importLanguage == "" {
+			fatal("--file and --language flags are required")
+		}
+		handleImport(*importFile, *importFormat, *importLanguage, splitList(*importTags), *importDryRun)
+	case "convert-deck":
+		convertDeckCmd.Parse(os.Args[2:])
+		if *convertOut == "" {
+			fatal("--out flag is required")
+		}
+		handleConvertDeck(*convertIn, *convertOut, *convertForce)
+	case "watch":
+		watchCmd.Parse(os.Args[2:])
+		handleWatch(*watchFolder, *watchOnce)
+	case "deck-stats":
+		deckStatsCmd.Parse(os.Args[2:])
+		handleDeckStats(*deckStatsJSON)
+	case "merge-progress":
+		mergeProgressCmd.Parse(os.Args[2:])
+		if *mergeFile == "" {
+			fatal("--file flag is required")
+		}
+		handleMergeProgress(*mergeFile, *mergeDryRun)
+	case "boost-card", "deprioritize-card":
+		cmd, playerID, cardID, tag, factor, reviews, days := boostCardCmd, boostPlayerID, boostCardID, boostTag, boostFactor, boostReviews, boostDays
+		if os.Args[1] == "deprioritize-card" {
+			cmd, playerID, cardID, tag, factor, reviews, days = deprioritizeCardCmd, deprioritizePlayerID, deprioritizeCardID, deprioritizeTag, deprioritizeFactor, deprioritizeReviews, deprioritizeDays
+		}
+		cmd.Parse(os.Args[2:])
+		if *playerID == "" {
+			fatal("--player-id flag is required")
+		}
+		if (*cardID == "") == (*tag == "") {
+			fatal("Give either --id or --tag")
+		}
+		if *boostClear {
+			handleClearBoost(*playerID, *cardID, *tag)
+			break
+		}
+		if *factor <= 1 {
+			fatal("--factor must be greater than 1")
+		}
+		if *reviews < 0 || *days < 0 {
+			fatal("--reviews and --days must not be negative")
+		}
+		if *reviews == 0 && *days == 0 {
+			fatal("A boost needs an end; give --reviews or --days")
+		}
+		if os.Args[1] == "deprioritize-card" {
+			*factor = 1 / *factor
+		}
+		handleBoost(*playerID, *cardID, *tag, *factor, *reviews, *days)
+	case "scheduler-state":
+		schedulerStateCmd.Parse(os.Args[2:])
+		if *playerIDSchedulerState == "" {
+			fatal("--player-id flag is required")
+		}
+		handleSchedulerState(*playerIDSchedulerState, *schedulerStateJSON)
+	case "list-boosts":
+		listBoostsCmd.Parse(os.Args[2:])
+		if *playerIDBoosts == "" {
+			fatal("--player-id flag is required")
+		}
+		handleListBoosts(*playerIDBoosts)
+	case "schema":
+		schemaCmd.Parse(os.Args[2:])
+		handleSchema(*schemaName)
+	case "edit-card":
+		editCardCmd.Parse(os.Args[2:])
+		if *editCardID == "" {
+			fatal("--id flag is required")
+		}
+		handleEditCard(editCardCmd, *editCardID, *editPrompt, *editSolution, *editLanguage, *editTags)
+	case "delete-card":
+		deleteCardCmd.Parse(os.Args[2:])
+		if *deleteCardID == "" {
+			fatal("--id flag is required")
+		}
+		handleDeleteCard(*deleteCardID)
+	case "restore-card":
+		restoreCardCmd.Parse(os.Args[2:])
+		if *restoreCardID == "" {
+			fatal("--id flag is required")
+		}
+		handleRestoreCard(*restoreCardID, *restoreRevision)
+	case "card-history":
+		cardHistoryCmd.Parse(os.Args[2:])
+		if *cardHistoryID == "" {
+			fatal("--id flag is required")
+		}
+		handleCardHistory(*cardHistoryID, *cardHistoryRevision, *cardHistoryJSON)
+	case "overview":
+		overviewCmd.Parse(os.Args[2:])
+		handleOverview(*overviewTop, *overviewJSON)
+	case "bench":
+		benchCmd.Parse(os.Args[2:])
+		if *benchCards < 1 || *benchPlayers < 1 || *benchPicks < 1 || *benchSaves < 1 {
+			fatal("--cards, --players, --picks and --saves must be at least 1")
+		}
+		if *benchHistory < 0 {
+			fatal("--history must not be negative")
+		}
+		handleBench(Bench{
+			Cards:   *benchCards,
+			History: *benchHistory,
+			Players: *benchPlayers,
+			Picks:   *benchPicks,
+			Saves:   *benchSaves,
+			Seed:    *benchSeed,
+		}, *benchJSON)
+	case "schedulers":
+		schedulersCmd.Parse(os.Args[2:])
+		handleSchedulers()
 	default:
-		log.Fatalf("Unknown subcommand: %s.", os.Args[1])
+		fatalf("Unknown subcommand: %s.", os.Args[1])
 	}
+	maybeAutoSendTelemetry()
+	stopProfiles()
 }
 
 // --- Command Handlers ---
 
-func handleGetCard(playerID string) {
-	cards := loadCards()
+func handleGetCard(playerID string, practice, reviewAhead, explain bool, seed int64) {
+	var explainSeed *int64
+	if explain {
+		explainSeed = &seed
+	}
+	var output interface{}
+	view, ok, err := nextCard(playerID, practice, reviewAhead, explainSeed, newRand(seed))
+	if err != nil {
+		fatalf("Error picking the next card: %v", err)
+	}
+	if ok {
+		output = view
+	} else {
+		output = playerDoneView(playerID)
+	}
+	jsonOutput, err := json.Marshal(output)
+	if err != nil {
+		fatalf("Error marshalling card to JSON: %v", err)
+	}
+	fmt.Println(string(jsonOutput))
+}
+
+// nextCard picks the player's next card, bringing in new cards as needed,
+// and returns it with the player's progress on it. With explainSeed, the
+// seed rng was made from, the view explains the pick. It returns false when
+// no card is left to serve (see completion.go); reviewAhead serves cards
+// that aren't due and retired cards then. It fails if the card can't be
+// shown, for example because its card type plugin is broken.
+func nextCard(playerID string, practice, reviewAhead bool, explainSeed *int64, rng *rand.Rand) (CardView, bool, error) {
 	allProgress := loadAllProgress()
 	playerProgress, ok := allProgress[playerID]
 	if !ok {
-		log.Fatalf("Player with ID '%s' not found.", playerID)
+		fatalf("Player with ID '%s' not found.", playerID)
 	}
+	deck := loadCards()
+	cards := withoutSkipped(deck, playerProgress)
 
-	progressUpdated := false
-	for _, card := range cards {
-		if _, ok := playerProgress.Cards[card.ID]; !ok {
-			playerProgress.Cards[card.ID] = CardProgress{Box: 1, Streak: 0, Passed: 0, Failed: 0, LastReviewed: time.Now()}
-			progressUpdated = true
+	scheduler := loadConfig().Scheduler
+	now := time.Now()
+	recent := playerProgress.RecentCards
+	next, err := activeScheduler(scheduler)
+	if err != nil {
+		return CardView{}, false, err
+	}
+	pick, ok, err := next.NextCard(playerProgress, cards, reviewAhead, now, rng)
+	if err != nil {
+		return CardView{}, false, fmt.Errorf("scheduler '%s' failed: %v", scheduler.Plugin, err)
+	}
+	chosenCard := pick.Card
+	if !ok {
+		if len(pick.Introduced) > 0 && !practice {
+			allProgress[playerID] = playerProgress
+			saveAllProgress(allProgress)
 		}
+		return CardView{}, false, nil
 	}
-	if progressUpdated {
+
+	if practice {
+		// Practice runs select from the same boxes but must not persist new
+		// cards, so only the recent picks are written back
+		fresh := loadAllProgress()
+		player := fresh[playerID]
+		player.RecentCards = rememberPick(player.RecentCards, chosenCard.ID, scheduler.historyLimit())
+		fresh[playerID] = player
+		saveAllProgress(fresh)
+	} else {
+		playerProgress.RecentCards = rememberPick(playerProgress.RecentCards, chosenCard.ID, scheduler.historyLimit())
 		allProgress[playerID] = playerProgress
 		saveAllProgress(allProgress)
 	}
 
-	boxes := make(map[int][]Card)
-	for _, card := range cards {
-		p := playerProgress.Cards[card.ID]
-		if p.Box > 0 && p.Box <= 5 {
-			boxes[p.Box] = append(boxes[p.Box], card)
-		}
+	view, err := cardView(playerProgress, chosenCard, pick.Box)
+	if err != nil {
+		return CardView{}, false, err
 	}
-
-	weights := map[int]int{1: 16, 2: 8, 3: 4, 4: 2, 5: 1}
-	totalWeight := 0
-	for boxNum, cardList := range boxes {
-		if len(cardList) > 0 {
-			totalWeight += weights[boxNum]
-		}
+	view.ReviewAhead = pick.Ahead
+	// Only the built-in scheduler's draws can be explained
+	if leitner, ok := next.(leitnerScheduler); ok && explainSeed != nil && !playerProgress.Cards[chosenCard.ID].Retired {
+		view.Explanation = explainPick(leitner.candidates(cards, playerProgress, pick, now), playerProgress, recent, scheduler, now,
+			chosenCard, pick.Box, len(deck)-len(cards), len(pick.Introduced), *explainSeed)
 	}
+	return view, true, nil
+}
 
-	if totalWeight == 0 {
-		fmt.Println(`{"prompt": "Congratulations, you have mastered all cards!", "id": "done"}`)
-		return
+// playerDoneView is the done response for a player get-card has nothing
+// for.
+func playerDoneView(playerID string) DoneView {
+	player, ok := loadAllProgress()[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
 	}
+	return doneView(player, withoutSkipped(loadCards(), player), loadConfig().Scheduler, time.Now())
+}
 
-	r := rand.Intn(totalWeight)
-	chosenBox := 0
-	for i := 1; i <= 5; i++ {
-		if weight, ok := weights[i]; ok && len(boxes[i]) > 0 {
-			if r < weight {
-				chosenBox = i
-				break
-			}
-			r -= weight
-		}
+// cardView returns card, in box, with the player's progress on it.
+func cardView(player PlayerData, card Card, box int) (CardView, error) {
+	progress := player.Cards[card.ID]
+	rendered, err := renderCard(card)
+	if err != nil {
+		return CardView{}, err
 	}
+	view := CardView{
+		Card:      rendered,
+		Box:       box,
+		Streak:    progress.Streak,
+		TimesSeen: progress.Passed + progress.Failed,
+		Passed:    progress.Passed,
+		Failed:    progress.Failed,
+		Note:      player.Notes[card.ID],
+	}
+	if view.TimesSeen > 0 {
+		view.LastReviewed = &progress.LastReviewed
+	}
+	return view, nil
+}
 
-	chosenCardIndex := rand.Intn(len(boxes[chosenBox]))
-	chosenCard := boxes[chosenBox][chosenCardIndex]
-
-	jsonOutput, err := json.Marshal(chosenCard)
+func handleCheckAnswer(playerID, cardID, userAnswer string, practice, spoken bool) {
+	card, ok := findCard(loadCards(), cardID)
+	if !ok {
+		fatalf("Card with ID '%s' not found.", cardID)
+	}
+	if spoken && userAnswer == "" {
+		userAnswer = transcribeAnswer(card, loadConfig().Speech)
+	}
+	result, err := recordAnswer(playerID, card, userAnswer, practice, spoken)
 	if err != nil {
-		log.Fatalf("Error marshalling card to JSON: %v", err)
+		fatalf("Error checking the answer: %v", err)
 	}
-	fmt.Println(string(jsonOutput))
+	printCheckResult(result)
 }
 
-func handleCheckAnswer(playerID, cardID, userAnswer string) {
-	cards := loadCards()
+// recordAnswer grades an answer, moves the card and logs the answer, the
+// way check-answer does. Nothing is recorded if the answer can't be graded.
+func recordAnswer(playerID string, targetCard Card, userAnswer string, practice, spoken bool) (CheckResult, error) {
+	cardID := targetCard.ID
 	allProgress := loadAllProgress()
 	playerProgress, ok := allProgress[playerID]
 	if !ok {
-		log.Fatalf("Player with ID '%s' not found.", playerID)
+		fatalf("Player with ID '%s' not found.", playerID)
 	}
 
-	var targetCard Card
-	found := false
-	for _, c := range cards {
-		if c.ID == cardID {
-			targetCard = c
-			found = true
-			break
+	check := checkAnswer
+	transcript := ""
+	if spoken {
+		check = func(card Card, answer string) (Verdict, error) {
+			return checkSpokenAnswer(card, answer, loadConfig().Speech)
 		}
+		transcript = userAnswer
+	}
+	verdict, err := check(targetCard, userAnswer)
+	if err != nil {
+		return CheckResult{}, err
 	}
-	if !found {
-		log.Fatalf("Card with ID '%s' not found.", cardID)
+	isCorrect := verdict.Correct
+	now := reviewTime(playerProgress)
+	// Retired cards served for review ahead stay retired
+	if playerProgress.Cards[cardID].Retired {
+		practice = true
 	}
 
-	isCorrect := normalizeString(userAnswer) == normalizeString(targetCard.Solution)
+	if practice {
+		item := AnswerLogItem{
+			CardID:    cardID,
+			Timestamp: now,
+			Correct:   isCorrect,
+			Spoken:    spoken,
+		}
+		playerProgress.Practice = append(playerProgress.Practice, item)
+		allProgress[playerID] = playerProgress
+		saveAnswer(allProgress, JournalEntry{PlayerID: playerID, Practice: true, Answer: item})
+		publishEvent(Event{
+			Type:      EventAnswer,
+			Timestamp: now,
+			PlayerID:  playerID,
+			Data:      map[string]interface{}{"card_id": cardID, "correct": isCorrect, "practice": true, "spoken": spoken},
+		})
+
+		box := playerProgress.Cards[cardID].Box
+		if box == 0 {
+			box = 1
+		}
+		return CheckResult{
+			Correct:    isCorrect,
+			NewBox:     box,
+			Solution:   targetCard.Solution,
+			Practice:   true,
+			Transcript: transcript,
+			Diff:       answerDiff(isCorrect, userAnswer, targetCard.Solution),
+			Feedback:   verdict.Feedback,
+		}, nil
+	}
 
 	// Update card and player stats
-	cardProgress := playerProgress.Cards[cardID]
+	goalsBefore := goalProgress(playerProgress, now)
 	playerProgress.TotalAnswered++
-	if isCorrect {
-		cardProgress.Box++
-		cardProgress.Streak++
-		cardProgress.Passed++
-	} else {
-		cardProgress.Box = 1
-		cardProgress.Streak = 0
-		cardProgress.Failed++
+	answeredBox := max(playerProgress.Cards[cardID].Box, 1)
+	scheduler := loadConfig().Scheduler
+	next, err := activeScheduler(scheduler)
+	if err != nil {
+		return CheckResult{}, err
 	}
-	cardProgress.LastReviewed = time.Now()
+	cardProgress, err := next.OnAnswer(playerProgress, targetCard, isCorrect, now)
+	if err != nil {
+		return CheckResult{}, fmt.Errorf("scheduler '%s' failed: %v", scheduler.Plugin, err)
+	}
+	cardProgress.LastReviewed = now
+	cardProgress.Decayed = 0
 	playerProgress.Cards[cardID] = cardProgress
+	playerProgress.Boosts = countBoostedAnswer(playerProgress.Boosts, targetCard, now)
 
 	// Add a new entry to the history log
-	playerProgress.History = append(playerProgress.History, AnswerLogItem{
+	item := AnswerLogItem{
 		CardID:    cardID,
-		Timestamp: time.Now(),
+		Timestamp: now,
 		Correct:   isCorrect,
-	})
+		Box:       answeredBox,
+		Spoken:    spoken,
+	}
+	playerProgress.History = append(playerProgress.History, item)
+	achievementsBefore := len(playerProgress.Achievements)
+	xpGained, newAchievements := applySeasonalEvents(&playerProgress, isCorrect, now)
 
 	allProgress[playerID] = playerProgress
-	saveAllProgress(allProgress)
+	saveAnswer(allProgress, JournalEntry{
+		PlayerID:     playerID,
+		Answer:       item,
+		Card:         cardProgress,
+		XP:           xpGained,
+		Achievements: playerProgress.Achievements[achievementsBefore:],
+		Boosts:       playerProgress.Boosts,
+	})
+	publishAnswerEvents(playerID, playerProgress, targetCard, cardProgress, isCorrect, now)
+	goals := goalProgress(playerProgress, now)
+	publishGoalEvents(playerID, goalsBefore, goals, now)
+	for _, name := range newAchievements {
+		publishEvent(Event{
+			Type:      EventMilestone,
+			Timestamp: now,
+			PlayerID:  playerID,
+			Data:      map[string]interface{}{"milestone": "achievement", "name": name},
+		})
+	}
 
 	result := CheckResult{
-		Correct:  isCorrect,
-		NewBox:   cardProgress.Box,
-		Solution: targetCard.Solution,
+		Correct:         isCorrect,
+		NewBox:          cardProgress.Box,
+		Retired:         cardProgress.Retired,
+		Solution:        targetCard.Solution,
+		Transcript:      transcript,
+		Diff:            answerDiff(isCorrect, userAnswer, targetCard.Solution),
+		Feedback:        verdict.Feedback,
+		XPGained:        xpGained,
+		NewAchievements: newAchievements,
+	}
+	if goals.DailyReviews > 0 {
+		result.DailyGoalRemaining = &goals.DailyRemaining
 	}
+	if goals.WeeklyNew > 0 {
+		result.WeeklyNewRemaining = &goals.WeeklyRemaining
+	}
+	return result, nil
+}
+
+// answerDiff returns the diff for wrong answers only.
+func answerDiff(correct bool, answer, solution string) []DiffSegment {
+	if correct {
+		return nil
+	}
+	return diffAnswer(answer, solution)
+}
+
+func printCheckResult(result CheckResult) {
 	jsonOutput, err := json.Marshal(result)
 	if err != nil {
-		log.Fatalf("Error marshalling result to JSON: %v", err)
+		fatalf("Error marshalling result to JSON: %v", err)
 	}
 	fmt.Println(string(jsonOutput))
 }
 
 func handleCreatePlayer(name string) {
+	fmt.Println(createPlayer(name))
+}
+
+// createPlayer adds a player and returns the new ID.
+func createPlayer(name string) string {
 	allProgress := loadAllProgress()
 	newID := generateUniqueID()
 
@@ -268,54 +1161,98 @@ func handleCreatePlayer(name string) {
 	}
 
 	saveAllProgress(allProgress)
-	fmt.Println(newID)
+	return newID
 }
 
 func handleListPlayers() {
 	allProgress := loadAllProgress()
 	if len(allProgress) == 0 {
-		fmt.Println("No players found. Create one with 'create-player --name=\"YourName\"'")
+		fmt.Println(tr("No players found. Create one with 'create-player --name=\"YourName\"'"))
 		return
 	}
 	for id, data := range allProgress {
-		fmt.Printf("Name: %s, ID: %s\n", data.Name, id)
+		fmt.Printf(tr("Name: %s, ID: %s\n"), data.Name, id)
 	}
 }
 
 func handleDeletePlayer(playerID string) {
 	allProgress := loadAllProgress()
 	if _, ok := allProgress[playerID]; !ok {
-		log.Fatalf("Player with ID '%s' not found.", playerID)
+		fatalf("Player with ID '%s' not found.", playerID)
 	}
 
 	delete(allProgress, playerID)
 	saveAllProgress(allProgress)
-	fmt.Printf("Player with ID '%s' has been deleted.\n", playerID)
+	fmt.Printf(tr("Player with ID '%s' has been deleted.\n"), playerID)
 }
 
-func handleGetStats(playerID string) {
+func handleGetStats(playerID, exportPath string) {
 	allProgress := loadAllProgress()
 	player, ok := allProgress[playerID]
 	if !ok {
-		log.Fatalf("Player with ID '%s' not found.", playerID)
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	if exportPath != "" {
+		report := buildStatsReport(player, loadFullHistory(playerID, player), loadCards())
+		exportStats(exportPath, report, resolveLocale(player.Locale))
+		fmt.Printf(tr("Statistics for %s exported to %s.\n"), player.Name, exportPath)
+		return
 	}
 
 	// --- Basic Stats ---
 	totalPassed := 0
 	totalFailed := 0
+	retired := 0
 	for _, cardProgress := range player.Cards {
 		totalPassed += cardProgress.Passed
 		totalFailed += cardProgress.Failed
+		if cardProgress.Retired {
+			retired++
+		}
 	}
 
-	fmt.Printf("Stats for Player: %s\n", player.Name)
+	loc := resolveLocale(player.Locale)
+	fmt.Printf(tr("Stats for Player: %s\n"), player.Name)
 	fmt.Println("-------------------------")
-	fmt.Printf("Total Cards Answered: %d\n", player.TotalAnswered)
-	fmt.Printf("Correct Answers: %d\n", totalPassed)
-	fmt.Printf("Incorrect Answers: %d\n", totalFailed)
+	fmt.Printf(tr("Total Cards Answered: %s\n"), loc.Number(player.TotalAnswered))
+	fmt.Printf(tr("Correct Answers: %s\n"), loc.Number(totalPassed))
+	fmt.Printf(tr("Incorrect Answers: %s\n"), loc.Number(totalFailed))
+	if totalPassed+totalFailed > 0 {
+		fmt.Printf(tr("Accuracy: %s\n"), loc.Percent(float64(totalPassed)/float64(totalPassed+totalFailed)))
+	}
+	fmt.Printf(tr("Retired Cards: %s\n"), loc.Number(retired))
+	if player.XP > 0 {
+		fmt.Printf(tr("XP: %s\n"), loc.Number(player.XP))
+	}
+	if len(player.Achievements) > 0 {
+		var names []string
+		for _, achievement := range player.Achievements {
+			names = append(names, achievement.Name)
+		}
+		fmt.Printf(tr("Achievements: %s\n"), strings.Join(names, ", "))
+	}
+	if len(player.Practice) > 0 {
+		practiceCorrect := 0
+		for _, item := range player.Practice {
+			if item.Correct {
+				practiceCorrect++
+			}
+		}
+		fmt.Printf(tr("Practice Answers: %s (%s correct)\n"), loc.Number(len(player.Practice)), loc.Number(practiceCorrect))
+	}
+	if spoken, spokenCorrect := countSpoken(append(loadFullHistory(playerID, player), player.Practice...)); spoken > 0 {
+		fmt.Printf(tr("Spoken Answers: %s (%s correct)\n"), loc.Number(spoken), loc.Number(spokenCorrect))
+	}
+	if len(player.Challenges) > 0 {
+		fmt.Printf(tr("Challenge Bests: %s\n"), challengeBests(player.Challenges))
+	}
+	if player.Goals != (Goals{}) {
+		printGoals(goalProgress(player, time.Now()), loc)
+	}
 
-	if len(player.History) == 0 {
-		fmt.Println("\nNo historical data to analyze yet.")
+	history := loadFullHistory(playerID, player)
+	if len(history) == 0 {
+		fmt.Println(tr("\nNo historical data to analyze yet."))
 		return
 	}
 
@@ -323,123 +1260,233 @@ func handleGetStats(playerID string) {
 	now := time.Now()
 	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	cardsToday := 0
-	for _, item := range player.History {
-		if item.Timestamp.After(todayStart) {
+	for _, item := range history {
+		if item.Timestamp.After(todayStart) && !isFutureDated(item.Timestamp, now) {
 			cardsToday++
 		}
 	}
-	fmt.Printf("Cards Answered Today: %d\n", cardsToday)
+	fmt.Printf(tr("Cards Answered Today: %s\n"), loc.Number(cardsToday))
+	if last := latestTimestamp(PlayerData{History: history}); !last.IsZero() && !isFutureDated(last, now) {
+		fmt.Printf(tr("Last Active: %s (%s ago)\n"), loc.DateTime(last), loc.Duration(now.Sub(last)))
+	}
 
 	// --- Daily Streak Calculation ---
-	if len(player.History) > 0 {
-		// Create a set of unique days the player was active
-		activeDays := make(map[time.Time]bool)
-		for _, item := range player.History {
-			day := time.Date(item.Timestamp.Year(), item.Timestamp.Month(), item.Timestamp.Day(), 0, 0, 0, 0, time.UTC)
-			activeDays[day] = true
-		}
+	currentStreak, longestStreak := dailyStreaks(history, now)
+	fmt.Printf(tr("Current Daily Streak: %s day(s)\n"), loc.Number(currentStreak))
+	fmt.Printf(tr("Longest Daily Streak: %s day(s)\n"), loc.Number(longestStreak))
+}
 
-		// Sort the unique days
-		sortedDays := make([]time.Time, 0, len(activeDays))
-		for day := range activeDays {
-			sortedDays = append(sortedDays, day)
-		}
-		sort.Slice(sortedDays, func(i, j int) bool {
-			return sortedDays[i].Before(sortedDays[j])
-		})
+// --- File I/O and Helper Functions ---
 
-		longestStreak := 0
-		currentStreak := 0
-		if len(sortedDays) > 0 {
-			longestStreak = 1
-			currentStreak = 1
-			for i := 1; i < len(sortedDays); i++ {
-				// Check if the current day is exactly one day after the previous
-				if sortedDays[i].Sub(sortedDays[i-1]).Hours() == 24 {
-					currentStreak++
-				} else {
-					currentStreak = 1 // Streak is broken
-				}
-				if currentStreak > longestStreak {
-					longestStreak = currentStreak
-				}
-			}
+func loadCards() []Card {
+	return loadDeck().Cards
+}
+
+// findCard looks a card up by ID.
+func findCard(cards []Card, id string) (Card, bool) {
+	for _, card := range cards {
+		if card.ID == id {
+			return card, true
 		}
-		fmt.Printf("Longest Daily Streak: %d day(s)\n", longestStreak)
 	}
+	return Card{}, false
 }
 
-// --- File I/O and Helper Functions ---
-
-func getConfigDir() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		log.Fatalf("Could not find user home directory: %v", err)
-	}
-	return filepath.Join(home, ".config", "decouvertes")
+// loadAllProgress reads progress.json with decay applied as configured.
+func loadAllProgress() map[string]PlayerData {
+	progress := readAllProgress()
+	applyDecayOnLoad(progress)
+	return progress
 }
 
-func loadCards() []Card {
-	configDir := getConfigDir()
-	filePath := filepath.Join(configDir, "cards.json")
-	if _, err := os.Stat(configDir); os.IsNotExist(err) {
-		log.Fatalf("Config directory not found at %s. Please create it and place your 'cards.json' file inside.", configDir)
+// readAllProgress reads progress.json as it is stored, or the copy held in
+// memory in serve mode, with any guest players.
+func readAllProgress() map[string]PlayerData {
+	if cache != nil {
+		return withGuests(cache.progress())
 	}
-	file, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		log.Fatalf("Error reading file (%s): %v.", filePath, err)
-	}
-	var cards []Card
-	if err := json.Unmarshal(file, &cards); err != nil {
-		log.Fatalf("Error unmarshalling cards JSON: %v", err)
-	}
-	return cards
+	return withGuests(readProgressFile())
 }
 
-func loadAllProgress() map[string]PlayerData {
-	progress := make(map[string]PlayerData)
-	configDir := getConfigDir()
-	filePath := filepath.Join(configDir, "progress.json")
+func readProgressFile() map[string]PlayerData {
+	filePath := filepath.Join(getDataDir(), "progress.json")
 	file, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return progress
-		}
-		log.Fatalf("Error reading progress file (%s): %v", filePath, err)
+	if err != nil && !os.IsNotExist(err) {
+		fatalf("Error reading progress file (%s): %v", filePath, err)
 	}
 	if len(file) == 0 {
+		progress := make(map[string]PlayerData)
+		replayJournal(progress, readJournal())
 		return progress
 	}
-	if err := json.Unmarshal(file, &progress); err != nil {
-		log.Fatalf("Error unmarshalling progress JSON: %v", err)
+	progress, version, damage, err := decodeProgress(file)
+	if err != nil {
+		fatalf("Error reading progress file (%s): %v", filePath, err)
+	}
+	if !damage.empty() {
+		if len(progress) == 0 {
+			fatalf("progress.json (%s) could not be read at all: %s. Try 'restore-backup'.", filePath, damage.Lost)
+		}
+		reportProgressDamage(filePath, file, damage, progress)
+	}
+	slog.Debug("Read progress file", "path", filePath, "players", len(progress), "version", version)
+	// Answers not yet folded into the file (see journal.go)
+	replayJournal(progress, readJournal())
+	if version < progressFormat {
+		keepPreMigrationCopy(filePath, file, version, progressFormat)
+		// A damaged file is written back by repair-progress, once looked at
+		if damage.empty() {
+			saveAllProgress(progress)
+		}
 	}
 	return progress
 }
 
 func saveAllProgress(progress map[string]PlayerData) {
-	configDir := getConfigDir()
-	filePath := filepath.Join(configDir, "progress.json")
-	data, err := json.MarshalIndent(progress, "", "  ")
+	// Guests stay in memory (see guest.go)
+	progress = withoutGuests(progress)
+	if cache != nil {
+		cache.saveProgress(progress)
+		return
+	}
+	writeProgressFile(progress)
+}
+
+func writeProgressFile(progress map[string]PlayerData) {
+	filePath := filepath.Join(getDataDir(), "progress.json")
+	data, err := json.MarshalIndent(progressFile{Version: progressFormat, Players: progress}, "", "  ")
 	if err != nil {
-		log.Fatalf("Error marshalling progress to JSON: %v", err)
+		fatalf("Error marshalling progress to JSON: %v", err)
+	}
+	if err := writeFileAtomic(filePath, data); err != nil {
+		fatalf("Error writing progress file (%s): %v", filePath, err)
+	}
+	slog.Debug("Wrote progress file", "path", filePath, "bytes", len(data))
+	trimJournal(progress)
+}
+
+func normalizeString(s string, opts NormalizationOptions) string {
+	if opts.Exact {
+		// Composed and decomposed accents are the same text even here
+		return norm.NFC.String(s)
+	}
+	if opts.Unicode == UnicodeNFC {
+		s = norm.NFC.String(s)
+	} else {
+		s = norm.NFKC.String(s)
 	}
-	if err := ioutil.WriteFile(filePath, data, 0644); err != nil {
-		log.Fatalf("Error writing progress file (%s): %v", filePath, err)
+	if !opts.CaseSensitive {
+		// Full case folding ("Straße" == "STRASSE") can leave the string
+		// denormalized, so compose it again
+		s = norm.NFC.String(cases.Fold().String(s))
+	}
+	if len(opts.OptionalArticles) > 0 {
+		s = removeArticles(s, opts.OptionalArticles)
+	}
+	if opts.StripPunctuation {
+		s = strings.Map(func(r rune) rune {
+			if unicode.IsPunct(r) {
+				return -1
+			}
+			return r
+		}, s)
+	}
+	switch opts.Whitespace {
+	case WhitespaceRemove:
+		s = strings.Join(strings.Fields(s), "")
+	case WhitespaceCollapse:
+		s = strings.Join(strings.Fields(s), " ")
+	}
+	if !opts.KeepSemicolon {
+		s = strings.TrimRight(s, ";")
+	}
+	return s
+}
+
+// removeArticles drops optional article words from s. Articles ending in an
+// apostrophe ("l'") are removed as prefixes of the following word.
+func removeArticles(s string, articles []string) string {
+	var kept []string
+	for _, word := range strings.Fields(s) {
+		word = strings.ReplaceAll(word, "’", "'")
+		drop := false
+		for _, article := range articles {
+			article = strings.ReplaceAll(article, "’", "'")
+			if strings.HasSuffix(article, "'") {
+				if len(word) > len(article) && strings.EqualFold(word[:len(article)], article) {
+					word = word[len(article):]
+				}
+				continue
+			}
+			if strings.EqualFold(word, article) {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			kept = append(kept, word)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// dailyStreaks returns the current and the longest run of consecutive days
+// with at least one answer. The current streak counts as alive until the
+// end of the day after the last active day.
+func dailyStreaks(history []AnswerLogItem, now time.Time) (current, longest int) {
+	// Create a set of unique days the player was active
+	activeDays := make(map[time.Time]bool)
+	for _, item := range history {
+		// Entries written under a skewed clock would inflate the streak
+		if isFutureDated(item.Timestamp, now) {
+			continue
+		}
+		activeDays[calendarDay(item.Timestamp)] = true
+	}
+	if len(activeDays) == 0 {
+		return 0, 0
+	}
+
+	// Sort the unique days
+	sortedDays := make([]time.Time, 0, len(activeDays))
+	for day := range activeDays {
+		sortedDays = append(sortedDays, day)
+	}
+	sort.Slice(sortedDays, func(i, j int) bool {
+		return sortedDays[i].Before(sortedDays[j])
+	})
+
+	longest = 1
+	run := 1
+	for i := 1; i < len(sortedDays); i++ {
+		// Check if the current day is exactly one day after the previous
+		if sortedDays[i].Sub(sortedDays[i-1]).Hours() == 24 {
+			run++
+		} else {
+			run = 1 // Streak is broken
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+
+	if today := calendarDay(now); today.Sub(sortedDays[len(sortedDays)-1]).Hours() <= 24 {
+		current = run
 	}
+	return current, longest
 }
 
-func normalizeString(s string) string {
-	lower := strings.ToLower(s)
-	noSpace := strings.Join(strings.Fields(lower), "")
-	noSemicolon := strings.TrimRight(noSpace, ";")
-	return noSemicolon
+// calendarDay truncates t to its calendar date, expressed in UTC so days
+// can be compared by subtraction.
+func calendarDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 }
 
 func generateUniqueID() string {
 	bytes := make([]byte, 16)
 	_, err := rand.Read(bytes)
 	if err != nil {
-		log.Fatalf("Failed to generate unique ID: %v", err)
+		fatalf("Failed to generate unique ID: %v", err)
 	}
 	return hex.EncodeToString(bytes)
 }
@@ -84,10 +84,39 @@ return {
 						vim.notify("Failed to parse JSON result: " .. tostring(res), vim.log.levels.ERROR)
 						return
 					end
-					if res.correct then
-						vim.notify("✅ Correct! Card moved to box " .. res.new_box, vim.log.levels.INFO)
+					local feedback = ""
+					if res.feedback then
+						feedback = "\n\n" .. res.feedback
+					end
+					if res.daily_goal_remaining then
+						if res.daily_goal_remaining == 0 then
+							feedback = feedback .. "\n\n🎯 Daily goal reached!"
+						else
+							feedback = feedback .. "\n\n🎯 " .. res.daily_goal_remaining .. " more for today's goal"
+						end
+					end
+					if res.retired then
+						vim.notify("🎓 Correct! Card retired." .. feedback, vim.log.levels.INFO)
+					elseif res.correct then
+						vim.notify("✅ Correct! Card moved to box " .. res.new_box .. feedback, vim.log.levels.INFO)
 					else
-						vim.notify("❌ Incorrect. The correct answer was:\n" .. res.solution, vim.log.levels.WARN)
+						local message = "❌ Incorrect. The correct answer was:\n" .. res.solution
+						-- Mark extra characters as [-x-] and missing ones as {+x+}
+						if res.diff then
+							local marked = {}
+							for _, segment in ipairs(res.diff) do
+								if segment.op == "extra" then
+									table.insert(marked, "[-" .. segment.text .. "-]")
+								elseif segment.op == "missing" then
+									table.insert(marked, "{+" .. segment.text .. "+}")
+								else
+									table.insert(marked, segment.text)
+								end
+							end
+							message = message .. "\n\nYour answer:\n" .. table.concat(marked)
+						end
+						message = message .. feedback
+						vim.notify(message, vim.log.levels.WARN)
 					end
 					draw_next_card()
 				end)
@@ -108,6 +137,13 @@ return {
 			for s in card.prompt:gmatch("[^\r\n]+") do
 				table.insert(lines, s)
 			end
+			if card.note then
+				table.insert(lines, "")
+				table.insert(lines, "Note:")
+				for s in card.note:gmatch("[^\r\n]+") do
+					table.insert(lines, s)
+				end
+			end
 			vim.api.nvim_buf_set_option(game_state.question_buf_id, "modifiable", true)
 			vim.api.nvim_buf_set_lines(game_state.question_buf_id, 0, -1, false, lines)
 			vim.api.nvim_buf_set_option(game_state.question_buf_id, "modifiable", false)
@@ -138,8 +174,12 @@ return {
 							return
 						end
 
-						if card.id == "done" then
-							vim.notify(card.prompt, vim.log.levels.INFO)
+						if card.status == "done" then
+							local message = card.message
+							if card.due_in then
+								message = message .. " Next card due in " .. card.due_in .. "."
+							end
+							vim.notify(message, vim.log.levels.INFO)
 							stop_game()
 							return
 						end
@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestNormalizeStringOptions(t *testing.T) {
+	french := []string{"le", "la", "les", "l'"}
+	tests := []struct {
+		name string
+		s    string
+		opts NormalizationOptions
+		want string
+	}{
+		{"defaults", " Int x = 1; ", NormalizationOptions{}, "intx=1"},
+		{"exact", " Int x = 1; ", NormalizationOptions{Exact: true}, " Int x = 1; "},
+		{"case sensitive", "Paris", NormalizationOptions{CaseSensitive: true}, "Paris"},
+		{"collapse whitespace", "  la   tour  ", NormalizationOptions{Whitespace: WhitespaceCollapse}, "la tour"},
+		{"keep whitespace", " a  b ", NormalizationOptions{Whitespace: WhitespaceKeep}, " a  b "},
+		{"keep semicolon", "x = 1;", NormalizationOptions{KeepSemicolon: true}, "x=1;"},
+		{"strip punctuation", "Oui, c'est ça !", NormalizationOptions{StripPunctuation: true, Whitespace: WhitespaceCollapse}, "oui cest ça"},
+		{"optional articles", "La Tour Eiffel", NormalizationOptions{OptionalArticles: french, Whitespace: WhitespaceCollapse}, "tour eiffel"},
+		{"elided article", "l'arbre", NormalizationOptions{OptionalArticles: french}, "arbre"},
+		{"typographic apostrophe", "l’arbre", NormalizationOptions{OptionalArticles: french}, "arbre"},
+		{"article inside a word", "lait", NormalizationOptions{OptionalArticles: french}, "lait"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeString(tt.s, tt.opts); got != tt.want {
+				t.Errorf("normalizeString(%q) = %q, want %q", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeStringUnicode(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		opts NormalizationOptions
+		same bool
+	}{
+		{"composed and decomposed accents", "café", "cafe\u0301", NormalizationOptions{}, true},
+		{"composed and decomposed, exact", "café", "cafe\u0301", NormalizationOptions{Exact: true}, true},
+		{"full-width letters", "ＡＢＣ", "abc", NormalizationOptions{}, true},
+		{"full-width letters under NFC", "ＡＢＣ", "abc", NormalizationOptions{Unicode: UnicodeNFC}, false},
+		{"sharp s", "Straße", "STRASSE", NormalizationOptions{}, true},
+		{"final sigma", "ΣΟΦΟΣ", "σοφος", NormalizationOptions{}, true},
+		{"ligature", "ﬁn", "fin", NormalizationOptions{}, true},
+		{"accents still count", "côte", "cote", NormalizationOptions{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := normalizeString(tt.a, tt.opts), normalizeString(tt.b, tt.opts)
+			if (a == b) != tt.same {
+				t.Errorf("normalizeString(%q) = %q, normalizeString(%q) = %q; same = %v, want %v", tt.a, a, tt.b, b, a == b, tt.same)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// diff.go
+//
+// Character-level diff between a wrong answer and the solution, so
+// frontends can highlight exactly where the learner went wrong.
+
+package main
+
+// Diff operations, seen from the learner's answer.
+const (
+	DiffEqual   = "equal"   // text present in both answer and solution
+	DiffMissing = "missing" // text of the solution the answer lacks
+	DiffExtra   = "extra"   // text of the answer the solution doesn't have
+)
+
+// DiffSegment is one run of characters sharing the same diff operation.
+type DiffSegment struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// maxDiffRunes bounds the quadratic LCS table; longer inputs get a single
+// extra/missing pair instead of a character-level diff.
+const maxDiffRunes = 2000
+
+// diffAnswer computes a character-level diff that turns answer into
+// solution, based on their longest common subsequence.
+func diffAnswer(answer, solution string) []DiffSegment {
+	a, b := []rune(answer), []rune(solution)
+	if len(a) > maxDiffRunes || len(b) > maxDiffRunes {
+		var segments []DiffSegment
+		segments = appendDiff(segments, DiffExtra, answer)
+		return appendDiff(segments, DiffMissing, solution)
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var segments []DiffSegment
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			segments = appendDiff(segments, DiffEqual, string(a[i]))
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			segments = appendDiff(segments, DiffExtra, string(a[i]))
+			i++
+		default:
+			segments = appendDiff(segments, DiffMissing, string(b[j]))
+			j++
+		}
+	}
+	segments = appendDiff(segments, DiffExtra, string(a[i:]))
+	return appendDiff(segments, DiffMissing, string(b[j:]))
+}
+
+// appendDiff adds text to the last segment if it has the same operation.
+func appendDiff(segments []DiffSegment, op, text string) []DiffSegment {
+	if text == "" {
+		return segments
+	}
+	if n := len(segments); n > 0 && segments[n-1].Op == op {
+		segments[n-1].Text += text
+		return segments
+	}
+	return append(segments, DiffSegment{Op: op, Text: text})
+}
@@ -0,0 +1,108 @@
+// due.go
+//
+// The due command, for status bars (tmux, i3blocks, polybar) that run it
+// every few seconds. It prints the number of cards that have gone their
+// box's interval without a review (see SchedulerConfig.boxIntervalDays)
+// or were introduced and never answered, or a one-line summary.
+//
+// To stay fast with years of history it reads progress.json into a
+// stripped-down struct that leaves out histories and everything else it
+// doesn't need, and neither migrates nor repairs; older or damaged files
+// fall back to the normal load.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// duePlayer is the part of PlayerData the due command needs.
+type duePlayer struct {
+	Name    string                  `json:"name"`
+	Cards   map[string]CardProgress `json:"cards"`
+	Skipped map[string]time.Time    `json:"skipped,omitempty"`
+}
+
+// --- Command Handlers ---
+
+func handleDue(playerID string, summary bool) {
+	player := readDuePlayer(playerID)
+	config := loadConfig()
+	if config.Decay.OnLoad {
+		full := PlayerData{Cards: player.Cards}
+		decayPlayer(&full, config.Decay.AfterDays, time.Now())
+	}
+
+	due, boxOne, retired := countDue(player, loadCards(), config.Scheduler, time.Now())
+	if !summary {
+		fmt.Println(due)
+		return
+	}
+	fmt.Printf(tr("%s: %d due, %d in box 1, %d retired\n"), player.Name, due, boxOne, retired)
+}
+
+// --- Helpers ---
+
+// countDue counts the player's due, box 1 and retired cards among cards,
+// leaving out skipped ones.
+func countDue(player duePlayer, cards []Card, config SchedulerConfig, now time.Time) (due, boxOne, retired int) {
+	inDeck := make(map[string]bool)
+	for _, card := range cards {
+		inDeck[card.ID] = true
+	}
+	for id, progress := range player.Cards {
+		if _, skipped := player.Skipped[id]; skipped || !inDeck[id] {
+			continue
+		}
+		if progress.Retired {
+			retired++
+			continue
+		}
+		if progress.Box == 1 {
+			boxOne++
+		}
+		// Cards just introduced to box 1 are waiting for their first answer
+		if inRotation(progress) && (progress.Passed+progress.Failed == 0 || isDue(progress, config, now)) {
+			due++
+		}
+	}
+	return due, boxOne, retired
+}
+
+// readDuePlayer reads one player from progress.json without histories.
+func readDuePlayer(playerID string) duePlayer {
+	filePath := progressPath()
+	file, err := ioutil.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		fatalf("Error reading progress file (%s): %v", filePath, err)
+	}
+	var stored struct {
+		Version int                  `json:"version"`
+		Players map[string]duePlayer `json:"players"`
+	}
+	if err == nil && json.Unmarshal(file, &stored) == nil && stored.Version == progressFormat {
+		if player, ok := stored.Players[playerID]; ok {
+			// Answers not yet folded into the file (see journal.go)
+			for _, entry := range readJournal() {
+				if entry.PlayerID == playerID && !entry.Practice {
+					if player.Cards == nil {
+						player.Cards = make(map[string]CardProgress)
+					}
+					player.Cards[entry.Answer.CardID] = entry.Card
+				}
+			}
+			return player
+		}
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+
+	player, ok := loadAllProgress()[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	return duePlayer{Name: player.Name, Cards: player.Cards, Skipped: player.Skipped}
+}
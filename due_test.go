@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCountDue(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cards := []Card{{ID: "new"}, {ID: "today"}, {ID: "stale"}, {ID: "fresh"}, {ID: "retired"}, {ID: "skipped"}}
+	player := duePlayer{
+		Cards: map[string]CardProgress{
+			"new":     {Box: 1, LastReviewed: now},
+			"today":   {Box: 1, Passed: 1, LastReviewed: now},
+			"stale":   {Box: 2, Passed: 2, LastReviewed: now.AddDate(0, 0, -2)},
+			"fresh":   {Box: 3, Passed: 3, LastReviewed: now.AddDate(0, 0, -1)},
+			"retired": {Box: topBox, Passed: 9, Retired: true},
+			"skipped": {Box: 1, LastReviewed: now.AddDate(0, 0, -5)},
+			"deleted": {Box: 1, LastReviewed: now.AddDate(0, 0, -5)},
+		},
+		Skipped: map[string]time.Time{"skipped": now},
+	}
+	due, boxOne, retired := countDue(player, cards, SchedulerConfig{}, now)
+	if due != 2 || boxOne != 2 || retired != 1 {
+		t.Errorf("countDue = %d due, %d in box 1, %d retired; want 2, 2, 1", due, boxOne, retired)
+	}
+}
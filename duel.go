@@ -0,0 +1,246 @@
+// duel.go
+//
+// Head-to-head duel mode: two players answer the same random sequence of
+// cards in alternating turns at one terminal. Duels are just for fun and
+// never touch the Leitner boxes; their outcome is stored in matches.json.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MatchPlayer is one participant's line in a match result.
+type MatchPlayer struct {
+	PlayerID string `json:"player_id"`
+	Name     string `json:"name"`
+	Score    int    `json:"score"`
+}
+
+// MatchResult records a finished duel.
+type MatchResult struct {
+	PlayedAt time.Time     `json:"played_at"`
+	CardIDs  []string      `json:"card_ids"`
+	Players  []MatchPlayer `json:"players"`
+	// WinnerID is empty when the duel ended in a draw.
+	WinnerID string `json:"winner_id"`
+}
+
+// --- Command Handlers ---
+
+func handleDuel(playerAID, playerBID string, rounds int) {
+	if playerAID == playerBID {
+		fatal("A duel needs two different players.")
+	}
+	cards := loadCards()
+	allProgress := loadAllProgress()
+	playerA, ok := allProgress[playerAID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerAID)
+	}
+	playerB, ok := allProgress[playerBID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerBID)
+	}
+	if len(cards) == 0 {
+		fatal("The deck has no cards to duel with.")
+	}
+	if rounds > len(cards) {
+		rounds = len(cards)
+	}
+
+	// Both players get the very same cards, in the same order
+	sequence := make([]Card, rounds)
+	for i, idx := range rand.Perm(len(cards))[:rounds] {
+		sequence[i] = cards[idx]
+	}
+
+	players := []MatchPlayer{
+		{PlayerID: playerAID, Name: playerA.Name},
+		{PlayerID: playerBID, Name: playerB.Name},
+	}
+	reader := bufio.NewReader(os.Stdin)
+	for _, p := range players {
+		publishEvent(Event{
+			Type:     EventSessionStart,
+			PlayerID: p.PlayerID,
+			Data:     map[string]interface{}{"mode": "duel", "rounds": rounds},
+		})
+	}
+
+	fmt.Printf(tr("Duel: %s vs. %s, %d round(s). Ctrl-D ends the duel early.\n"), playerA.Name, playerB.Name, rounds)
+	played := 0
+	for round, card := range sequence {
+		// Points only count once both players had their turn on the card
+		points := make([]int, len(players))
+		finished := false
+		for i := range players {
+			fmt.Printf(tr("\nRound %d/%d - %s's turn\n"), round+1, rounds, players[i].Name)
+			answer, ok, err := askCard(reader, card)
+			if err != nil {
+				// Nobody scores on a card that can't be shown
+				warnf("Skipping card '%s': %v", card.ID, err)
+				continue
+			}
+			if !ok {
+				finished = true
+				break
+			}
+			correct, err := isAnswerCorrect(card, answer)
+			if err != nil {
+				warnf("Could not check the answer to card '%s': %v", card.ID, err)
+			}
+			if correct {
+				points[i]++
+				fmt.Println(tr("Correct!"))
+			} else {
+				fmt.Printf(tr("Incorrect. The correct answer was: %s\n"), card.Solution)
+			}
+		}
+		if finished {
+			fmt.Println(tr("\nInput closed, ending the duel early."))
+			break
+		}
+		for i := range players {
+			players[i].Score += points[i]
+		}
+		played++
+		fmt.Printf(tr("Score: %s %d - %d %s\n"), players[0].Name, players[0].Score, players[1].Score, players[1].Name)
+	}
+
+	result := MatchResult{
+		PlayedAt: time.Now(),
+		Players:  players,
+	}
+	for _, card := range sequence[:played] {
+		result.CardIDs = append(result.CardIDs, card.ID)
+	}
+	switch {
+	case players[0].Score > players[1].Score:
+		result.WinnerID = players[0].PlayerID
+		fmt.Printf(tr("\n%s wins!\n"), players[0].Name)
+	case players[1].Score > players[0].Score:
+		result.WinnerID = players[1].PlayerID
+		fmt.Printf(tr("\n%s wins!\n"), players[1].Name)
+	default:
+		fmt.Println(tr("\nIt's a draw!"))
+	}
+
+	matches := loadMatches()
+	matches = append(matches, result)
+	saveMatches(matches)
+	for _, p := range players {
+		publishEvent(Event{
+			Type:     EventSessionEnd,
+			PlayerID: p.PlayerID,
+			Data:     map[string]interface{}{"mode": "duel", "score": p.Score, "won": p.PlayerID == result.WinnerID},
+		})
+	}
+}
+
+func handleMatchHistory(playerID string) {
+	matches := loadMatches()
+	locale := ""
+	if playerID != "" {
+		locale = loadAllProgress()[playerID].Locale
+	}
+	loc := resolveLocale(locale)
+	shown := 0
+	for _, match := range matches {
+		if playerID != "" && !matchHasPlayer(match, playerID) {
+			continue
+		}
+		var scores []string
+		for _, p := range match.Players {
+			scores = append(scores, fmt.Sprintf("%s %d", p.Name, p.Score))
+		}
+		winner := tr("draw")
+		for _, p := range match.Players {
+			if p.PlayerID == match.WinnerID {
+				winner = fmt.Sprintf(tr("%s won"), p.Name)
+			}
+		}
+		fmt.Printf(tr("%s  %s  (%d cards, %s)\n"), loc.DateTime(match.PlayedAt), strings.Join(scores, " - "), len(match.CardIDs), winner)
+		shown++
+	}
+	if shown == 0 {
+		fmt.Println(tr("No duels played yet. Start one with 'duel --player-a=<id> --player-b=<id>'"))
+	}
+}
+
+// --- Helpers ---
+
+// askCard shows a card's prompt and reads one line of input as the answer,
+// through the input helpers for the card's language (see input.go).
+// It returns false once the input is exhausted, and fails without asking if
+// the card can't be shown.
+func askCard(reader *bufio.Reader, card Card) (string, bool, error) {
+	card, err := renderCard(card)
+	if err != nil {
+		return "", true, err
+	}
+	fmt.Printf("[%s] %s\n> ", card.Language, card.Prompt)
+	answer, ok := readAnswer(reader)
+	if !ok {
+		return "", false, nil
+	}
+	return typedAnswer(card.Language, answer), true, nil
+}
+
+// readAnswer reads one line of input. It returns false once the input is
+// exhausted.
+func readAnswer(reader *bufio.Reader) (string, bool) {
+	line, err := reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", false
+	}
+	return strings.TrimRight(line, "\r\n"), true
+}
+
+func matchHasPlayer(match MatchResult, playerID string) bool {
+	for _, p := range match.Players {
+		if p.PlayerID == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+func loadMatches() []MatchResult {
+	filePath := filepath.Join(getDataDir(), "matches.json")
+	file, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		fatalf("Error reading match history (%s): %v", filePath, err)
+	}
+	var matches []MatchResult
+	if len(file) == 0 {
+		return matches
+	}
+	if err := json.Unmarshal(file, &matches); err != nil {
+		fatalf("Error unmarshalling match history JSON: %v", err)
+	}
+	return matches
+}
+
+func saveMatches(matches []MatchResult) {
+	filePath := filepath.Join(getDataDir(), "matches.json")
+	data, err := json.MarshalIndent(matches, "", "  ")
+	if err != nil {
+		fatalf("Error marshalling match history to JSON: %v", err)
+	}
+	if err := ioutil.WriteFile(filePath, data, 0644); err != nil {
+		fatalf("Error writing match history (%s): %v", filePath, err)
+	}
+}
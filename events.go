@@ -0,0 +1,210 @@
+// events.go
+//
+// A machine-readable stream of study events. Every answer, session and
+// milestone is published to the subscribers registered with
+// subscribeEvents. The first of them appends the event as one JSON line to
+// events.jsonl, which `events --follow` tails so dashboards and stream
+// overlays can react in real time without polling progress.json; others
+// such as webhooks register themselves in init.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Event types written to the stream.
+const (
+	EventAnswer       = "answer"
+	EventSessionStart = "session_start"
+	EventSessionEnd   = "session_end"
+	EventMilestone    = "milestone"
+)
+
+// Event is one line of events.jsonl.
+type Event struct {
+	Type      string                 `json:"type"`
+	Timestamp time.Time              `json:"timestamp"`
+	PlayerID  string                 `json:"player_id,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// answeredMilestones are the TotalAnswered values that emit a milestone.
+var answeredMilestones = map[int]bool{10: true, 50: true, 100: true, 250: true, 500: true, 1000: true, 2500: true, 5000: true, 10000: true}
+
+// streakMilestones are the daily streak lengths that emit a milestone.
+var streakMilestones = map[int]bool{3: true, 7: true, 14: true, 30: true, 50: true, 100: true, 200: true, 365: true}
+
+// eventPollInterval is how often `events --follow` checks for new lines.
+const eventPollInterval = 500 * time.Millisecond
+
+func eventsFilePath() string {
+	return filepath.Join(getDataDir(), "events.jsonl")
+}
+
+// eventSubscribers receive every published event, in order of
+// subscription.
+var eventSubscribers = []func(Event){appendEventLog}
+
+// subscribeEvents registers a subscriber for all events.
+func subscribeEvents(subscriber func(Event)) {
+	eventSubscribers = append(eventSubscribers, subscriber)
+}
+
+// publishEvent hands an event to every subscriber. Failing to deliver an
+// event must never fail the command that produced it, so subscribers only
+// log their errors.
+func publishEvent(event Event) {
+	// Guests leave no trace (see guest.go)
+	if isGuest(event.PlayerID) {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	for _, subscriber := range eventSubscribers {
+		subscriber(event)
+	}
+}
+
+// appendEventLog appends an event to events.jsonl.
+func appendEventLog(event Event) {
+	line, err := json.Marshal(event)
+	if err != nil {
+		warnf("could not encode %s event: %v", event.Type, err)
+		return
+	}
+	file, err := os.OpenFile(eventsFilePath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		warnf("could not open event log: %v", err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		warnf("could not write event log: %v", err)
+	}
+}
+
+// publishAnswerEvents emits the answer itself plus any milestones it reached.
+func publishAnswerEvents(playerID string, player PlayerData, card Card, progress CardProgress, correct bool, at time.Time) {
+	publishEvent(Event{
+		Type:      EventAnswer,
+		Timestamp: at,
+		PlayerID:  playerID,
+		Data: map[string]interface{}{
+			"card_id": card.ID,
+			"correct": correct,
+			"box":     progress.Box,
+			"streak":  progress.Streak,
+		},
+	})
+	if correct && progress.Box == topBox && progress.TopBoxPasses == 0 {
+		publishEvent(Event{
+			Type:      EventMilestone,
+			Timestamp: at,
+			PlayerID:  playerID,
+			Data:      map[string]interface{}{"milestone": "box_5", "card_id": card.ID},
+		})
+	}
+	if correct && progress.Retired {
+		publishEvent(Event{
+			Type:      EventMilestone,
+			Timestamp: at,
+			PlayerID:  playerID,
+			Data:      map[string]interface{}{"milestone": "card_mastered", "card_id": card.ID},
+		})
+	}
+	// The first answer of the day is the one that extends the streak
+	if n := len(player.History); n == 1 || (n > 1 && !calendarDay(player.History[n-2].Timestamp).Equal(calendarDay(at))) {
+		if streak, _ := dailyStreaks(player.History, at); streakMilestones[streak] {
+			publishEvent(Event{
+				Type:      EventMilestone,
+				Timestamp: at,
+				PlayerID:  playerID,
+				Data:      map[string]interface{}{"milestone": "daily_streak", "days": streak},
+			})
+		}
+	}
+	if answeredMilestones[player.TotalAnswered] {
+		publishEvent(Event{
+			Type:      EventMilestone,
+			Timestamp: at,
+			PlayerID:  playerID,
+			Data:      map[string]interface{}{"milestone": "total_answered", "count": player.TotalAnswered},
+		})
+	}
+}
+
+// --- Command Handlers ---
+
+func handleEvents(playerID string, follow bool) {
+	filePath := eventsFilePath()
+	file, err := os.OpenFile(filePath, os.O_RDONLY|os.O_CREATE, 0644)
+	if err != nil {
+		fatalf("Error opening event log (%s): %v", filePath, err)
+	}
+	defer func() { file.Close() }()
+
+	// Following only streams what happens from now on
+	var offset int64
+	if follow {
+		offset, err = file.Seek(0, io.SeekEnd)
+		if err != nil {
+			fatalf("Error seeking event log (%s): %v", filePath, err)
+		}
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	for {
+		offset = copyEvents(file, offset, playerID, out)
+		out.Flush()
+		if !follow {
+			return
+		}
+		time.Sleep(eventPollInterval)
+
+		// Start over if the log was truncated or replaced
+		info, err := os.Stat(filePath)
+		if err != nil {
+			continue
+		}
+		if info.Size() < offset {
+			file.Close()
+			file, err = os.Open(filePath)
+			if err != nil {
+				fatalf("Error reopening event log (%s): %v", filePath, err)
+			}
+			offset = 0
+		}
+	}
+}
+
+// copyEvents writes every complete line after offset that belongs to
+// playerID (or all lines if playerID is empty) and returns the new offset.
+func copyEvents(file *os.File, offset int64, playerID string, out io.Writer) int64 {
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		fatalf("Error seeking event log: %v", err)
+	}
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			// A partial line is still being written; pick it up next time
+			return offset
+		}
+		offset += int64(len(line))
+		if playerID != "" {
+			var event Event
+			if json.Unmarshal(line, &event) != nil || event.PlayerID != playerID {
+				continue
+			}
+		}
+		fmt.Fprint(out, string(line))
+	}
+}
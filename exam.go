@@ -0,0 +1,354 @@
+// exam.go
+//
+// Exam mode: a fixed set of cards matching some filters is asked exactly
+// once each, without any Leitner weighting, and graded at the end. Exams
+// don't touch the boxes; their reports are kept in exams.json so results
+// can be listed and compared over time.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CardFilter narrows the deck down by language and tags. Empty fields match
+// everything.
+type CardFilter struct {
+	Language string   `json:"language,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// ExamQuestion is the graded outcome of one exam card.
+type ExamQuestion struct {
+	CardID   string        `json:"card_id"`
+	Prompt   string        `json:"prompt"`
+	Answer   string        `json:"answer"`
+	Solution string        `json:"solution"`
+	Correct  bool          `json:"correct"`
+	Duration time.Duration `json:"duration"`
+}
+
+// ExamResult is the stored report of a finished exam.
+type ExamResult struct {
+	ID        string         `json:"id"`
+	PlayerID  string         `json:"player_id"`
+	TakenAt   time.Time      `json:"taken_at"`
+	Filter    CardFilter     `json:"filter"`
+	Questions []ExamQuestion `json:"questions"`
+	Score     int            `json:"score"`
+	Total     int            `json:"total"`
+	Duration  time.Duration  `json:"duration"`
+	Grade     string         `json:"grade"`
+}
+
+// --- Command Handlers ---
+
+func handleExam(playerID string, count int, filter CardFilter) {
+	cards := filterCards(loadCards(), filter)
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	if len(cards) == 0 {
+		fatal("No cards match the exam filters.")
+	}
+	if count > len(cards) {
+		count = len(cards)
+	}
+
+	// Every matching card is equally likely; boxes play no role in exams
+	rand.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
+	questions := cards[:count]
+
+	warnPausedSession(PausedExam, playerID)
+	result := ExamResult{
+		ID:       generateUniqueID(),
+		PlayerID: playerID,
+		TakenAt:  time.Now(),
+		Filter:   filter,
+		Total:    count,
+	}
+
+	publishEvent(Event{
+		Type:      EventSessionStart,
+		Timestamp: result.TakenAt,
+		PlayerID:  playerID,
+		Data:      map[string]interface{}{"mode": "exam", "session_id": result.ID, "questions": count},
+	})
+
+	fmt.Printf(tr("Exam for %s: %d question(s). Each card is asked once.\n"), player.Name, count)
+	runExam(PausedSession{Mode: PausedExam, PlayerID: playerID, Exam: &result}, questions, resolveLocale(player.Locale))
+}
+
+// handleResumeExam continues a paused exam with the questions it had left.
+// Questions whose cards have left the deck since are dropped.
+func handleResumeExam(playerID string) {
+	paused, ok := findPausedSession(PausedExam, playerID)
+	if !ok {
+		fatalf("No paused exam for player '%s'.", playerID)
+	}
+	player, ok := loadAllProgress()[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	cards := loadCards()
+	var questions []Card
+	for _, id := range paused.Pending {
+		if card, ok := findCard(cards, id); ok {
+			questions = append(questions, card)
+		} else {
+			warnf("card %s is no longer in the deck and is left out of the exam.", id)
+			paused.Exam.Total--
+		}
+	}
+	paused.Paused += time.Since(paused.PausedAt)
+	publishEvent(Event{
+		Type:     EventSessionStart,
+		PlayerID: playerID,
+		Data:     map[string]interface{}{"mode": "exam", "session_id": paused.Exam.ID, "questions": paused.Exam.Total, "resumed": true},
+	})
+
+	fmt.Printf(tr("Resuming the exam for %s: %d of %d question(s) left.\n"), player.Name, len(questions), paused.Exam.Total)
+	runExam(paused, questions, resolveLocale(player.Locale))
+}
+
+// runExam asks the remaining questions of an exam, checkpointing as it
+// goes, and grades it.
+func runExam(state PausedSession, questions []Card, loc Locale) {
+	playerID, result := state.PlayerID, state.Exam
+	reader := bufio.NewReader(os.Stdin)
+	pauseOnInterrupt("exam", playerID)
+
+	asked := len(result.Questions)
+	for i, card := range questions {
+		state.Pending = make([]string, 0, len(questions)-i)
+		for _, rest := range questions[i:] {
+			state.Pending = append(state.Pending, rest.ID)
+		}
+		checkpointSession(state)
+
+		fmt.Printf(tr("\nQuestion %d/%d\n"), asked+i+1, result.Total)
+		started := time.Now()
+		answer, ok, err := askCard(reader, card)
+		if err != nil {
+			// Counted as wrong, like an unanswered question
+			warnf("Skipping card '%s': %v", card.ID, err)
+			result.Questions = append(result.Questions, ExamQuestion{CardID: card.ID, Prompt: card.Prompt, Solution: card.Solution})
+			continue
+		}
+		if !ok {
+			fmt.Println(tr("\nInput closed, unanswered questions count as wrong."))
+			for _, rest := range questions[i:] {
+				result.Questions = append(result.Questions, ExamQuestion{CardID: rest.ID, Prompt: rest.Prompt, Solution: rest.Solution})
+			}
+			break
+		}
+		correct, err := isAnswerCorrect(card, answer)
+		if err != nil {
+			// Counted as wrong rather than losing the exam
+			warnf("Could not check the answer to card '%s': %v", card.ID, err)
+		}
+		question := ExamQuestion{
+			CardID:   card.ID,
+			Prompt:   card.Prompt,
+			Answer:   answer,
+			Solution: card.Solution,
+			Correct:  correct,
+			Duration: time.Since(started),
+		}
+		if question.Correct {
+			result.Score++
+		}
+		result.Questions = append(result.Questions, question)
+	}
+	result.Duration = time.Since(result.TakenAt) - state.Paused
+	result.Grade = examGrade(result.Score, result.Total)
+
+	exams := loadExams()
+	exams = append(exams, *result)
+	saveExams(exams)
+	clearPausedSession(PausedExam, playerID)
+	publishEvent(Event{
+		Type:     EventSessionEnd,
+		PlayerID: playerID,
+		Data:     map[string]interface{}{"mode": "exam", "session_id": result.ID, "score": result.Score, "total": result.Total, "grade": result.Grade},
+	})
+
+	printExamReport(*result, loc)
+}
+
+func handleListExams(playerID string) {
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	loc := resolveLocale(player.Locale)
+
+	// Compare each exam to the previous one taken with the same filters
+	previous := make(map[string]float64)
+	shown := 0
+	for _, exam := range loadExams() {
+		if exam.PlayerID != playerID {
+			continue
+		}
+		ratio := 0.0
+		if exam.Total > 0 {
+			ratio = float64(exam.Score) / float64(exam.Total)
+		}
+		key := describeFilter(exam.Filter)
+		change := ""
+		if last, ok := previous[key]; ok {
+			delta := (ratio - last) * 100
+			sign := "+"
+			if delta < 0 {
+				sign = ""
+			}
+			change = fmt.Sprintf("  (%s%s pts)", sign, loc.Float(delta, 1))
+		}
+		previous[key] = ratio
+		fmt.Printf("%s  %s  %d/%d  %s  %s  [%s]%s\n", loc.DateTime(exam.TakenAt), exam.Grade, exam.Score, exam.Total, loc.Percent(ratio), loc.Duration(exam.Duration), key, change)
+		shown++
+	}
+	if shown == 0 {
+		fmt.Printf(tr("No exams taken by %s yet. Start one with 'exam --player-id=%s'\n"), player.Name, playerID)
+	}
+}
+
+// --- Helpers ---
+
+func printExamReport(result ExamResult, loc Locale) {
+	fmt.Println(tr("\nExam Report"))
+	fmt.Println("-------------------------")
+	for i, q := range result.Questions {
+		mark := "✗"
+		if q.Correct {
+			mark = "✓"
+		}
+		fmt.Printf("%2d. %s %s (%s)\n", i+1, mark, q.CardID, loc.Duration(q.Duration))
+		if !q.Correct {
+			fmt.Printf("    %s %s\n    %s %s\n", tr("your answer:"), q.Answer, tr("solution:   "), q.Solution)
+		}
+	}
+	ratio := 0.0
+	if result.Total > 0 {
+		ratio = float64(result.Score) / float64(result.Total)
+	}
+	fmt.Println("-------------------------")
+	fmt.Printf(tr("Score: %d/%d (%s)\n"), result.Score, result.Total, loc.Percent(ratio))
+	fmt.Printf(tr("Grade: %s\n"), result.Grade)
+	fmt.Printf(tr("Time: %s\n"), loc.Duration(result.Duration))
+}
+
+// examGrade maps a score onto the usual A-F letter scale.
+func examGrade(score, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	percent := score * 100 / total
+	switch {
+	case percent >= 90:
+		return "A"
+	case percent >= 80:
+		return "B"
+	case percent >= 70:
+		return "C"
+	case percent >= 60:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+func filterCards(cards []Card, filter CardFilter) []Card {
+	var matched []Card
+	for _, card := range cards {
+		if filter.Matches(card) {
+			matched = append(matched, card)
+		}
+	}
+	return matched
+}
+
+// Matches reports whether card has the filter's language and at least one
+// of its tags.
+func (f CardFilter) Matches(card Card) bool {
+	if f.Language != "" && !strings.EqualFold(card.Language, f.Language) {
+		return false
+	}
+	if len(f.Tags) == 0 {
+		return true
+	}
+	for _, want := range f.Tags {
+		for _, tag := range card.Tags {
+			if strings.EqualFold(tag, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func describeFilter(filter CardFilter) string {
+	var parts []string
+	if filter.Language != "" {
+		parts = append(parts, "language="+filter.Language)
+	}
+	if len(filter.Tags) > 0 {
+		parts = append(parts, "tags="+strings.Join(filter.Tags, ","))
+	}
+	if len(parts) == 0 {
+		return "all cards"
+	}
+	return strings.Join(parts, " ")
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func loadExams() []ExamResult {
+	filePath := filepath.Join(getDataDir(), "exams.json")
+	file, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		fatalf("Error reading exam results (%s): %v", filePath, err)
+	}
+	var exams []ExamResult
+	if len(file) == 0 {
+		return exams
+	}
+	if err := json.Unmarshal(file, &exams); err != nil {
+		fatalf("Error unmarshalling exam results JSON: %v", err)
+	}
+	return exams
+}
+
+func saveExams(exams []ExamResult) {
+	filePath := filepath.Join(getDataDir(), "exams.json")
+	data, err := json.MarshalIndent(exams, "", "  ")
+	if err != nil {
+		fatalf("Error marshalling exam results to JSON: %v", err)
+	}
+	if err := ioutil.WriteFile(filePath, data, 0644); err != nil {
+		fatalf("Error writing exam results (%s): %v", filePath, err)
+	}
+}
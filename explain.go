@@ -0,0 +1,150 @@
+// explain.go
+//
+// Why this card: get-card --explain (or ?explain=true on the API) adds the
+// scheduler's reasoning to the card it serves. It shows the box the card
+// came from, the weight and interval of every box, the chance the pick had
+// among the cards that could be served, whether the card was due, and which
+// cards were left out and why. The chances are those of the draw that was
+// made, so they include boosts, adaptive weights and the hold-back rules.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PickExplanation is the reasoning behind one get-card pick.
+type PickExplanation struct {
+	Box       int     `json:"box"`
+	BoxWeight int     `json:"box_weight"`
+	Boost     float64 `json:"boost,omitempty"`
+	// Chance is the chance this card had of being drawn.
+	Chance       float64 `json:"chance"`
+	IntervalDays int     `json:"interval_days"`
+	// Due reports whether the card had gone its interval without a review;
+	// DueOn is the day it is or was due. New cards have neither.
+	Due     bool       `json:"due"`
+	DueOn   *time.Time `json:"due_on,omitempty"`
+	NewCard bool       `json:"new_card,omitempty"`
+	// Adaptive is set when the box weights follow the player's accuracy.
+	Adaptive bool             `json:"adaptive,omitempty"`
+	Boxes    []BoxExplanation `json:"boxes"`
+	// Filters describes the cards that couldn't be drawn.
+	Filters []string `json:"filters,omitempty"`
+	Seed    int64    `json:"seed"`
+}
+
+// BoxExplanation is one box at the time of a pick.
+type BoxExplanation struct {
+	Box int `json:"box"`
+	// Cards are the cards in the box, Candidates those that could be drawn.
+	Cards        int     `json:"cards"`
+	Candidates   int     `json:"candidates"`
+	Weight       int     `json:"weight"`
+	IntervalDays int     `json:"interval_days"`
+	Chance       float64 `json:"chance"`
+}
+
+// explainPick explains why chosen was drawn from box for player, who had
+// the given recent picks, at now. skipped is the number of cards the player
+// skipped and introduced the number of new cards this pick brought into box 1.
+func explainPick(cards []Card, player PlayerData, recent []string, config SchedulerConfig, now time.Time, chosen Card, box, skipped, introduced int, seed int64) *PickExplanation {
+	pool := buildPool(cards, player, recent, config)
+	weights := config.weightsFor(player)
+	multipliers := boostMultipliers(pool.all, player, now)
+
+	total := 0.0
+	boxWeights := make([]float64, topBox+1)
+	for b, inBox := range pool.boxes {
+		for _, card := range inBox {
+			weight := boostedWeight(weights[b-1], len(inBox), multipliers, card.ID)
+			boxWeights[b] += weight
+			total += weight
+		}
+	}
+	inBox := make([]int, topBox+1)
+	for _, card := range pool.all {
+		inBox[player.Cards[card.ID].Box]++
+	}
+
+	progress := player.Cards[chosen.ID]
+	explanation := &PickExplanation{
+		Box:          box,
+		BoxWeight:    weights[box-1],
+		Boost:        multipliers[chosen.ID],
+		Chance:       boostedWeight(weights[box-1], len(pool.boxes[box]), multipliers, chosen.ID) / total,
+		IntervalDays: config.boxIntervalDays(box),
+		NewCard:      progress.Passed+progress.Failed == 0,
+		Adaptive:     config.Adaptive.Enabled,
+		Seed:         seed,
+	}
+	if !explanation.NewCard {
+		dueOn := calendarDay(progress.LastReviewed).AddDate(0, 0, explanation.IntervalDays)
+		explanation.Due = isDue(progress, config, now)
+		explanation.DueOn = &dueOn
+	}
+	for b := 1; b <= topBox; b++ {
+		entry := BoxExplanation{
+			Box:          b,
+			Cards:        inBox[b],
+			Candidates:   len(pool.boxes[b]),
+			Weight:       weights[b-1],
+			IntervalDays: config.boxIntervalDays(b),
+		}
+		if total > 0 {
+			entry.Chance = boxWeights[b] / total
+		}
+		explanation.Boxes = append(explanation.Boxes, entry)
+	}
+	explanation.Filters = pickFilters(pool, len(cards), skipped, introduced)
+	return explanation
+}
+
+// --- Helpers ---
+
+// pickFilters describes the cards left out of a pick.
+func pickFilters(pool candidatePool, cards, skipped, introduced int) []string {
+	var filters []string
+	if skipped > 0 {
+		filters = append(filters, fmt.Sprintf("%d skipped card(s) left out", skipped))
+	}
+	if out := cards - len(pool.all); out > 0 {
+		filters = append(filters, fmt.Sprintf("%d retired or not yet introduced card(s) left out", out))
+	}
+	if introduced > 0 {
+		filters = append(filters, fmt.Sprintf("%d new card(s) put into box 1 by this pick", introduced))
+	}
+	relaxed := make(map[string]bool)
+	for _, rule := range pool.relaxed {
+		relaxed[rule] = true
+	}
+	held := 0
+	for _, card := range pool.all {
+		if pool.held[card.ID] {
+			held++
+		}
+	}
+	if held > 0 {
+		filter := fmt.Sprintf("%d recently served card(s) held back", held)
+		if relaxed["recent cards"] {
+			filter = fmt.Sprintf("%d recently served card(s) not held back, as no other card was left", held)
+		}
+		filters = append(filters, filter)
+	}
+	if len(pool.streakTags) > 0 {
+		var tags []string
+		for tag := range pool.streakTags {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		filter := fmt.Sprintf("cards tagged %s held back to break up a run", strings.Join(tags, ", "))
+		if relaxed["interleaving"] {
+			filter = fmt.Sprintf("cards tagged %s not held back, as no other card was left", strings.Join(tags, ", "))
+		}
+		filters = append(filters, filter)
+	}
+	return filters
+}
@@ -0,0 +1,218 @@
+// export.go
+//
+// Statistics export for get-stats --export. Everything is computed from the
+// answer history (archived entries included) and broken down per card, per
+// day and per tag. The format follows the file extension: .csv gives one
+// flat table for spreadsheets, .md a report to paste into notes.
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatsRow is one line of the exported breakdown.
+type StatsRow struct {
+	Section      string // "card", "day" or "tag"
+	Key          string
+	Label        string
+	Answered     int
+	Correct      int
+	Box          int  // cards only
+	Retired      bool // cards only
+	Cards        int  // tags only: distinct cards answered
+	LastAnswered time.Time
+}
+
+// Accuracy returns the share of correct answers.
+func (r StatsRow) Accuracy() float64 {
+	if r.Answered == 0 {
+		return 0
+	}
+	return float64(r.Correct) / float64(r.Answered)
+}
+
+// StatsReport is the full breakdown for one player.
+type StatsReport struct {
+	Player string
+	Cards  []StatsRow
+	Days   []StatsRow
+	Tags   []StatsRow
+}
+
+// buildStatsReport aggregates history per card, day and tag. Cards that are
+// no longer in the deck keep their rows but have no prompt or tags.
+func buildStatsReport(player PlayerData, history []AnswerLogItem, cards []Card) StatsReport {
+	cardsByID := make(map[string]Card, len(cards))
+	for _, card := range cards {
+		cardsByID[card.ID] = card
+	}
+
+	perCard := make(map[string]*StatsRow)
+	perDay := make(map[string]*StatsRow)
+	perTag := make(map[string]*StatsRow)
+	tagCards := make(map[string]map[string]bool)
+	add := func(rows map[string]*StatsRow, section, key string, item AnswerLogItem) *StatsRow {
+		row, ok := rows[key]
+		if !ok {
+			row = &StatsRow{Section: section, Key: key}
+			rows[key] = row
+		}
+		row.Answered++
+		if item.Correct {
+			row.Correct++
+		}
+		if item.Timestamp.After(row.LastAnswered) {
+			row.LastAnswered = item.Timestamp
+		}
+		return row
+	}
+
+	for _, item := range history {
+		card := cardsByID[item.CardID]
+		row := add(perCard, "card", item.CardID, item)
+		row.Label = card.Prompt
+		row.Box = player.Cards[item.CardID].Box
+		row.Retired = player.Cards[item.CardID].Retired
+		add(perDay, "day", calendarDay(item.Timestamp).Format("2006-01-02"), item)
+		for _, tag := range card.Tags {
+			add(perTag, "tag", tag, item)
+			if tagCards[tag] == nil {
+				tagCards[tag] = make(map[string]bool)
+			}
+			tagCards[tag][item.CardID] = true
+		}
+	}
+	for tag, row := range perTag {
+		row.Cards = len(tagCards[tag])
+	}
+
+	return StatsReport{
+		Player: player.Name,
+		Cards:  sortedRows(perCard),
+		Days:   sortedRows(perDay),
+		Tags:   sortedRows(perTag),
+	}
+}
+
+// boxLabel is the box column of a card row.
+func boxLabel(row StatsRow) string {
+	if row.Retired {
+		return "retired"
+	}
+	return strconv.Itoa(row.Box)
+}
+
+func sortedRows(rows map[string]*StatsRow) []StatsRow {
+	sorted := make([]StatsRow, 0, len(rows))
+	for _, row := range rows {
+		sorted = append(sorted, *row)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	return sorted
+}
+
+// exportStats writes the report to path in the format its extension asks for.
+func exportStats(path string, report StatsReport, loc Locale) {
+	var write func(io.Writer, StatsReport, Locale) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		write = writeStatsCSV
+	case ".md", ".markdown":
+		write = writeStatsMarkdown
+	default:
+		fatalf("Unknown export format '%s', expected a .csv or .md file.", filepath.Ext(path))
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		fatalf("Error creating export file (%s): %v", path, err)
+	}
+	if err := write(file, report, loc); err != nil {
+		file.Close()
+		fatalf("Error writing export file (%s): %v", path, err)
+	}
+	if err := file.Close(); err != nil {
+		fatalf("Error writing export file (%s): %v", path, err)
+	}
+}
+
+// writeStatsCSV writes all sections into one table with a "section" column.
+// Numbers are left unformatted so spreadsheets can parse them.
+func writeStatsCSV(out io.Writer, report StatsReport, _ Locale) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"section", "key", "label", "answered", "correct", "incorrect", "accuracy", "box", "cards", "last_answered"})
+	for _, rows := range [][]StatsRow{report.Cards, report.Days, report.Tags} {
+		for _, row := range rows {
+			box, cards := "", ""
+			switch row.Section {
+			case "card":
+				box = boxLabel(row)
+			case "tag":
+				cards = strconv.Itoa(row.Cards)
+			}
+			w.Write([]string{
+				row.Section,
+				row.Key,
+				row.Label,
+				strconv.Itoa(row.Answered),
+				strconv.Itoa(row.Correct),
+				strconv.Itoa(row.Answered - row.Correct),
+				strconv.FormatFloat(row.Accuracy(), 'f', 4, 64),
+				box,
+				cards,
+				row.LastAnswered.Format(time.RFC3339),
+			})
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func writeStatsMarkdown(out io.Writer, report StatsReport, loc Locale) error {
+	var b strings.Builder
+	total, correct := 0, 0
+	for _, row := range report.Days {
+		total += row.Answered
+		correct += row.Correct
+	}
+	fmt.Fprintf(&b, "# Statistics for %s\n\n", markdownCell(report.Player))
+	fmt.Fprintf(&b, "Generated %s from %s answer(s) on %s day(s).\n", loc.DateTime(time.Now()), loc.Number(total), loc.Number(len(report.Days)))
+	if total > 0 {
+		fmt.Fprintf(&b, "Overall accuracy: %s.\n", loc.Percent(float64(correct)/float64(total)))
+	}
+
+	b.WriteString("\n## Per Card\n\n| Card | Prompt | Box | Answered | Correct | Accuracy | Last Answered |\n|---|---|---:|---:|---:|---:|---|\n")
+	for _, row := range report.Cards {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n", markdownCell(row.Key), markdownCell(row.Label), boxLabel(row),
+			loc.Number(row.Answered), loc.Number(row.Correct), loc.Percent(row.Accuracy()), loc.Date(row.LastAnswered))
+	}
+
+	b.WriteString("\n## Per Day\n\n| Day | Answered | Correct | Accuracy |\n|---|---:|---:|---:|\n")
+	for _, row := range report.Days {
+		day, _ := time.ParseInLocation("2006-01-02", row.Key, time.Local)
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", loc.Date(day), loc.Number(row.Answered), loc.Number(row.Correct), loc.Percent(row.Accuracy()))
+	}
+
+	b.WriteString("\n## Per Tag\n\n| Tag | Cards | Answered | Correct | Accuracy |\n|---|---:|---:|---:|---:|\n")
+	for _, row := range report.Tags {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", markdownCell(row.Key), loc.Number(row.Cards), loc.Number(row.Answered), loc.Number(row.Correct), loc.Percent(row.Accuracy()))
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// markdownCell keeps a value from breaking the table it is put in.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
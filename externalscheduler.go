@@ -0,0 +1,259 @@
+// externalscheduler.go
+//
+// Scheduler plugins, for trying other algorithms (FSRS, SM-2, something of
+// your own) without changing this program. With
+//
+//	{ "scheduler": { "plugin": "fsrs", "plugin_options": {"retention": 0.9} } }
+//
+// get-card, check-answer, simulate and bench hand their decisions to an
+// executable named decouvertes-scheduler-fsrs on PATH, or to the path given
+// if the name has a slash in it, relative to the config directory. Like
+// card type plugins it is called once per action with a JSON request on
+// stdin and answers with a JSON response on stdout:
+//
+//	{"action": "next", "player": {...}, "cards": [...], "review_ahead": false, "now": "...", "seed": 1, "options": {...}}
+//	    -> {"card_id": "fr_chat", "introduce": ["fr_chien"]}, or {"card_id": ""} when done
+//	{"action": "answer", "player": {...}, "card": {...}, "correct": true, "now": "...", "options": {...}}
+//	    -> {"progress": {"box": 2, ..., "scheduler_state": {...}}}
+//
+// "player" holds the player's progress on every card and the recent picks,
+// newest last; "cards" is the deck without the player's skipped cards. The
+// cards named in "introduce" are put into box 1 before the pick. A plugin
+// keeps what it needs per card, such as stability and difficulty, in
+// "scheduler_state", which is stored with the card's progress and sent back
+// with every request. The box it returns is what get-stats and the charts
+// show. Go's own plugin package was left out as it only works on some
+// platforms and needs the plugin built with the exact same toolchain.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// schedulerPrefix is the executable name prefix scheduler plugins are
+// discovered by.
+const schedulerPrefix = "decouvertes-scheduler-"
+
+// builtinScheduler is the name of the Leitner boxes in "plugin".
+const builtinScheduler = "leitner"
+
+// pluginState is what a scheduler plugin keeps for a card: JSON, held as a
+// string so that CardProgress values can still be compared.
+type pluginState string
+
+func (s pluginState) MarshalJSON() ([]byte, error) {
+	if s == "" {
+		return []byte("null"), nil
+	}
+	return []byte(s), nil
+}
+
+func (s *pluginState) UnmarshalJSON(data []byte) error {
+	*s = ""
+	if string(data) != "null" {
+		*s = pluginState(data)
+	}
+	return nil
+}
+
+// schedulerPlayer is the part of a player a scheduler plugin gets.
+type schedulerPlayer struct {
+	Cards       map[string]CardProgress `json:"cards"`
+	RecentCards []string                `json:"recent_cards"`
+}
+
+// schedulerRequest is what a scheduler plugin receives on stdin.
+type schedulerRequest struct {
+	Action      string          `json:"action"`
+	Player      schedulerPlayer `json:"player"`
+	Cards       []Card          `json:"cards,omitempty"`
+	Card        *Card           `json:"card,omitempty"`
+	Correct     *bool           `json:"correct,omitempty"`
+	ReviewAhead bool            `json:"review_ahead,omitempty"`
+	Now         time.Time       `json:"now"`
+	Seed        int64           `json:"seed,omitempty"`
+	Options     json.RawMessage `json:"options,omitempty"`
+}
+
+// externalScheduler runs a plugin speaking the protocol described at the
+// top of this file.
+type externalScheduler struct {
+	path    string
+	options json.RawMessage
+}
+
+func (s externalScheduler) NextCard(player PlayerData, cards []Card, reviewAhead bool, now time.Time, rng *rand.Rand) (Pick, bool, error) {
+	var pick Pick
+	var response struct {
+		CardID    string   `json:"card_id"`
+		Introduce []string `json:"introduce"`
+	}
+	request := s.request("next", player, now)
+	request.Cards, request.ReviewAhead, request.Seed = cards, reviewAhead, rng.Int63()
+	if err := s.call(request, &response); err != nil {
+		return pick, false, err
+	}
+
+	byID := make(map[string]Card, len(cards))
+	for _, card := range cards {
+		byID[card.ID] = card
+	}
+	for _, id := range response.Introduce {
+		card, ok := byID[id]
+		if !ok {
+			return pick, false, fmt.Errorf("it introduced card '%s', which isn't in the deck", id)
+		}
+		if _, seen := player.Cards[id]; !seen {
+			player.Cards[id] = CardProgress{Box: 1, LastReviewed: now}
+			pick.Introduced = append(pick.Introduced, card)
+		}
+	}
+	if response.CardID == "" {
+		return pick, false, nil
+	}
+	card, ok := byID[response.CardID]
+	if !ok {
+		return pick, false, fmt.Errorf("it picked card '%s', which isn't in the deck", response.CardID)
+	}
+	progress := player.Cards[card.ID]
+	pick.Card, pick.Box = card, max(progress.Box, 1)
+	pick.Ahead = !inRotation(progress)
+	return pick, true, nil
+}
+
+func (s externalScheduler) OnAnswer(player PlayerData, card Card, correct bool, now time.Time) (CardProgress, error) {
+	var response struct {
+		Progress *CardProgress `json:"progress"`
+	}
+	request := s.request("answer", player, now)
+	request.Card, request.Correct = &card, &correct
+	if err := s.call(request, &response); err != nil {
+		return CardProgress{}, err
+	}
+	if response.Progress == nil {
+		return CardProgress{}, fmt.Errorf("its answer response has no \"progress\"")
+	}
+	if response.Progress.Box < 1 || response.Progress.Box > topBox {
+		return CardProgress{}, fmt.Errorf("it put card '%s' into box %d; boxes go from 1 to %d", card.ID, response.Progress.Box, topBox)
+	}
+	return *response.Progress, nil
+}
+
+func (s externalScheduler) request(action string, player PlayerData, now time.Time) schedulerRequest {
+	return schedulerRequest{
+		Action:  action,
+		Player:  schedulerPlayer{Cards: player.Cards, RecentCards: player.RecentCards},
+		Now:     now,
+		Options: s.options,
+	}
+}
+
+func (s externalScheduler) call(request schedulerRequest, response interface{}) error {
+	input, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), externalCheckerTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, s.path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(output, response); err != nil {
+		return fmt.Errorf("invalid %s response: %v", request.Action, err)
+	}
+	return nil
+}
+
+// activeScheduler returns the scheduler config.json asks for.
+func activeScheduler(config SchedulerConfig) (Scheduler, error) {
+	if config.Plugin == "" || config.Plugin == builtinScheduler {
+		return leitnerScheduler{config: config}, nil
+	}
+	path, err := findSchedulerPlugin(config.Plugin)
+	if err != nil {
+		return nil, err
+	}
+	return externalScheduler{path: path, options: config.PluginOptions}, nil
+}
+
+// findSchedulerPlugin resolves a plugin name to an executable: names with a
+// slash are paths relative to the config directory, others are looked up on
+// PATH with the decouvertes-scheduler- prefix.
+func findSchedulerPlugin(name string) (string, error) {
+	if strings.ContainsRune(name, filepath.Separator) || strings.ContainsRune(name, '/') {
+		if filepath.IsAbs(name) {
+			return name, nil
+		}
+		return filepath.Join(getConfigDir(), name), nil
+	}
+	path, err := exec.LookPath(schedulerPrefix + name)
+	if err != nil {
+		return "", fmt.Errorf("no scheduler plugin '%s'; install %s%s on your PATH or give its path in \"plugin\"", name, schedulerPrefix, name)
+	}
+	return path, nil
+}
+
+// --- Command Handlers ---
+
+// handleSchedulers lists the built-in scheduler and the plugins on PATH,
+// marking the one in use.
+func handleSchedulers() {
+	found := map[string]string{builtinScheduler: "(built in)"}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasPrefix(name, schedulerPrefix) || entry.Mode()&0111 == 0 {
+				continue
+			}
+			plugin := strings.TrimSuffix(strings.TrimPrefix(name, schedulerPrefix), ".exe")
+			// Earlier PATH entries shadow later ones, like the shell does
+			if _, ok := found[plugin]; !ok {
+				found[plugin] = filepath.Join(dir, name)
+			}
+		}
+	}
+	active := loadConfig().Scheduler.Plugin
+	if active == "" {
+		active = builtinScheduler
+	}
+	if _, ok := found[active]; !ok {
+		path, err := findSchedulerPlugin(active)
+		if err != nil {
+			fatalf("Error finding the scheduler: %v", err)
+		}
+		found[active] = path
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		marker := " "
+		if name == active {
+			marker = "*"
+		}
+		fmt.Printf("%s %-20s %s\n", marker, name, found[name])
+	}
+}
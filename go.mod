@@ -1,3 +1,8 @@
 module github.com/k1tesurfen/decouvertes
 
 go 1.24.5
+
+require (
+	github.com/nicksnyder/go-i18n/v2 v2.6.1
+	golang.org/x/text v0.32.0
+)
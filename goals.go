@@ -0,0 +1,116 @@
+// goals.go
+//
+// Study goals: a number of reviews a day and a number of new cards a week.
+// Progress is counted from the answer history, so goals can be changed at
+// any time and apply to today's answers right away. Weeks start on Monday.
+// A card counts as new in the week of its first answer.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+// Goals are a player's study goals; 0 means no goal.
+type Goals struct {
+	DailyReviews int `json:"daily_reviews,omitempty"`
+	WeeklyNew    int `json:"weekly_new,omitempty"`
+}
+
+// GoalProgress is how far a player is towards their goals.
+type GoalProgress struct {
+	Goals
+	ReviewsToday    int
+	NewThisWeek     int
+	DailyRemaining  int
+	WeeklyRemaining int
+}
+
+// goalProgress counts the player's answers towards their goals as of now.
+func goalProgress(player PlayerData, now time.Time) GoalProgress {
+	progress := GoalProgress{Goals: player.Goals}
+	today := calendarDay(now)
+	weekStart := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+
+	answersThisWeek := make(map[string]int)
+	for _, item := range player.History {
+		day := calendarDay(item.Timestamp)
+		if day.Equal(today) {
+			progress.ReviewsToday++
+		}
+		if !day.Before(weekStart) && !day.After(today) {
+			answersThisWeek[item.CardID]++
+		}
+	}
+	// A card is new this week if every answer it ever got was given this week
+	for cardID, n := range answersThisWeek {
+		if card := player.Cards[cardID]; card.Passed+card.Failed <= n {
+			progress.NewThisWeek++
+		}
+	}
+
+	progress.DailyRemaining = max(progress.DailyReviews-progress.ReviewsToday, 0)
+	progress.WeeklyRemaining = max(progress.WeeklyNew-progress.NewThisWeek, 0)
+	return progress
+}
+
+// publishGoalEvents emits a milestone for each goal the latest answer
+// completed. before is the progress without it.
+func publishGoalEvents(playerID string, before, after GoalProgress, at time.Time) {
+	if after.DailyReviews > 0 && before.DailyRemaining > 0 && after.DailyRemaining == 0 {
+		publishEvent(Event{
+			Type:      EventMilestone,
+			Timestamp: at,
+			PlayerID:  playerID,
+			Data:      map[string]interface{}{"milestone": "daily_goal", "reviews": after.ReviewsToday},
+		})
+	}
+	if after.WeeklyNew > 0 && before.WeeklyRemaining > 0 && after.WeeklyRemaining == 0 {
+		publishEvent(Event{
+			Type:      EventMilestone,
+			Timestamp: at,
+			PlayerID:  playerID,
+			Data:      map[string]interface{}{"milestone": "weekly_goal", "new_cards": after.NewThisWeek},
+		})
+	}
+}
+
+// --- Command Handlers ---
+
+func handleSetGoal(fs *flag.FlagSet, playerID string, dailyReviews, weeklyNew int) {
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	// Only the goals given on the command line change
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "daily-reviews":
+			player.Goals.DailyReviews = dailyReviews
+		case "weekly-new":
+			player.Goals.WeeklyNew = weeklyNew
+		}
+	})
+	allProgress[playerID] = player
+	saveAllProgress(allProgress)
+
+	fmt.Printf(tr("Goals for %s:\n"), player.Name)
+	printGoals(goalProgress(player, time.Now()), resolveLocale(player.Locale))
+}
+
+// printGoals prints goal progress as get-stats shows it.
+func printGoals(progress GoalProgress, loc Locale) {
+	if progress.DailyReviews == 0 && progress.WeeklyNew == 0 {
+		fmt.Println(tr("No goals set."))
+		return
+	}
+	if progress.DailyReviews > 0 {
+		fmt.Printf(tr("Daily Goal: %s of %s reviews today\n"), loc.Number(progress.ReviewsToday), loc.Number(progress.DailyReviews))
+	}
+	if progress.WeeklyNew > 0 {
+		fmt.Printf(tr("Weekly Goal: %s of %s new cards this week\n"), loc.Number(progress.NewThisWeek), loc.Number(progress.WeeklyNew))
+	}
+}
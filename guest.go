@@ -0,0 +1,80 @@
+// guest.go
+//
+// Guest players, for letting a friend try the deck without touching
+// anyone's stats. A guest exists only in memory: it is added to the
+// progress every command reads and taken out again before progress is
+// saved, so it lasts as long as the study session or the server it was
+// created in. Guests publish no events, so hooks, webhooks and the event
+// log don't hear of them either.
+
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// guestPrefix starts the ID of every guest player.
+const guestPrefix = "guest-"
+
+var (
+	guestMu sync.Mutex
+	// guests holds the guest players by ID.
+	guests = make(map[string]PlayerData)
+)
+
+// createGuest adds a guest player and returns its ID.
+func createGuest(name string) string {
+	guestMu.Lock()
+	defer guestMu.Unlock()
+	id := guestPrefix + generateUniqueID()[:8]
+	guests[id] = PlayerData{
+		Name:    name,
+		Cards:   make(map[string]CardProgress),
+		History: make([]AnswerLogItem, 0),
+	}
+	return id
+}
+
+// isGuest reports whether a player ID belongs to a guest.
+func isGuest(playerID string) bool {
+	return strings.HasPrefix(playerID, guestPrefix)
+}
+
+// withGuests adds the guest players to progress read from disk.
+func withGuests(progress map[string]PlayerData) map[string]PlayerData {
+	guestMu.Lock()
+	defer guestMu.Unlock()
+	for id, player := range cloneProgress(guests) {
+		progress[id] = player
+	}
+	return progress
+}
+
+// withoutGuests takes the guest players out of progress about to be saved
+// and keeps them in memory instead.
+func withoutGuests(progress map[string]PlayerData) map[string]PlayerData {
+	guestMu.Lock()
+	defer guestMu.Unlock()
+	if len(guests) == 0 {
+		return progress
+	}
+	stored := make(map[string]PlayerData, len(progress))
+	for id, player := range progress {
+		if _, ok := guests[id]; ok {
+			guests[id] = cloneProgress(map[string]PlayerData{id: player})[id]
+		} else {
+			stored[id] = player
+		}
+	}
+	return stored
+}
+
+// deleteGuest removes a guest player; it reports whether there was one.
+func deleteGuest(playerID string) bool {
+	guestMu.Lock()
+	defer guestMu.Unlock()
+	_, ok := guests[playerID]
+	delete(guests, playerID)
+	return ok
+}
@@ -0,0 +1,88 @@
+// history.go
+//
+// The history command: a player's answers, archived ones included, with
+// filters for time, card and wrong answers, as text or as JSON for other
+// tools.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// HistoryFilter selects answers for the history command.
+type HistoryFilter struct {
+	Since      time.Time
+	CardID     string
+	OnlyWrong  bool
+	OnlySpoken bool
+}
+
+// matches reports whether an answer passes the filter.
+func (f HistoryFilter) matches(item AnswerLogItem) bool {
+	switch {
+	case !f.Since.IsZero() && item.Timestamp.Before(f.Since):
+		return false
+	case f.CardID != "" && item.CardID != f.CardID:
+		return false
+	case f.OnlyWrong && item.Correct:
+		return false
+	case f.OnlySpoken && !item.Spoken:
+		return false
+	}
+	return true
+}
+
+// --- Command Handlers ---
+
+func handleHistory(playerID string, filter HistoryFilter, asJSON bool) {
+	player, ok := loadAllProgress()[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	items := []AnswerLogItem{}
+	for _, item := range loadFullHistory(playerID, player) {
+		if filter.matches(item) {
+			items = append(items, item)
+		}
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(items); err != nil {
+			fatalf("Error writing history JSON: %v", err)
+		}
+		return
+	}
+
+	if len(items) == 0 {
+		fmt.Println(tr("No answers match."))
+		return
+	}
+	prompts := make(map[string]string)
+	for _, card := range loadCards() {
+		prompts[card.ID] = firstLine(card.Prompt)
+	}
+	loc := resolveLocale(player.Locale)
+	correct := 0
+	for _, item := range items {
+		mark := "wrong"
+		if item.Correct {
+			mark = "right"
+			correct++
+		}
+		prompt, ok := prompts[item.CardID]
+		if !ok {
+			prompt = "(no longer in the deck)"
+		}
+		if item.Spoken {
+			prompt += " (spoken)"
+		}
+		fmt.Printf("%s  %-5s  %s  %s\n", loc.DateTime(item.Timestamp), mark, item.CardID, prompt)
+	}
+	fmt.Printf(tr("\n%s answer(s), %s correct.\n"), loc.Number(len(items)), loc.Number(correct))
+}
@@ -0,0 +1,91 @@
+// hooks.go
+//
+// Event hooks: executables in the hooks/ directory next to cards.json that
+// run on events, for integrations too small to fork the program for, such
+// as logging answers to a spreadsheet or playing a sound. A hook is named
+// after the event it handles:
+//
+//	pre-session    a study session, exam or duel starts
+//	post-answer    an answer was recorded
+//	post-session   a session ended
+//	on-milestone   a milestone was reached
+//
+// The event is passed as JSON on stdin (the same object as a line of
+// events.jsonl), with its type and player in DECOUVERTES_EVENT and
+// DECOUVERTES_PLAYER_ID. Anything a hook prints goes to stderr, so it can't
+// get mixed into JSON output meant for the editor plugin. A failing hook
+// is reported but never fails the command.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// hookNames maps event types to the hooks that handle them.
+var hookNames = map[string]string{
+	EventSessionStart: "pre-session",
+	EventAnswer:       "post-answer",
+	EventSessionEnd:   "post-session",
+	EventMilestone:    "on-milestone",
+}
+
+// hookTimeout bounds how long a hook may take. Hooks with longer work
+// should start it in the background.
+const hookTimeout = 10 * time.Second
+
+func init() {
+	subscribeEvents(runHook)
+}
+
+// runHook runs the hook for an event, if there is one.
+func runHook(event Event) {
+	path, ok := findHook(hookNames[event.Type])
+	if !ok {
+		return
+	}
+	input, err := json.Marshal(event)
+	if err != nil {
+		warnf("could not encode %s event for hook: %v", event.Type, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "DECOUVERTES_EVENT="+event.Type, "DECOUVERTES_PLAYER_ID="+event.PlayerID)
+	if err := cmd.Run(); err != nil {
+		warnf("hook %s failed: %v", path, err)
+	}
+}
+
+// --- Helpers ---
+
+// findHook returns the executable for a hook name. On Windows the usual
+// executable extensions are tried as well.
+func findHook(name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+	base := filepath.Join(getConfigDir(), "hooks", name)
+	candidates := []string{base}
+	if runtime.GOOS == "windows" {
+		candidates = append(candidates, base+".exe", base+".bat", base+".cmd")
+	}
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,148 @@
+// i18n.go
+//
+// Translations of the command-line output. Messages are looked up by their
+// English text, format verbs included, in the catalogs under locales/ (one
+// go-i18n JSON file per language, embedded in the binary); text without a
+// translation is shown in English. Leading and trailing whitespace isn't
+// part of a message, so "\nCard %d/%d\n" is translated as "Card %d/%d".
+// Errors and warnings are translated on the console only: the log file and
+// telemetry keep the English text. JSON output is never translated.
+//
+// Messages and the number and date formats (see locale.go) follow one
+// language: the --lang flag, else the player's locale, the "locale" of
+// config.json, or the first of LC_ALL, LC_MESSAGES and LANG that names one
+// (see outputTag).
+
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+)
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// l10nKey is the log attribute holding the translation of an error or
+// warning, which the console shows instead of the English message.
+const l10nKey = "l10n"
+
+var (
+	// bundle holds the catalogs; nil until setLanguage is called.
+	bundle *i18n.Bundle
+	// localizer translates messages into the current output language.
+	localizer *i18n.Localizer
+	// localizerMu guards localizer, which resolveLocale switches to a
+	// player's language.
+	localizerMu sync.Mutex
+	// outputLanguage is the --lang flag.
+	outputLanguage string
+)
+
+// setLanguage loads the catalogs and picks the output language.
+func setLanguage(lang string) {
+	catalogs := i18n.NewBundle(language.English)
+	catalogs.RegisterUnmarshalFunc("json", json.Unmarshal)
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		fatalf("Error reading translations: %v", err)
+	}
+	for _, entry := range entries {
+		filePath := path.Join("locales", entry.Name())
+		data, err := localeFiles.ReadFile(filePath)
+		if err == nil {
+			_, err = catalogs.ParseMessageFileBytes(data, filePath)
+		}
+		if err != nil {
+			fatalf("Error reading translations (%s): %v", filePath, err)
+		}
+	}
+
+	if lang != "" && languageTag(lang) == "" {
+		fatalf("Unknown language '%s', expected a tag such as fr, de or es.", lang)
+	}
+	bundle = catalogs
+	outputLanguage = lang
+	useLanguage(outputTag(""))
+}
+
+// outputTag returns the language tag output is written in, for a player
+// with the given locale ("" for none): the --lang flag, the player's
+// locale, the config override, then the POSIX environment variables. It is
+// "" if none of them names a language.
+func outputTag(playerLocale string) string {
+	candidates := []string{outputLanguage, playerLocale, loadConfig().Locale, os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")}
+	for _, candidate := range candidates {
+		if tag := languageTag(candidate); tag != "" {
+			return tag
+		}
+	}
+	return ""
+}
+
+// useLanguage switches message translation to tag, or to English for "".
+func useLanguage(tag string) {
+	if bundle == nil {
+		return
+	}
+	if tag == "" {
+		tag = "en"
+	}
+	localizerMu.Lock()
+	defer localizerMu.Unlock()
+	localizer = i18n.NewLocalizer(bundle, tag)
+}
+
+// tr translates a message, or returns it unchanged if there is no
+// translation.
+func tr(message string) string {
+	trimmed := strings.TrimSpace(message)
+	localizerMu.Lock()
+	current := localizer
+	localizerMu.Unlock()
+	if current == nil || trimmed == "" {
+		return message
+	}
+	translated, err := current.Localize(&i18n.LocalizeConfig{MessageID: trimmed})
+	if err != nil || translated == "" {
+		return message
+	}
+	start := strings.Index(message, trimmed)
+	return message[:start] + translated + message[start+len(trimmed):]
+}
+
+// localizedAttrs returns the log attribute with the translation of a
+// formatted error or warning, if it has one.
+func localizedAttrs(format string, args ...interface{}) []any {
+	if translated := tr(format); translated != format {
+		return []any{slog.String(l10nKey, fmt.Sprintf(translated, args...))}
+	}
+	return nil
+}
+
+// --- Helpers ---
+
+// languageTag turns POSIX locale names like "de_DE.UTF-8" into language
+// tags like "de-DE". It returns "" for anything that isn't one.
+func languageTag(name string) string {
+	if i := strings.IndexAny(name, ".@"); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.ReplaceAll(name, "_", "-")
+	if name == "" || strings.EqualFold(name, "C") || strings.EqualFold(name, "POSIX") {
+		return ""
+	}
+	if _, err := language.Parse(name); err != nil {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// formatVerb matches fmt verbs, so that "%s\n" doesn't count as text.
+var formatVerb = regexp.MustCompile(`%[-+# 0-9.*\[\]]*[a-zA-Z%]`)
+
+// parseSources parses the non-test Go files of the package.
+func parseSources(t *testing.T) (*token.FileSet, []*ast.File) {
+	t.Helper()
+	paths, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	return fset, files
+}
+
+// stringLiteral returns the value of a string literal expression.
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	return value, err == nil
+}
+
+func TestOutputIsTranslated(t *testing.T) {
+	fset, files := parseSources(t)
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "fmt" {
+				return true
+			}
+			switch sel.Sel.Name {
+			case "Printf", "Println", "Print":
+			default:
+				return true
+			}
+			text, ok := stringLiteral(call.Args[0])
+			if !ok {
+				return true
+			}
+			if strings.IndexFunc(formatVerb.ReplaceAllString(text, ""), unicode.IsLetter) >= 0 {
+				t.Errorf("%s: fmt.%s(%q) prints text without tr()", fset.Position(call.Pos()), sel.Sel.Name, text)
+			}
+			return true
+		})
+	}
+}
+
+func TestCatalogsHaveEveryMessage(t *testing.T) {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalogs := make(map[string]map[string]string)
+	for _, entry := range entries {
+		data, err := localeFiles.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		catalog := make(map[string]string)
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			t.Fatalf("%s: %v", entry.Name(), err)
+		}
+		catalogs[entry.Name()] = catalog
+	}
+
+	fset, files := parseSources(t)
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 {
+				return true
+			}
+			if fun, ok := call.Fun.(*ast.Ident); !ok || fun.Name != "tr" {
+				return true
+			}
+			message, ok := stringLiteral(call.Args[0])
+			if !ok {
+				return true
+			}
+			for name, catalog := range catalogs {
+				if _, ok := catalog[strings.TrimSpace(message)]; !ok {
+					t.Errorf("%s: %q is missing from %s", fset.Position(call.Pos()), strings.TrimSpace(message), name)
+				}
+			}
+			return true
+		})
+	}
+}
+
+func TestResolveLocale(t *testing.T) {
+	dataDirOverride = t.TempDir()
+	defer func() { dataDirOverride = "" }()
+	setLanguage("")
+	defer func() { outputLanguage = ""; useLanguage("") }()
+
+	tests := []struct {
+		name        string
+		flag        string
+		player      string
+		env         string
+		wantTag     string
+		wantMessage string
+	}{
+		{"flag beats the player", "fr", "de_DE", "es_ES.UTF-8", "fr", "Passée."},
+		{"player beats the environment", "", "de_DE", "es_ES.UTF-8", "de", "Übersprungen."},
+		{"environment", "", "", "es_ES.UTF-8", "es", "Omitida."},
+		{"nothing set", "", "", "C", "en", "Skipped."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LC_ALL", "")
+			t.Setenv("LC_MESSAGES", "")
+			t.Setenv("LANG", tt.env)
+			outputLanguage = tt.flag
+			if got := resolveLocale(tt.player); got.Tag != tt.wantTag {
+				t.Errorf("resolveLocale(%q) = %s, want %s", tt.player, got.Tag, tt.wantTag)
+			}
+			if got := tr("Skipped."); got != tt.wantMessage {
+				t.Errorf("tr after resolveLocale(%q) = %q, want %q", tt.player, got, tt.wantMessage)
+			}
+		})
+	}
+}
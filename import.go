@@ -0,0 +1,286 @@
+// import.go
+//
+// Importing vocabulary from other flashcard services. Quizlet exports a set
+// as tab-separated "term<TAB>definition" lines; Memrise course exports are
+// CSV files, usually with a header row naming the level, the item being
+// learned and its definition. Every pair becomes a card with the term as the
+// prompt and the definition as the solution (generate_reverse in the deck
+// adds the other direction), tagged with the service, the name of the file
+// and, for Memrise, the level.
+//
+// Imported cards are appended to the deck, cards.json or cards.md. Pairs
+// that are already in the deck are skipped, so a set can be imported again
+// after it has grown.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// importPair is one term and its definition read from an export.
+type importPair struct {
+	Term       string
+	Definition string
+	// Level is the Memrise level the item belongs to, if the export has one.
+	Level string
+}
+
+// Header names of the Memrise columns, lowercased.
+var (
+	memriseLevelColumns      = []string{"level", "level name", "lesson"}
+	memriseItemColumns       = []string{"item", "learnable", "word", "term"}
+	memriseDefinitionColumns = []string{"definition", "meaning", "translation"}
+)
+
+// --- Command Handlers ---
+
+func handleImport(filePath, format, language string, extraTags []string, dryRun bool) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		fatalf("Error reading file (%s): %v", filePath, err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	if format == "" {
+		format = detectImportFormat(filePath, data)
+	}
+
+	var pairs []importPair
+	switch format {
+	case "quizlet":
+		pairs, err = parseQuizlet(data)
+	case "memrise":
+		pairs, err = parseMemrise(data)
+	default:
+		fatalf("Unknown format '%s', expected 'quizlet' or 'memrise'.", format)
+	}
+	if err != nil {
+		fatalf("Error reading %s export (%s): %v", format, filePath, err)
+	}
+
+	name := slugify(strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)))
+	tags := []string{format}
+	if name != "" && name != format {
+		tags = append(tags, name)
+	}
+	tags = append(tags, extraTags...)
+
+	// Deleted cards count as in the deck, so their IDs aren't reused and
+	// importing a set again doesn't bring them back
+	deck := loadDeck()
+	cards, skipped := importCards(pairs, append(deck.Cards, deck.deleted...), language, tags)
+	if dryRun {
+		for _, card := range cards {
+			fmt.Printf("%s: %s -> %s [%s]\n", card.ID, card.Prompt, card.Solution, strings.Join(card.Tags, ", "))
+		}
+		fmt.Printf(tr("Would import %d card(s) from %s (%s); %d already in the deck.\n"), len(cards), filePath, format, skipped)
+		return
+	}
+	if len(cards) > 0 {
+		appendToDeck(cards)
+	}
+	fmt.Printf(tr("Imported %d card(s) from %s (%s); %d already in the deck.\n"), len(cards), filePath, format, skipped)
+}
+
+// --- Helpers ---
+
+// detectImportFormat guesses the format of an export from its extension,
+// then from whether its first line has a tab in it.
+func detectImportFormat(filePath string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".tsv", ".txt":
+		return "quizlet"
+	case ".csv":
+		return "memrise"
+	}
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Contains(firstLine, []byte("\t")) {
+		return "quizlet"
+	}
+	return "memrise"
+}
+
+// parseQuizlet reads a Quizlet export with the default separators: a tab
+// between term and definition and one pair per line.
+func parseQuizlet(data []byte) ([]importPair, error) {
+	var pairs []importPair
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		term, definition, ok := strings.Cut(line, "\t")
+		if !ok {
+			return nil, fmt.Errorf("line %d has no tab between term and definition", i+1)
+		}
+		pairs = append(pairs, importPair{Term: strings.TrimSpace(term), Definition: strings.TrimSpace(definition)})
+	}
+	return pairs, nil
+}
+
+// parseMemrise reads a Memrise course export. Columns are found by their
+// header; without a header row the first column is the item and the second
+// its definition.
+func parseMemrise(data []byte) ([]importPair, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	level, item, definition := -1, 0, 1
+	if header := records[0]; isMemriseHeader(header) {
+		records = records[1:]
+		level = columnIndex(header, memriseLevelColumns)
+		item = columnIndex(header, memriseItemColumns)
+		definition = columnIndex(header, memriseDefinitionColumns)
+		// Columns named after languages ("French", "English") are the item
+		// and the definition, in that order
+		for i := range header {
+			if i == level || i == item || i == definition {
+				continue
+			}
+			if item < 0 {
+				item = i
+			} else if definition < 0 {
+				definition = i
+			}
+		}
+		if item < 0 || definition < 0 {
+			return nil, fmt.Errorf("the header has no item and definition columns")
+		}
+	}
+
+	var pairs []importPair
+	for i, record := range records {
+		if len(record) <= max(item, definition) {
+			if strings.TrimSpace(strings.Join(record, "")) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("row %d has %d column(s), expected at least %d", i+1, len(record), max(item, definition)+1)
+		}
+		pair := importPair{Term: strings.TrimSpace(record[item]), Definition: strings.TrimSpace(record[definition])}
+		if level >= 0 && level < len(record) {
+			pair.Level = strings.TrimSpace(record[level])
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, nil
+}
+
+// isMemriseHeader reports whether a row names the columns rather than
+// holding an item.
+func isMemriseHeader(row []string) bool {
+	return columnIndex(row, memriseLevelColumns) >= 0 ||
+		columnIndex(row, memriseItemColumns) >= 0 ||
+		columnIndex(row, memriseDefinitionColumns) >= 0
+}
+
+// columnIndex returns the first column of header with one of names, or -1.
+func columnIndex(header []string, names []string) int {
+	for i, cell := range header {
+		cell = strings.ToLower(strings.TrimSpace(cell))
+		for _, name := range names {
+			if cell == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// importCards turns pairs into new cards, leaving out empty pairs and those
+// the deck already has. It returns the cards and the number left out as
+// duplicates.
+func importCards(pairs []importPair, deck []Card, language string, tags []string) ([]Card, int) {
+	ids := make(map[string]bool, len(deck))
+	known := make(map[[2]string]bool, len(deck))
+	for _, card := range deck {
+		ids[card.ID] = true
+		known[[2]string{card.Prompt, card.Solution}] = true
+	}
+
+	var cards []Card
+	skipped := 0
+	prefix := slugify(language)
+	for _, pair := range pairs {
+		if pair.Term == "" || pair.Definition == "" {
+			continue
+		}
+		key := [2]string{pair.Term, pair.Definition}
+		if known[key] {
+			skipped++
+			continue
+		}
+		known[key] = true
+
+		slug := slugify(pair.Term)
+		if slug == "" {
+			slug = strconv.Itoa(len(cards) + 1)
+		}
+		base := prefix + "-" + slug
+		id := base
+		for n := 2; ids[id]; n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		ids[id] = true
+
+		cardTags := append([]string(nil), tags...)
+		// Memrise levels are numbers or names; both read well as "level-..."
+		if level := strings.TrimPrefix(slugify(pair.Level), "level-"); level != "" {
+			cardTags = append(cardTags, "level-"+level)
+		}
+		cards = append(cards, Card{ID: id, Language: language, Tags: cardTags, Prompt: pair.Term, Solution: pair.Definition})
+	}
+	return cards, skipped
+}
+
+// appendToDeck adds cards to the end of the deck file. The cards already
+// there are written back as they were, so templates and options are kept.
+func appendToDeck(cards []Card) {
+	filePath := deckPath()
+	if isMarkdownDeck(filePath) {
+		var buf bytes.Buffer
+		buf.WriteString("\n")
+		if err := writeMarkdownCards(&buf, cards); err != nil {
+			fatalf("Error writing cards: %v", err)
+		}
+		file, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			fatalf("Error opening deck (%s): %v", filePath, err)
+		}
+		defer file.Close()
+		if _, err := file.Write(buf.Bytes()); err != nil {
+			fatalf("Error writing deck (%s): %v", filePath, err)
+		}
+		return
+	}
+
+	deck := readRawDeck(filePath)
+	deck.cards = appendRawCards(deck.cards, cards)
+	deck.write()
+}
+
+func appendRawCards(list []json.RawMessage, cards []Card) []json.RawMessage {
+	for _, card := range cards {
+		raw, err := json.Marshal(card)
+		if err != nil {
+			fatalf("Error marshalling cards to JSON: %v", err)
+		}
+		list = append(list, raw)
+	}
+	return list
+}
@@ -0,0 +1,280 @@
+// input.go
+//
+// Typing helpers for learners without the target language's keyboard
+// layout. In study, daily, duel, exam, challenge and writing, what is typed
+// for a card goes through the helpers set for the card's language in
+// config.json before it is checked:
+//
+//	{ "input": { "fr": { "helpers": ["accents"] },
+//	             "ja": { "helpers": ["romaji"] },
+//	             "de": { "helpers": ["accents"], "replace": { "sz": "ß" } } } }
+//
+// "accents" turns a letter followed by a doubled mark into the accented
+// letter: e'' is é, a`` is à, o^^ is ô, u"" is ü, n~~ is ñ and c,, is ç.
+// The marks are doubled so that ordinary punctuation, as in "qu'il" or
+// "le parc, la ville", is left alone. "romaji" turns Hepburn or Kunrei
+// romaji into hiragana, or katakana where it is typed in capitals.
+// "replace" maps sequences of your own, applied first. A backslash keeps
+// the next character as it is. When the helpers change an answer, the
+// result is shown.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// InputConfig is how answers in one language are typed.
+type InputConfig struct {
+	// Helpers are applied in order; see inputHelpers.
+	Helpers []string `json:"helpers,omitempty"`
+	// Replace maps typed sequences to what they stand for.
+	Replace map[string]string `json:"replace,omitempty"`
+}
+
+// UnmarshalJSON rejects helpers that don't exist when config.json is read,
+// rather than on every answer.
+func (c *InputConfig) UnmarshalJSON(data []byte) error {
+	type plain InputConfig
+	if err := json.Unmarshal(data, (*plain)(c)); err != nil {
+		return err
+	}
+	for _, name := range c.Helpers {
+		if inputHelpers[name] == nil {
+			return fmt.Errorf("unknown input helper '%s', expected 'accents' or 'romaji'", name)
+		}
+	}
+	return nil
+}
+
+// inputHelpers are the helpers "helpers" can name.
+var inputHelpers = map[string]func(string) string{
+	"accents": typeAccents,
+	"romaji":  romajiToKana,
+}
+
+// typedAnswer applies the input helpers for language to a line typed in an
+// interactive mode, and shows the result if they changed it.
+func typedAnswer(language, line string) string {
+	config, ok := loadConfig().Input[language]
+	if !ok {
+		return line
+	}
+	converted := convertInput(line, config)
+	if converted != line {
+		fmt.Printf("→ %s\n", converted)
+	}
+	return converted
+}
+
+// convertInput applies config to line, leaving characters escaped with a
+// backslash alone.
+func convertInput(line string, config InputConfig) string {
+	var out, plain strings.Builder
+	flush := func() {
+		s := replaceSequences(plain.String(), config.Replace)
+		for _, name := range config.Helpers {
+			s = inputHelpers[name](s)
+		}
+		out.WriteString(s)
+		plain.Reset()
+	}
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) {
+			flush()
+			out.WriteRune(runes[i+1])
+			i++
+			continue
+		}
+		plain.WriteRune(runes[i])
+	}
+	flush()
+	return out.String()
+}
+
+// --- Helpers ---
+
+// replaceSequences replaces the keys of replacements in s, longest first
+// where several start at the same place.
+func replaceSequences(s string, replacements map[string]string) string {
+	if len(replacements) == 0 {
+		return s
+	}
+	keys := make([]string, 0, len(replacements))
+	for key := range replacements {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+	var out strings.Builder
+	for i := 0; i < len(s); {
+		matched := false
+		for _, key := range keys {
+			if strings.HasPrefix(s[i:], key) {
+				out.WriteString(replacements[key])
+				i += len(key)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			_, size := utf8.DecodeRuneInString(s[i:])
+			out.WriteString(s[i : i+size])
+			i += size
+		}
+	}
+	return out.String()
+}
+
+// accentMarks maps each mark typed twice after a letter to its combining
+// character and the letters it goes on.
+var accentMarks = map[rune]struct {
+	combining rune
+	letters   string
+}{
+	'\'': {'́', "aeiouy"},
+	'`':  {'̀', "aeiou"},
+	'^':  {'̂', "aeiou"},
+	'"':  {'̈', "aeiouy"},
+	'~':  {'̃', "ano"},
+	',':  {'̧', "c"},
+}
+
+// typeAccents turns a letter followed by a doubled accent mark into the
+// accented letter.
+func typeAccents(s string) string {
+	runes := []rune(s)
+	var out strings.Builder
+	for i := 0; i < len(runes); i++ {
+		if i+2 < len(runes) && runes[i+1] == runes[i+2] {
+			mark, ok := accentMarks[runes[i+1]]
+			if ok && strings.ContainsRune(mark.letters, unicode.ToLower(runes[i])) {
+				out.WriteString(norm.NFC.String(string([]rune{runes[i], mark.combining})))
+				i += 2
+				continue
+			}
+		}
+		out.WriteRune(runes[i])
+	}
+	return out.String()
+}
+
+// romajiKana maps romaji syllables to hiragana.
+var romajiKana = map[string]string{
+	"a": "あ", "i": "い", "u": "う", "e": "え", "o": "お",
+	"ka": "か", "ki": "き", "ku": "く", "ke": "け", "ko": "こ",
+	"sa": "さ", "shi": "し", "si": "し", "su": "す", "se": "せ", "so": "そ",
+	"ta": "た", "chi": "ち", "ti": "ち", "tsu": "つ", "tu": "つ", "te": "て", "to": "と",
+	"na": "な", "ni": "に", "nu": "ぬ", "ne": "ね", "no": "の",
+	"ha": "は", "hi": "ひ", "fu": "ふ", "hu": "ふ", "he": "へ", "ho": "ほ",
+	"ma": "ま", "mi": "み", "mu": "む", "me": "め", "mo": "も",
+	"ya": "や", "yu": "ゆ", "yo": "よ",
+	"ra": "ら", "ri": "り", "ru": "る", "re": "れ", "ro": "ろ",
+	"wa": "わ", "wo": "を",
+	"ga": "が", "gi": "ぎ", "gu": "ぐ", "ge": "げ", "go": "ご",
+	"za": "ざ", "ji": "じ", "zi": "じ", "zu": "ず", "ze": "ぜ", "zo": "ぞ",
+	"da": "だ", "di": "ぢ", "du": "づ", "de": "で", "do": "ど",
+	"ba": "ば", "bi": "び", "bu": "ぶ", "be": "べ", "bo": "ぼ",
+	"pa": "ぱ", "pi": "ぴ", "pu": "ぷ", "pe": "ぺ", "po": "ぽ",
+	"kya": "きゃ", "kyu": "きゅ", "kyo": "きょ",
+	"sha": "しゃ", "shu": "しゅ", "sho": "しょ", "she": "しぇ", "sya": "しゃ", "syu": "しゅ", "syo": "しょ",
+	"cha": "ちゃ", "chu": "ちゅ", "cho": "ちょ", "che": "ちぇ", "tya": "ちゃ", "tyu": "ちゅ", "tyo": "ちょ",
+	"nya": "にゃ", "nyu": "にゅ", "nyo": "にょ",
+	"hya": "ひゃ", "hyu": "ひゅ", "hyo": "ひょ",
+	"mya": "みゃ", "myu": "みゅ", "myo": "みょ",
+	"rya": "りゃ", "ryu": "りゅ", "ryo": "りょ",
+	"gya": "ぎゃ", "gyu": "ぎゅ", "gyo": "ぎょ",
+	"ja": "じゃ", "ju": "じゅ", "jo": "じょ", "je": "じぇ", "zya": "じゃ", "zyu": "じゅ", "zyo": "じょ",
+	"bya": "びゃ", "byu": "びゅ", "byo": "びょ",
+	"pya": "ぴゃ", "pyu": "ぴゅ", "pyo": "ぴょ",
+	"fa": "ふぁ", "fi": "ふぃ", "fe": "ふぇ", "fo": "ふぉ",
+	"xa": "ぁ", "xi": "ぃ", "xu": "ぅ", "xe": "ぇ", "xo": "ぉ",
+	"xya": "ゃ", "xyu": "ゅ", "xyo": "ょ", "xtsu": "っ", "xtu": "っ",
+	"-": "ー",
+}
+
+// romajiToKana converts romaji to hiragana, and runs typed in capitals to
+// katakana. What isn't romaji is left as it is.
+func romajiToKana(s string) string {
+	runes := []rune(s)
+	lower := []rune(strings.ToLower(s))
+	if len(lower) != len(runes) {
+		// Case mapping changed the length; match on what was typed
+		lower = runes
+	}
+	var out strings.Builder
+	emit := func(kana string, typed rune) {
+		if unicode.IsUpper(typed) {
+			kana = toKatakana(kana)
+		}
+		out.WriteString(kana)
+	}
+	for i := 0; i < len(runes); {
+		c := lower[i]
+		next := rune(0)
+		if i+1 < len(lower) {
+			next = lower[i+1]
+		}
+		switch {
+		// n before anything but a vowel or y is ん; nn and n' are ん too,
+		// but the second n of nn starts the next syllable if one follows
+		case c == 'n' && next == '\'':
+			emit("ん", runes[i])
+			i += 2
+			continue
+		case c == 'n' && next == 'n':
+			emit("ん", runes[i])
+			if i+2 < len(lower) && strings.ContainsRune("aiueoy", lower[i+2]) {
+				i++
+			} else {
+				i += 2
+			}
+			continue
+		case c == 'n' && !strings.ContainsRune("aiueoy", next):
+			emit("ん", runes[i])
+			i++
+			continue
+		// A doubled consonant, or t before ch, is a small tsu
+		case c >= 'a' && c <= 'z' && !strings.ContainsRune("aiueon", c) && (next == c || c == 't' && next == 'c'):
+			emit("っ", runes[i])
+			i++
+			continue
+		}
+		matched := false
+		for length := 4; length >= 1; length-- {
+			if i+length > len(lower) {
+				continue
+			}
+			if kana, ok := romajiKana[string(lower[i:i+length])]; ok {
+				emit(kana, runes[i])
+				i += length
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			out.WriteRune(runes[i])
+			i++
+		}
+	}
+	return out.String()
+}
+
+// toKatakana converts the hiragana in s to katakana.
+func toKatakana(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'ぁ' && r <= 'ゖ' {
+			return r + 0x60
+		}
+		return r
+	}, s)
+}
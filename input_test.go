@@ -0,0 +1,111 @@
+package main
+
+import "testing"
+
+func TestTypeAccents(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"e''te''", "été"},
+		{"franc,,ais", "français"},
+		{"c,,a", "ça"},
+		{"C,,a", "Ça"},
+		{"pe``re", "père"},
+		{"fe^^te", "fête"},
+		{"nai\"\"ve", "naïve"},
+		{"an~~o", "año"},
+		{"E''cole", "École"},
+		// Single marks are ordinary punctuation
+		{"l'eau", "l'eau"},
+		{"le parc, le lac", "le parc, le lac"},
+		{"c'est", "c'est"},
+		{"\"oui\"", "\"oui\""},
+		// Marks that don't go on the letter stay as typed
+		{"x''", "x''"},
+		{"e,,", "e,,"},
+		{"'', ,,", "'', ,,"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := typeAccents(tt.in); got != tt.want {
+			t.Errorf("typeAccents(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestConvertInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		config InputConfig
+		in     string
+		want   string
+	}{
+		{"no helpers", InputConfig{}, "e''te''", "e''te''"},
+		{"accents", InputConfig{Helpers: []string{"accents"}}, "le parc, l'e''te''", "le parc, l'été"},
+		{"escaped mark", InputConfig{Helpers: []string{"accents"}}, `e'\'`, "e''"},
+		{"replace", InputConfig{Replace: map[string]string{"ss": "ß"}}, "Strasse", "Straße"},
+		{"longest replacement first", InputConfig{Replace: map[string]string{"a": "1", "ae": "ä"}}, "aeа", "äа"},
+		{"escaped replacement", InputConfig{Replace: map[string]string{"ss": "ß"}}, `Mas\se`, "Masse"},
+		{"romaji", InputConfig{Helpers: []string{"romaji"}}, "konnichiha", "こんにちは"},
+		{"katakana", InputConfig{Helpers: []string{"romaji"}}, "KOHI-", "コヒー"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := convertInput(tt.in, tt.config); got != tt.want {
+				t.Errorf("convertInput(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRomajiToKana(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"sushi", "すし"},
+		{"kitte", "きって"},
+		{"matcha", "まっちゃ"},
+		{"hon", "ほん"},
+		{"kon'ya", "こんや"},
+		{"konnya", "こんにゃ"},
+		{"onna", "おんな"},
+		{"kyou", "きょう"},
+		{"TOKYO", "トキョ"},
+		{"shichitsu", "しちつ"},
+		{"xtsu", "っ"},
+		{"fa-xa", "ふぁーぁ"},
+		{"tchotto", "っちょっと"},
+		{"raamen", "らあめん"},
+		{"RA-MEN", "ラーメン"},
+		{"sushi desu", "すし です"},
+		{"Kyoto", "キョと"},
+		{"123", "123"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := romajiToKana(tt.in); got != tt.want {
+			t.Errorf("romajiToKana(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestInputConfigUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{"known helpers", `{"helpers": ["accents", "romaji"]}`, false},
+		{"replacements only", `{"replace": {"ss": "ß"}}`, false},
+		{"unknown helper", `{"helpers": ["accent"]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config InputConfig
+			err := config.UnmarshalJSON([]byte(tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UnmarshalJSON(%s) error = %v, want error %v", tt.json, err, tt.wantErr)
+			}
+		})
+	}
+}
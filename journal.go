@@ -0,0 +1,298 @@
+// journal.go
+//
+// The answer journal. Rewriting progress.json after every answer is slow
+// with a long history, and a crash halfway through the write could leave
+// the whole file broken. Instead each answer is appended to
+// progress.journal in the data directory, one JSON line per answer, and
+// synced to disk before the command returns. The journal is folded into
+// progress.json once it holds journalFoldAnswers answers or its oldest
+// answer is journalFoldAge old, at the end of a study session, and when the
+// server stops; progress.json itself is written to a temporary file and
+// renamed into place, so it is always either the old or the new version.
+//
+// Whatever reads progress.json replays the journal on top of it, so answers
+// not yet folded count everywhere. Replaying is idempotent: an answer
+// already in the player's history is skipped, so a crash between writing
+// progress.json and emptying the journal doesn't count it twice. A last
+// line cut off by a crash is dropped.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// journalFoldAnswers is how many answers the journal holds before it is
+	// folded into progress.json.
+	journalFoldAnswers = 25
+	// journalFoldAge is how long an answer may stay in the journal only.
+	journalFoldAge = 10 * time.Minute
+)
+
+// JournalEntry is one answer in the journal, with the changes it made to
+// the player.
+type JournalEntry struct {
+	PlayerID string        `json:"player_id"`
+	Practice bool          `json:"practice,omitempty"`
+	Answer   AnswerLogItem `json:"answer"`
+	// Card is the card's progress after the answer; XP and Achievements
+	// are what the answer earned, Boosts the player's boosts after it.
+	Card         CardProgress  `json:"card"`
+	XP           int           `json:"xp,omitempty"`
+	Achievements []Achievement `json:"achievements,omitempty"`
+	Boosts       []Boost       `json:"boosts,omitempty"`
+	// Logged is when the entry was written, for journalFoldAge.
+	Logged time.Time `json:"logged"`
+}
+
+// key identifies the answer of an entry.
+func (e JournalEntry) key() string {
+	return e.PlayerID + "\x00" + e.Answer.CardID + "\x00" + e.Answer.Timestamp.Format(time.RFC3339Nano)
+}
+
+var (
+	// journalMu guards journalFolded and rewrites of the journal.
+	journalMu sync.Mutex
+	// journalFolded holds the keys of the entries replayed by the last
+	// read of progress.json. Writing progress.json drops them from the
+	// journal even if the progress written no longer has them, as after
+	// reset-progress or delete-player.
+	journalFolded = make(map[string]bool)
+	// tornJournalWarning warns about a cut-off line once per run, though
+	// the journal is read several times.
+	tornJournalWarning sync.Once
+)
+
+// saveAnswer saves progress after an answer: guests as before, everyone
+// else by journaling the answer and writing progress.json only when the
+// journal is due to be folded. In serve mode the answer is journaled and
+// progress is kept in memory, to be flushed as usual.
+func saveAnswer(allProgress map[string]PlayerData, entry JournalEntry) {
+	if isGuest(entry.PlayerID) {
+		saveAllProgress(allProgress)
+		return
+	}
+	entry.Logged = time.Now()
+	entries := appendJournal(entry)
+	if cache != nil || journalDue(entries) {
+		saveAllProgress(allProgress)
+	}
+}
+
+// replayJournal applies the journaled answers missing from progress.
+func replayJournal(progress map[string]PlayerData, entries []JournalEntry) {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+	journalFolded = make(map[string]bool)
+	replayed := 0
+	for _, entry := range entries {
+		journalFolded[entry.key()] = true
+		player, ok := progress[entry.PlayerID]
+		if !ok || hasAnswer(player, entry) {
+			continue
+		}
+		if entry.Practice {
+			player.Practice = append(player.Practice, entry.Answer)
+		} else {
+			if player.Cards == nil {
+				player.Cards = make(map[string]CardProgress)
+			}
+			player.TotalAnswered++
+			player.Cards[entry.Answer.CardID] = entry.Card
+			player.History = append(player.History, entry.Answer)
+			player.XP += entry.XP
+			player.Achievements = append(player.Achievements, entry.Achievements...)
+			player.Boosts = entry.Boosts
+		}
+		progress[entry.PlayerID] = player
+		replayed++
+	}
+	if replayed > 0 {
+		slog.Debug("Replayed answer journal", "answers", replayed, "entries", len(entries))
+	}
+}
+
+// foldJournal writes the journaled answers into progress.json and empties
+// the journal. In serve mode the server's flush does this.
+func foldJournal() {
+	if cache != nil || !fileExists(journalPath()) {
+		return
+	}
+	writeProgressFile(readProgressFile())
+}
+
+// discardJournal drops the journal without folding it, for restoring a
+// backup.
+func discardJournal() {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+	if err := os.Remove(journalPath()); err != nil && !os.IsNotExist(err) {
+		fatalf("Error removing answer journal (%s): %v", journalPath(), err)
+	}
+	journalFolded = make(map[string]bool)
+}
+
+// --- Helpers ---
+
+func journalPath() string {
+	return filepath.Join(getDataDir(), "progress.journal")
+}
+
+// appendJournal adds entry to the journal, syncs it to disk and returns
+// all entries now in the journal.
+func appendJournal(entry JournalEntry) []JournalEntry {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		fatalf("Error marshalling journal entry to JSON: %v", err)
+	}
+	filePath := journalPath()
+	file, err := os.OpenFile(filePath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		fatalf("Error opening answer journal (%s): %v", filePath, err)
+	}
+	defer file.Close()
+	// Start on a line of its own after a line cut off by a crash
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			line = append([]byte{'\n'}, line...)
+		}
+	}
+	// One write per line, so answers appended by two commands don't mix
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		fatalf("Error writing answer journal (%s): %v", filePath, err)
+	}
+	if err := file.Sync(); err != nil {
+		fatalf("Error writing answer journal (%s): %v", filePath, err)
+	}
+	return readJournal()
+}
+
+// readJournal reads the journal, skipping lines that can't be read.
+func readJournal() []JournalEntry {
+	filePath := journalPath()
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		fatalf("Error reading answer journal (%s): %v", filePath, err)
+	}
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			// A crash while appending leaves the last line unfinished
+			if !bytes.HasSuffix(data, []byte("\n")) && bytes.HasSuffix(bytes.TrimSpace(data), line) {
+				tornJournalWarning.Do(func() {
+					slog.Warn("Dropped an unfinished last line of the answer journal", "path", filePath, "line", n)
+				})
+				continue
+			}
+			warnf("Skipping line %d of the answer journal (%s): %v", n, filePath, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// journalDue reports whether the journal should be folded now.
+func journalDue(entries []JournalEntry) bool {
+	if len(entries) >= journalFoldAnswers {
+		return true
+	}
+	return len(entries) > 0 && time.Since(entries[0].Logged) >= journalFoldAge
+}
+
+// trimJournal removes the entries that progress, just written to
+// progress.json, already holds, and those folded when it was read.
+// Answers journaled since by another command are kept.
+func trimJournal(progress map[string]PlayerData) {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+	filePath := journalPath()
+	if !fileExists(filePath) {
+		return
+	}
+	var kept bytes.Buffer
+	for _, entry := range readJournal() {
+		if journalFolded[entry.key()] || hasAnswer(progress[entry.PlayerID], entry) {
+			continue
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			fatalf("Error marshalling journal entry to JSON: %v", err)
+		}
+		kept.Write(append(line, '\n'))
+	}
+	journalFolded = make(map[string]bool)
+	if kept.Len() == 0 {
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			warnf("Could not empty the answer journal (%s): %v", filePath, err)
+		}
+		return
+	}
+	if err := writeFileAtomic(filePath, kept.Bytes()); err != nil {
+		warnf("Could not rewrite the answer journal (%s): %v", filePath, err)
+	}
+}
+
+// hasAnswer reports whether player's history already holds the answer of
+// entry.
+func hasAnswer(player PlayerData, entry JournalEntry) bool {
+	items := player.History
+	if entry.Practice {
+		items = player.Practice
+	}
+	// Journaled answers are the latest, so look from the end
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].CardID == entry.Answer.CardID && items[i].Timestamp.Equal(entry.Answer.Timestamp) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFileAtomic writes data to a temporary file next to filePath, syncs
+// it and renames it over filePath, so readers see the old or the new file
+// but never half of one.
+func writeFileAtomic(filePath string, data []byte) error {
+	file, err := ioutil.TempFile(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := file.Name()
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, filePath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReplayJournal(t *testing.T) {
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	answer := AnswerLogItem{CardID: "c1", Timestamp: at, Correct: true, Box: 1}
+	entry := JournalEntry{PlayerID: "p1", Answer: answer, Card: CardProgress{Box: 2, Passed: 1, LastReviewed: at}, XP: 10}
+
+	tests := []struct {
+		name        string
+		player      PlayerData
+		entries     []JournalEntry
+		wantTotal   int
+		wantHistory int
+		wantXP      int
+		wantBox     int
+	}{
+		{
+			name:        "new answer",
+			player:      PlayerData{Name: "a"},
+			entries:     []JournalEntry{entry},
+			wantTotal:   1,
+			wantHistory: 1,
+			wantXP:      10,
+			wantBox:     2,
+		},
+		{
+			name:        "answer already folded",
+			player:      PlayerData{Name: "a", TotalAnswered: 1, XP: 10, History: []AnswerLogItem{answer}, Cards: map[string]CardProgress{"c1": {Box: 2}}},
+			entries:     []JournalEntry{entry},
+			wantTotal:   1,
+			wantHistory: 1,
+			wantXP:      10,
+			wantBox:     2,
+		},
+		{
+			name:        "same answer journaled twice",
+			player:      PlayerData{Name: "a"},
+			entries:     []JournalEntry{entry, entry},
+			wantTotal:   1,
+			wantHistory: 1,
+			wantXP:      10,
+			wantBox:     2,
+		},
+		{
+			name:   "two answers to one card",
+			player: PlayerData{Name: "a"},
+			entries: []JournalEntry{entry, {
+				PlayerID: "p1",
+				Answer:   AnswerLogItem{CardID: "c1", Timestamp: at.Add(time.Minute), Box: 2},
+				Card:     CardProgress{Box: 1, Passed: 1, Failed: 1},
+			}},
+			wantTotal:   2,
+			wantHistory: 2,
+			wantXP:      10,
+			wantBox:     1,
+		},
+		{
+			name:    "practice answer",
+			player:  PlayerData{Name: "a"},
+			entries: []JournalEntry{{PlayerID: "p1", Practice: true, Answer: answer, Card: CardProgress{Box: 2}}},
+		},
+		{
+			name:    "unknown player",
+			player:  PlayerData{Name: "a"},
+			entries: []JournalEntry{{PlayerID: "p2", Answer: answer, Card: CardProgress{Box: 2}, XP: 10}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress := map[string]PlayerData{"p1": tt.player}
+			replayJournal(progress, tt.entries)
+			player := progress["p1"]
+			if player.TotalAnswered != tt.wantTotal {
+				t.Errorf("total answered = %d, want %d", player.TotalAnswered, tt.wantTotal)
+			}
+			if len(player.History) != tt.wantHistory {
+				t.Errorf("history has %d entries, want %d", len(player.History), tt.wantHistory)
+			}
+			if player.XP != tt.wantXP {
+				t.Errorf("xp = %d, want %d", player.XP, tt.wantXP)
+			}
+			if box := player.Cards["c1"].Box; box != tt.wantBox {
+				t.Errorf("box of c1 = %d, want %d", box, tt.wantBox)
+			}
+			if _, ok := progress["p2"]; ok {
+				t.Errorf("replay created player p2")
+			}
+			for _, entry := range tt.entries {
+				if !journalFolded[entry.key()] {
+					t.Errorf("entry %q not marked as folded", entry.key())
+				}
+			}
+		})
+	}
+}
+
+func TestReplayJournalPractice(t *testing.T) {
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	entry := JournalEntry{PlayerID: "p1", Practice: true, Answer: AnswerLogItem{CardID: "c1", Timestamp: at}}
+	progress := map[string]PlayerData{"p1": {Name: "a"}}
+	replayJournal(progress, []JournalEntry{entry, entry})
+	if practice := progress["p1"].Practice; len(practice) != 1 {
+		t.Errorf("practice has %d entries, want 1", len(practice))
+	}
+}
@@ -0,0 +1,184 @@
+// locale.go
+//
+// Locale-aware formatting of numbers, dates and durations for the
+// human-readable output (stats, summaries). Machine-readable JSON output is
+// never localized.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale describes how numbers, dates and durations are written.
+type Locale struct {
+	Tag            string
+	Thousands      string
+	Decimal        string
+	DateLayout     string
+	DateTimeLayout string
+	// Day, Hour, Minute and Second are the duration unit symbols.
+	Day, Hour, Minute, Second string
+	// SpacedUnits puts a space between a duration value and its unit.
+	SpacedUnits bool
+	// PercentSign is appended to percentages, including any spacing.
+	PercentSign string
+}
+
+// locales is keyed by lower-case language or language_territory tags.
+var locales = map[string]Locale{
+	"en":    {Tag: "en", Thousands: ",", Decimal: ".", DateLayout: "Jan 2, 2006", DateTimeLayout: "Jan 2, 2006 3:04 PM", Day: "d", Hour: "h", Minute: "m", Second: "s", PercentSign: "%"},
+	"en_gb": {Tag: "en_GB", Thousands: ",", Decimal: ".", DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04", Day: "d", Hour: "h", Minute: "m", Second: "s", PercentSign: "%"},
+	"fr":    {Tag: "fr", Thousands: " ", Decimal: ",", DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04", Day: "j", Hour: "h", Minute: "min", Second: "s", SpacedUnits: true, PercentSign: " %"},
+	"de":    {Tag: "de", Thousands: ".", Decimal: ",", DateLayout: "02.01.2006", DateTimeLayout: "02.01.2006 15:04", Day: "T.", Hour: "Std.", Minute: "Min.", Second: "Sek.", SpacedUnits: true, PercentSign: " %"},
+	"es":    {Tag: "es", Thousands: ".", Decimal: ",", DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04", Day: "d", Hour: "h", Minute: "min", Second: "s", SpacedUnits: true, PercentSign: " %"},
+	"it":    {Tag: "it", Thousands: ".", Decimal: ",", DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04", Day: "g", Hour: "h", Minute: "min", Second: "s", SpacedUnits: true, PercentSign: "%"},
+	"pt":    {Tag: "pt", Thousands: ".", Decimal: ",", DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04", Day: "d", Hour: "h", Minute: "min", Second: "s", SpacedUnits: true, PercentSign: "%"},
+	"nl":    {Tag: "nl", Thousands: ".", Decimal: ",", DateLayout: "02-01-2006", DateTimeLayout: "02-01-2006 15:04", Day: "d", Hour: "u", Minute: "min", Second: "s", SpacedUnits: true, PercentSign: "%"},
+	"ja":    {Tag: "ja", Thousands: ",", Decimal: ".", DateLayout: "2006/01/02", DateTimeLayout: "2006/01/02 15:04", Day: "日", Hour: "時間", Minute: "分", Second: "秒", PercentSign: "%"},
+}
+
+// resolveLocale picks the locale for output to a player, from the same
+// language as the messages (see outputTag): the --lang flag wins, then the
+// player's own locale, the config override and the POSIX environment
+// variables. Messages switch to that language too. Languages without a
+// locale of their own are formatted the English way.
+func resolveLocale(playerLocale string) Locale {
+	tag := outputTag(playerLocale)
+	useLanguage(tag)
+	if locale, ok := lookupLocale(tag); ok {
+		return locale
+	}
+	return locales["en"]
+}
+
+// lookupLocale maps tags like "de_DE.UTF-8", "pt-BR" or "fr" onto a known
+// locale, falling back from language_territory to the bare language.
+func lookupLocale(tag string) (Locale, bool) {
+	tag = strings.ToLower(tag)
+	if i := strings.IndexAny(tag, ".@"); i >= 0 {
+		tag = tag[:i]
+	}
+	tag = strings.ReplaceAll(tag, "-", "_")
+	if tag == "" || tag == "c" || tag == "posix" {
+		return Locale{}, false
+	}
+	if locale, ok := locales[tag]; ok {
+		return locale, true
+	}
+	if i := strings.Index(tag, "_"); i > 0 {
+		locale, ok := locales[tag[:i]]
+		return locale, ok
+	}
+	return Locale{}, false
+}
+
+// Number formats an integer with the locale's thousands separator.
+func (l Locale) Number(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(l.Thousands)
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
+
+// Float formats f with prec decimals using the locale's separators.
+func (l Locale) Float(f float64, prec int) string {
+	s := strconv.FormatFloat(f, 'f', prec, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	n, _ := strconv.Atoi(whole)
+	out := sign + l.Number(n)
+	if frac != "" {
+		out += l.Decimal + frac
+	}
+	return out
+}
+
+// Percent formats a ratio in [0,1] as a percentage with one decimal.
+func (l Locale) Percent(ratio float64) string {
+	return l.Float(ratio*100, 1) + l.PercentSign
+}
+
+func (l Locale) Date(t time.Time) string {
+	return t.Local().Format(l.DateLayout)
+}
+
+func (l Locale) DateTime(t time.Time) string {
+	return t.Local().Format(l.DateTimeLayout)
+}
+
+// Duration renders d with its two most significant units, e.g. "3h 20m"
+// or "3 h 20 min".
+func (l Locale) Duration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	parts := []struct {
+		value int
+		unit  string
+	}{
+		{int(d / (24 * time.Hour)), l.Day},
+		{int(d % (24 * time.Hour) / time.Hour), l.Hour},
+		{int(d % time.Hour / time.Minute), l.Minute},
+		{int(d % time.Minute / time.Second), l.Second},
+	}
+	var out []string
+	for _, part := range parts {
+		if part.value == 0 && len(out) == 0 {
+			continue
+		}
+		if l.SpacedUnits {
+			out = append(out, fmt.Sprintf("%d %s", part.value, part.unit))
+		} else {
+			out = append(out, fmt.Sprintf("%d%s", part.value, part.unit))
+		}
+		if len(out) == 2 {
+			break
+		}
+	}
+	if len(out) == 0 {
+		if l.SpacedUnits {
+			return "0 " + l.Second
+		}
+		return "0" + l.Second
+	}
+	return strings.Join(out, " ")
+}
+
+// --- Command Handlers ---
+
+func handleSetLocale(playerID, tag string) {
+	if tag != "" {
+		if _, ok := lookupLocale(tag); !ok {
+			fatalf("Unsupported locale '%s'.", tag)
+		}
+	}
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	player.Locale = tag
+	allProgress[playerID] = player
+	saveAllProgress(allProgress)
+	if tag == "" {
+		fmt.Printf(tr("Locale for %s reset to the default.\n"), player.Name)
+		return
+	}
+	fmt.Printf(tr("Locale for %s set to %s.\n"), player.Name, tag)
+}
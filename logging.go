@@ -0,0 +1,239 @@
+// logging.go
+//
+// Leveled, structured logging on top of log/slog. Messages go to two places:
+//
+//   - the terminal, filtered by --verbose (debug) and --quiet (errors only),
+//     formatted for people ("Warning: ...");
+//   - decouvertes.log in the data directory, as JSON lines at debug level,
+//     so that files read and scheduling decisions can be looked at after the
+//     fact. The file is rotated once it grows past logFileMaxSize.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Log file rotation: decouvertes.log is moved to decouvertes.log.1 (and so
+// on) once it is this large, keeping logFileBackups old files.
+const (
+	logFileMaxSize = 1 << 20
+	logFileBackups = 3
+)
+
+// consoleLevel is the lowest level shown in the terminal.
+var consoleLevel = new(slog.LevelVar)
+
+// setupLogging installs the default logger. verbose and quiet come from the
+// global flags; quiet wins if both are given.
+func setupLogging(verbose, quiet bool) {
+	consoleLevel.Set(slog.LevelWarn)
+	if verbose {
+		consoleLevel.Set(slog.LevelDebug)
+	}
+	if quiet {
+		consoleLevel.Set(slog.LevelError)
+	}
+
+	handlers := []slog.Handler{&consoleHandler{out: os.Stderr, level: consoleLevel}}
+	// A data directory that can't be written is reported by whichever command needs it
+	if file, err := openLogFile(filepath.Join(getDataDir(), "decouvertes.log")); err == nil {
+		handlers = append(handlers, slog.NewJSONHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: dropTranslation}))
+	}
+	slog.SetDefault(slog.New(fanoutHandler(handlers)))
+}
+
+// fatalf logs an error and exits, replacing log.Fatalf.
+func fatalf(format string, args ...interface{}) {
+	slog.Error(fmt.Sprintf(format, args...), localizedAttrs(format, args...)...)
+	stopProfiles()
+	os.Exit(1)
+}
+
+// fatal logs an error and exits, replacing log.Fatal.
+func fatal(args ...interface{}) {
+	message := fmt.Sprint(args...)
+	slog.Error(message, localizedAttrs(strings.ReplaceAll(message, "%", "%%"))...)
+	stopProfiles()
+	os.Exit(1)
+}
+
+// warnf logs a warning that doesn't stop the command.
+func warnf(format string, args ...interface{}) {
+	slog.Warn(fmt.Sprintf(format, args...), localizedAttrs(format, args...)...)
+}
+
+// dropTranslation keeps translated messages (see i18n.go) out of the log
+// file, which has the English ones.
+func dropTranslation(_ []string, attr slog.Attr) slog.Attr {
+	if attr.Key == l10nKey {
+		return slog.Attr{}
+	}
+	return attr
+}
+
+// --- Handlers ---
+
+// consoleHandler writes records as plain sentences, with attributes
+// appended as key=value pairs.
+type consoleHandler struct {
+	out   io.Writer
+	level slog.Leveler
+	attrs []slog.Attr
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *consoleHandler) Handle(_ context.Context, record slog.Record) error {
+	var b strings.Builder
+	switch {
+	case record.Level >= slog.LevelError:
+		b.WriteString(tr("Error: "))
+	case record.Level >= slog.LevelWarn:
+		b.WriteString(tr("Warning: "))
+	case record.Level < slog.LevelInfo:
+		b.WriteString("debug: ")
+	}
+	message := record.Message
+	record.Attrs(func(attr slog.Attr) bool {
+		if attr.Key == l10nKey {
+			message = attr.Value.String()
+		}
+		return true
+	})
+	b.WriteString(message)
+	writeAttr := func(attr slog.Attr) bool {
+		if attr.Key != l10nKey {
+			fmt.Fprintf(&b, " %s=%v", attr.Key, attr.Value)
+		}
+		return true
+	}
+	for _, attr := range h.attrs {
+		writeAttr(attr)
+	}
+	record.Attrs(writeAttr)
+	b.WriteByte('\n')
+
+	if record.Level >= slog.LevelWarn {
+		countErrorClass(record.Level, record.Message)
+	}
+	_, err := io.WriteString(h.out, b.String())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup is not needed by this program; groups are flattened.
+func (h *consoleHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// fanoutHandler passes every record to all handlers that want it.
+type fanoutHandler []slog.Handler
+
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var firstErr error
+	for _, h := range f {
+		if h.Enabled(ctx, record.Level) {
+			if err := h.Handle(ctx, record.Clone()); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
+
+// --- Log File ---
+
+// rotatingFile appends to a log file and rotates it when it gets too big.
+type rotatingFile struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	size int64
+}
+
+func openLogFile(path string) (*rotatingFile, error) {
+	r := &rotatingFile{path: path}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file, r.size = file, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.size+int64(len(p)) > logFileMaxSize && r.size > 0 {
+		r.rotate()
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts decouvertes.log.N to .N+1, dropping the oldest. Failures
+// leave the current file in place; logging must never stop a command.
+func (r *rotatingFile) rotate() {
+	r.file.Close()
+	for i := logFileBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	os.Rename(r.path, r.path+".1")
+	if err := r.open(); err != nil {
+		// Fall back to discarding rather than failing every later write
+		r.file, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	}
+}
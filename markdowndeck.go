@@ -0,0 +1,563 @@
+// markdowndeck.go
+//
+// Decks written in Markdown, so they can live in a notes vault next to
+// everything else. A "# " heading names the language of the cards below
+// it, a "## " heading is a card's prompt and the text under it is the
+// solution:
+//
+//	---
+//	tags: a1
+//	generate_reverse: true
+//	---
+//
+//	# french
+//
+//	## the cat
+//	> with the article
+//	le chat
+//	#animals
+//	<!-- id: fr-cat -->
+//
+// "> " lines right after the heading continue the prompt, and a solution in
+// a fenced code block is taken as it is. A line of #hashtags tags the card;
+// an HTML comment of "key: value" lines sets other card fields. A card
+// without an id gets one made from its language and prompt.
+//
+// Before the first "## " card of a section, a table whose header names a
+// prompt and a solution column gives one card per row. Any other text
+// outside cards is ignored, so a deck can carry notes of its own.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Header names of the columns in a Markdown card table, lowercased.
+var (
+	markdownPromptColumns   = []string{"prompt", "question", "front", "term"}
+	markdownSolutionColumns = []string{"solution", "answer", "back", "definition"}
+)
+
+// markdownFields are the card fields a comment or a table column can set.
+var markdownFields = []string{"id", "language", "tags", "validation", "pattern", "tolerance", "checker", "order"}
+
+// markdownParser holds the state of reading a Markdown deck line by line.
+type markdownParser struct {
+	deck     Deck
+	language string
+	tags     []string // from the front matter, for every card
+
+	card     *Card
+	cardLine int
+	body     []string
+	fence    string // the marker of the open code block, if any
+
+	comment   []string
+	inComment bool
+
+	inTable bool
+	columns []string // field of each column; nil if the table isn't cards
+}
+
+// isMarkdownDeck reports whether a deck file is in the Markdown format.
+func isMarkdownDeck(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+// parseMarkdownDeck reads a deck in the Markdown format.
+func parseMarkdownDeck(data []byte) (Deck, error) {
+	p := &markdownParser{deck: Deck{Version: deckFormat}}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+
+	start := 0
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "---" {
+		end := -1
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == "---" {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return Deck{}, fmt.Errorf("the front matter is not closed with ---")
+		}
+		if err := p.frontMatter(lines[1:end]); err != nil {
+			return Deck{}, err
+		}
+		start = end + 1
+	}
+
+	for i := start; i < len(lines); i++ {
+		if err := p.parseLine(lines[i], i+1); err != nil {
+			return Deck{}, err
+		}
+	}
+	switch {
+	case p.fence != "":
+		return Deck{}, fmt.Errorf("line %d: the code block of card %q is not closed", p.cardLine, firstLine(p.card.Prompt))
+	case p.inComment:
+		return Deck{}, fmt.Errorf("a comment is not closed with -->")
+	}
+	if err := p.finishCard(); err != nil {
+		return Deck{}, err
+	}
+	return p.deck, nil
+}
+
+// frontMatter reads the deck options between the --- lines.
+func (p *markdownParser) frontMatter(lines []string) error {
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return fmt.Errorf("expected key: value in the front matter, got %q", line)
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "language":
+			p.language = value
+		case "tags":
+			p.tags = splitMarkdownTags(value)
+		case "generate_reverse":
+			reverse, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("generate_reverse must be true or false, got %q", value)
+			}
+			p.deck.GenerateReverse = reverse
+		default:
+			return fmt.Errorf("unknown front matter key %q", strings.TrimSpace(key))
+		}
+	}
+	return nil
+}
+
+// parseLine reads one line. Its errors say which line they are about.
+func (p *markdownParser) parseLine(line string, number int) error {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case p.fence != "":
+		p.body = append(p.body, line)
+		if strings.HasPrefix(trimmed, p.fence) && strings.Trim(trimmed, p.fence[:1]) == "" {
+			p.fence = ""
+		}
+		return nil
+	case p.inComment:
+		if before, ok := strings.CutSuffix(trimmed, "-->"); ok {
+			p.inComment = false
+			return lineError(number, p.applyComment(append(p.comment, before)))
+		}
+		p.comment = append(p.comment, line)
+		return nil
+	case strings.HasPrefix(trimmed, "<!--"):
+		inner := strings.TrimPrefix(trimmed, "<!--")
+		if before, ok := strings.CutSuffix(inner, "-->"); ok {
+			return lineError(number, p.applyComment([]string{before}))
+		}
+		p.inComment, p.comment = true, []string{inner}
+		return nil
+	case strings.HasPrefix(line, "# "):
+		p.inTable = false
+		p.language = strings.TrimSpace(line[2:])
+		return p.finishCard()
+	case strings.HasPrefix(line, "## "):
+		p.inTable = false
+		err := p.finishCard()
+		p.card = &Card{Language: p.language, Prompt: strings.TrimSpace(line[3:])}
+		p.cardLine, p.body = number, nil
+		return err
+	case p.card == nil && strings.HasPrefix(trimmed, "|"):
+		return lineError(number, p.tableRow(trimmed))
+	case p.card == nil:
+		p.inTable = false
+		return nil
+	case len(p.body) == 0 && strings.HasPrefix(trimmed, ">"):
+		p.card.Prompt += "\n" + strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+	case isTagLine(trimmed):
+		p.card.Tags = append(p.card.Tags, splitMarkdownTags(trimmed)...)
+	case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+		p.fence = trimmed[:3]
+		p.body = append(p.body, line)
+	case trimmed != "" || len(p.body) > 0:
+		p.body = append(p.body, line)
+	}
+	return nil
+}
+
+// applyComment sets the fields a comment in a card names. Comments that
+// don't start with a field are ordinary comments and are ignored, as are
+// comments outside cards.
+func (p *markdownParser) applyComment(lines []string) error {
+	var fields [][2]string
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || !slices.Contains(markdownFields, key) {
+			if len(fields) == 0 {
+				return nil
+			}
+			return fmt.Errorf("expected one of %s in the card comment, got %q", strings.Join(markdownFields, ", "), strings.TrimSpace(line))
+		}
+		fields = append(fields, [2]string{key, strings.TrimSpace(value)})
+	}
+	if p.card == nil {
+		return nil
+	}
+	for _, field := range fields {
+		if err := setMarkdownField(p.card, field[0], field[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tableRow reads one line of a table outside cards. The first line of a
+// table is its header.
+func (p *markdownParser) tableRow(line string) error {
+	cells := splitTableRow(line)
+	if !p.inTable {
+		p.inTable, p.columns = true, nil
+		columns := make([]string, len(cells))
+		for i, cell := range cells {
+			name := strings.ToLower(cell)
+			switch {
+			case slices.Contains(markdownPromptColumns, name):
+				columns[i] = "prompt"
+			case slices.Contains(markdownSolutionColumns, name):
+				columns[i] = "solution"
+			case slices.Contains(markdownFields, name):
+				columns[i] = name
+			}
+		}
+		if slices.Contains(columns, "prompt") && slices.Contains(columns, "solution") {
+			p.columns = columns
+		}
+		return nil
+	}
+	if p.columns == nil || isTableSeparator(cells) {
+		return nil
+	}
+
+	card := Card{Language: p.language}
+	for i, cell := range cells {
+		if i >= len(p.columns) || cell == "" {
+			continue
+		}
+		switch p.columns[i] {
+		case "":
+		case "prompt":
+			card.Prompt = cell
+		case "solution":
+			card.Solution = cell
+		default:
+			if err := setMarkdownField(&card, p.columns[i], cell); err != nil {
+				return err
+			}
+		}
+	}
+	if card.Prompt == "" || card.Solution == "" {
+		return fmt.Errorf("a table row needs both a prompt and a solution")
+	}
+	return p.addCard(card)
+}
+
+// finishCard adds the card being read, if any, to the deck.
+func (p *markdownParser) finishCard() error {
+	if p.card == nil {
+		return nil
+	}
+	card := *p.card
+	p.card = nil
+
+	body := p.body
+	for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+		body = body[:len(body)-1]
+	}
+	if n := len(body); n >= 2 && isFenceLine(body[0]) && isFenceLine(body[n-1]) {
+		card.Solution = strings.Join(body[1:n-1], "\n")
+	} else {
+		card.Solution = strings.TrimSpace(strings.Join(body, "\n"))
+	}
+	if card.Solution == "" {
+		return lineError(p.cardLine, fmt.Errorf("card %q has no solution", firstLine(card.Prompt)))
+	}
+	return lineError(p.cardLine, p.addCard(card))
+}
+
+func (p *markdownParser) addCard(card Card) error {
+	if card.Language == "" {
+		return fmt.Errorf("card %q has no language; put it under a \"# language\" heading", firstLine(card.Prompt))
+	}
+	card.Tags = append(append([]string{}, p.tags...), card.Tags...)
+	if card.ID == "" {
+		slug := slugify(firstLine(card.Prompt))
+		if slug == "" {
+			return fmt.Errorf("card %q needs an id", card.Prompt)
+		}
+		card.ID = slugify(card.Language) + "-" + slug
+	}
+	p.deck.Cards = append(p.deck.Cards, card)
+	return nil
+}
+
+// --- Command Handlers ---
+
+func handleConvertDeck(inPath, outPath string, force bool) {
+	if inPath == "" {
+		inPath = deckPath()
+	}
+	if fileExists(outPath) && !force {
+		fatalf("%s already exists; pass --force to overwrite it.", outPath)
+	}
+	data, err := ioutil.ReadFile(inPath)
+	if err != nil {
+		fatalf("Error reading file (%s): %v", inPath, err)
+	}
+	deck, err := decodeDeck(inPath, data)
+	if err != nil {
+		fatalf("Error reading deck: %v", err)
+	}
+	deck.Version = deckFormat
+
+	var buf bytes.Buffer
+	if isMarkdownDeck(outPath) {
+		// Markdown has no tombstones, so deleted cards are left behind
+		err = writeMarkdownDeck(&buf, deck)
+	} else {
+		deck.Cards = append(deck.Cards, deck.deleted...)
+		var out []byte
+		out, err = json.MarshalIndent(deck, "", "  ")
+		buf.Write(append(out, '\n'))
+	}
+	if err != nil {
+		fatalf("Error converting deck: %v", err)
+	}
+	if err := ioutil.WriteFile(outPath, buf.Bytes(), 0644); err != nil {
+		fatalf("Error writing deck (%s): %v", outPath, err)
+	}
+	fmt.Printf(tr("Wrote %d card(s) from %s to %s.\n"), len(deck.Cards), inPath, outPath)
+	if isMarkdownDeck(outPath) {
+		fmt.Println(tr("To study from it, save it as cards.md in the config directory and remove cards.json."))
+	}
+}
+
+// --- Writing ---
+
+// writeMarkdownDeck writes a deck in the Markdown format. Options and cards
+// the format can't hold are refused rather than dropped.
+func writeMarkdownDeck(out io.Writer, deck Deck) error {
+	if !reflect.DeepEqual(deck.Normalization, NormalizationOptions{}) {
+		return fmt.Errorf("normalization options can't be written to a Markdown deck")
+	}
+	if deck.GenerateReverse {
+		fmt.Fprint(out, "---\ngenerate_reverse: true\n---\n\n")
+	}
+	return writeMarkdownCards(out, deck.Cards)
+}
+
+// writeMarkdownCards writes cards under "# language" headings.
+func writeMarkdownCards(out io.Writer, cards []Card) error {
+	language := ""
+	for i, card := range cards {
+		if len(card.Variants) > 0 || card.Type != "" || len(card.Data) > 0 {
+			return fmt.Errorf("card %s is a template or has a card type, which a Markdown deck can't hold", card.ID)
+		}
+		if i == 0 || card.Language != language {
+			language = card.Language
+			fmt.Fprintf(out, "# %s\n\n", language)
+		}
+		writeMarkdownCard(out, card)
+	}
+	return nil
+}
+
+func writeMarkdownCard(out io.Writer, card Card) {
+	prompt := strings.Split(card.Prompt, "\n")
+	fmt.Fprintf(out, "## %s\n", prompt[0])
+	for _, line := range prompt[1:] {
+		fmt.Fprintf(out, "> %s\n", line)
+	}
+
+	if needsFence(card.Solution) {
+		fence := "```"
+		if strings.Contains(card.Solution, fence) {
+			fence = "~~~"
+		}
+		fmt.Fprintf(out, "%s\n%s\n%s\n", fence, card.Solution, fence)
+	} else {
+		fmt.Fprintln(out, card.Solution)
+	}
+
+	fields := [][2]string{{"id", card.ID}}
+	if hashtags(card.Tags) {
+		if len(card.Tags) > 0 {
+			fmt.Fprintln(out, "#"+strings.Join(card.Tags, " #"))
+		}
+	} else {
+		fields = append(fields, [2]string{"tags", strings.Join(card.Tags, ", ")})
+	}
+	if card.Validation != "" {
+		fields = append(fields, [2]string{"validation", card.Validation})
+	}
+	if card.Pattern != "" {
+		fields = append(fields, [2]string{"pattern", card.Pattern})
+	}
+	if card.Tolerance != 0 {
+		fields = append(fields, [2]string{"tolerance", strconv.FormatFloat(card.Tolerance, 'g', -1, 64)})
+	}
+	if card.Checker != "" {
+		fields = append(fields, [2]string{"checker", card.Checker})
+	}
+	if card.Order != nil {
+		fields = append(fields, [2]string{"order", strconv.Itoa(*card.Order)})
+	}
+	if len(fields) == 1 {
+		fmt.Fprintf(out, "<!-- id: %s -->\n\n", card.ID)
+		return
+	}
+	fmt.Fprintln(out, "<!--")
+	for _, field := range fields {
+		fmt.Fprintf(out, "%s: %s\n", field[0], field[1])
+	}
+	fmt.Fprint(out, "-->\n\n")
+}
+
+// --- Helpers ---
+
+// lineError adds the line number to err, if there is an error.
+func lineError(line int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("line %d: %w", line, err)
+}
+
+func setMarkdownField(card *Card, key, value string) error {
+	switch key {
+	case "id":
+		card.ID = value
+	case "language":
+		card.Language = value
+	case "tags":
+		card.Tags = append(card.Tags, splitMarkdownTags(value)...)
+	case "validation":
+		card.Validation = value
+	case "pattern":
+		card.Pattern = value
+	case "tolerance":
+		tolerance, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid tolerance %q", value)
+		}
+		card.Tolerance = tolerance
+	case "checker":
+		card.Checker = value
+	case "order":
+		order, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid order %q", value)
+		}
+		card.Order = &order
+	}
+	return nil
+}
+
+// splitMarkdownTags splits a list of tags written as "a, b" or "#a #b".
+func splitMarkdownTags(value string) []string {
+	var tags []string
+	for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		if tag := strings.TrimPrefix(field, "#"); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// isTagLine reports whether a line is nothing but #hashtags.
+func isTagLine(line string) bool {
+	fields := strings.Fields(line)
+	for _, field := range fields {
+		if len(field) < 2 || field[0] != '#' || strings.Contains(field[1:], "#") {
+			return false
+		}
+	}
+	return len(fields) > 0
+}
+
+// hashtags reports whether tags can be written as a line of #hashtags.
+func hashtags(tags []string) bool {
+	for _, tag := range tags {
+		if tag == "" || strings.ContainsAny(tag, "#, \t\n") {
+			return false
+		}
+	}
+	return true
+}
+
+func isFenceLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// needsFence reports whether a solution has to go in a code block to be
+// read back as it is.
+func needsFence(solution string) bool {
+	if solution == "" || strings.Contains(solution, "\n") || strings.TrimSpace(solution) != solution {
+		return true
+	}
+	for _, prefix := range []string{"#", ">", "|", "<!--", "```", "~~~"} {
+		if strings.HasPrefix(solution, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitTableRow returns the cells of a table line; "\|" is a literal bar.
+func splitTableRow(line string) []string {
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// isTableSeparator reports whether a table line is the one under the
+// header, such as |---|:--:|.
+func isTableSeparator(cells []string) bool {
+	for _, cell := range cells {
+		if strings.Trim(cell, ":-") != "" || !strings.Contains(cell, "-") {
+			return false
+		}
+	}
+	return true
+}
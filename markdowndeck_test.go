@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const markdownDeck = `---
+tags: a1
+generate_reverse: true
+---
+
+Notes about this deck are ignored.
+
+# french
+
+| Prompt | Answer | id |
+|--------|:------:|----|
+| the dog | le chien | fr-dog |
+| yes \| no | oui \| non | |
+
+## the cat
+> with the article
+le chat
+#animals #pets
+<!-- id: fr-cat -->
+
+## a number
+
+42
+<!--
+validation: numeric
+tolerance: 0.5
+-->
+
+# code
+
+## print hello
+` + "```" + `
+fmt.Println("hello")
+` + "```" + `
+`
+
+func TestParseMarkdownDeck(t *testing.T) {
+	deck, err := parseMarkdownDeck([]byte(strings.ReplaceAll(markdownDeck, "\n", "\r\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Deck{Version: deckFormat, GenerateReverse: true, Cards: []Card{
+		{ID: "fr-dog", Language: "french", Prompt: "the dog", Solution: "le chien", Tags: []string{"a1"}},
+		{ID: "french-yes-no", Language: "french", Prompt: "yes | no", Solution: "oui | non", Tags: []string{"a1"}},
+		{ID: "fr-cat", Language: "french", Prompt: "the cat\nwith the article", Solution: "le chat", Tags: []string{"a1", "animals", "pets"}},
+		{ID: "french-a-number", Language: "french", Prompt: "a number", Solution: "42", Tags: []string{"a1"}, Validation: ValidationNumeric, Tolerance: 0.5},
+		{ID: "code-print-hello", Language: "code", Prompt: "print hello", Solution: `fmt.Println("hello")`, Tags: []string{"a1"}},
+	}}
+	if !reflect.DeepEqual(deck, want) {
+		t.Errorf("parseMarkdownDeck =\n%+v\nwant\n%+v", deck, want)
+	}
+}
+
+func TestParseMarkdownDeckErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		deck string
+		want string
+	}{
+		{"open front matter", "---\ntags: a\n# french\n", "front matter is not closed"},
+		{"unknown front matter key", "---\ncolor: red\n---\n", `unknown front matter key "color"`},
+		{"no language", "## the cat\nle chat\n", `line 1: card "the cat" has no language`},
+		{"no solution", "# french\n\n## the cat\n\n## the dog\nle chien\n", `line 3: card "the cat" has no solution`},
+		{"open code block", "# code\n## hello\n```\nhi\n", `line 2: the code block of card "hello" is not closed`},
+		{"open comment", "# french\n## the cat\nle chat\n<!-- id: x\n", "comment is not closed"},
+		{"unknown field", "# french\n## the cat\nle chat\n<!--\nid: x\ncolour: red\n-->\n", "expected one of"},
+		{"bad tolerance", "# french\n## pi\n3.14\n<!-- tolerance: about -->\n", `line 4: invalid tolerance "about"`},
+		{"table row without a solution", "# french\n| prompt | solution |\n|---|---|\n| the cat | |\n", "line 4: a table row needs both"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseMarkdownDeck([]byte(tt.deck))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownDeckRoundTrip(t *testing.T) {
+	order := 2
+	deck := Deck{Version: deckFormat, GenerateReverse: true, Cards: []Card{
+		{ID: "fr-cat", Language: "french", Prompt: "the cat\nwith the article", Solution: "le chat", Tags: []string{"animals"}},
+		{ID: "fr-grey", Language: "french", Prompt: "grey", Solution: "gris", Tags: []string{"c#"}, Validation: ValidationRegex, Pattern: "gris|grise", Order: &order},
+		{ID: "md-heading", Language: "markdown", Tags: []string{}, Prompt: "a heading", Solution: "# Title"},
+		{ID: "md-fence", Language: "markdown", Tags: []string{}, Prompt: "a code block", Solution: "```\ncode\n```"},
+		{ID: "sp", Language: "markdown", Tags: []string{}, Prompt: "indented", Solution: "  x"},
+	}}
+	var out bytes.Buffer
+	if err := writeMarkdownDeck(&out, deck); err != nil {
+		t.Fatal(err)
+	}
+	read, err := parseMarkdownDeck(out.Bytes())
+	if err != nil {
+		t.Fatalf("reading back\n%s: %v", out.String(), err)
+	}
+	// Compared as JSON, since the parser leaves unset fields empty rather than nil
+	got, _ := json.Marshal(read)
+	want, _ := json.Marshal(deck)
+	if !bytes.Equal(got, want) {
+		t.Errorf("read back\n%s\nwant\n%s\nfrom\n%s", got, want, out.String())
+	}
+}
+
+func TestWriteMarkdownDeckRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		deck Deck
+	}{
+		{"normalization options", Deck{Normalization: NormalizationOptions{CaseSensitive: true}}},
+		{"template card", Deck{Cards: []Card{{ID: "t", Variants: []map[string]string{{"a": "b"}}}}}},
+		{"card type", Deck{Cards: []Card{{ID: "t", Type: "cloze"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := writeMarkdownDeck(&bytes.Buffer{}, tt.deck); err == nil {
+				t.Error("writeMarkdownDeck succeeded, want an error")
+			}
+		})
+	}
+}
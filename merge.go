@@ -0,0 +1,249 @@
+// merge.go
+//
+// merge-progress reconciles progress.json with a copy that went its own way,
+// such as the one on a laptop the config directory was copied to. The
+// merge is deterministic and can be repeated: answers are the union of both
+// histories by timestamp, the answer counters of a card take the larger
+// value, and its box and streak come from whichever side reviewed it last.
+// Notes, the name and goals of the local copy win where both have one.
+// Answers already moved to the local archive aren't brought back.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+)
+
+// MergeSummary counts what a merge changed for one player.
+type MergeSummary struct {
+	New      bool // the player only existed in the other file
+	Answers  int  // answers added to the history
+	Cards    int  // cards whose progress changed
+	Practice int  // practice answers added
+}
+
+// mergePlayer merges other into local and reports what changed. archived
+// holds the answers in the local archive.
+func mergePlayer(local, other PlayerData, archived []AnswerLogItem) (PlayerData, MergeSummary) {
+	var summary MergeSummary
+	merged := local
+	if merged.Name == "" {
+		merged.Name = other.Name
+	}
+	if merged.Locale == "" {
+		merged.Locale = other.Locale
+	}
+	if merged.Goals == (Goals{}) {
+		merged.Goals = other.Goals
+	}
+	merged.XP = max(local.XP, other.XP)
+
+	merged.History, summary.Answers = mergeAnswers(local.History, other.History, archived)
+	merged.Practice, summary.Practice = mergeAnswers(local.Practice, other.Practice, nil)
+	// Every answer brought over is one more answered; merging again adds none
+	merged.TotalAnswered = max(local.TotalAnswered+summary.Answers, other.TotalAnswered)
+
+	merged.Cards = make(map[string]CardProgress, len(local.Cards))
+	for id, progress := range local.Cards {
+		merged.Cards[id] = progress
+	}
+	for id, theirs := range other.Cards {
+		ours, ok := merged.Cards[id]
+		result := mergeCardProgress(ours, theirs)
+		if !ok || result != ours {
+			summary.Cards++
+		}
+		merged.Cards[id] = result
+	}
+
+	// Achievements keep the earliest time they were earned
+	achievements := make(map[string]Achievement)
+	for _, achievement := range append(append([]Achievement{}, local.Achievements...), other.Achievements...) {
+		if known, ok := achievements[achievement.ID]; !ok || achievement.EarnedAt.Before(known.EarnedAt) {
+			achievements[achievement.ID] = achievement
+		}
+	}
+	merged.Achievements = nil
+	for _, achievement := range achievements {
+		merged.Achievements = append(merged.Achievements, achievement)
+	}
+	sort.Slice(merged.Achievements, func(i, j int) bool {
+		a, b := merged.Achievements[i], merged.Achievements[j]
+		if !a.EarnedAt.Equal(b.EarnedAt) {
+			return a.EarnedAt.Before(b.EarnedAt)
+		}
+		return a.ID < b.ID
+	})
+
+	// A writing entry that was reviewed more often is further along
+	writing := make(map[string]WritingEntry)
+	var writingOrder []string
+	for _, entry := range append(append([]WritingEntry{}, local.Writing...), other.Writing...) {
+		known, ok := writing[entry.ID]
+		if !ok {
+			writingOrder = append(writingOrder, entry.ID)
+		}
+		if !ok || entry.Reviews > known.Reviews {
+			writing[entry.ID] = entry
+		}
+	}
+	merged.Writing = nil
+	for _, id := range writingOrder {
+		merged.Writing = append(merged.Writing, writing[id])
+	}
+	sort.SliceStable(merged.Writing, func(i, j int) bool { return merged.Writing[i].WrittenAt.Before(merged.Writing[j].WrittenAt) })
+
+	merged.Notes = mergeMap(local.Notes, other.Notes, func(ours, theirs string) string { return ours })
+	merged.Skipped = mergeMap(local.Skipped, other.Skipped, func(ours, theirs time.Time) time.Time {
+		if theirs.Before(ours) {
+			return theirs
+		}
+		return ours
+	})
+	merged.Challenges = mergeMap(local.Challenges, other.Challenges, func(ours, theirs ChallengeBest) ChallengeBest {
+		if theirs.Score > ours.Score || (theirs.Score == ours.Score && theirs.PlayedAt.Before(ours.PlayedAt)) {
+			return theirs
+		}
+		return ours
+	})
+	return merged, summary
+}
+
+// mergeCardProgress combines two records of the same card. Counters take
+// the larger value; the state that scheduling depends on comes from the
+// later review, or from the higher box if both were reviewed at once.
+func mergeCardProgress(ours, theirs CardProgress) CardProgress {
+	latest := ours
+	if theirs.LastReviewed.After(ours.LastReviewed) || (theirs.LastReviewed.Equal(ours.LastReviewed) && theirs.Box > ours.Box) {
+		latest = theirs
+	}
+	latest.Passed = max(ours.Passed, theirs.Passed)
+	latest.Failed = max(ours.Failed, theirs.Failed)
+	return latest
+}
+
+// mergeAnswers returns the union of two answer logs in time order, leaving
+// out answers in skip, and the number of answers taken from theirs.
+func mergeAnswers(ours, theirs, skip []AnswerLogItem) ([]AnswerLogItem, int) {
+	seen := make(map[AnswerLogItem]bool, len(ours)+len(skip))
+	for _, item := range skip {
+		seen[answerKey(item)] = true
+	}
+	merged := append([]AnswerLogItem{}, ours...)
+	for _, item := range ours {
+		seen[answerKey(item)] = true
+	}
+	added := 0
+	for _, item := range theirs {
+		if key := answerKey(item); !seen[key] {
+			seen[key] = true
+			merged = append(merged, item)
+			added++
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Timestamp.Before(merged[j].Timestamp) })
+	if len(merged) == 0 {
+		return ours, 0
+	}
+	return merged, added
+}
+
+// --- Command Handlers ---
+
+func handleMergeProgress(filePath string, dryRun bool) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		fatalf("Error reading progress file (%s): %v", filePath, err)
+	}
+	others, _, damage, err := decodeProgress(data)
+	if err != nil {
+		fatalf("Error reading progress file (%s): %v", filePath, err)
+	}
+	if !damage.empty() {
+		fatalf("%s is damaged; repair it with 'repair-progress' on the machine it comes from before merging.", filePath)
+	}
+
+	allProgress := loadAllProgress()
+	ids := make([]string, 0, len(others))
+	for id := range others {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	changed := false
+	for _, id := range ids {
+		other := others[id]
+		local, ok := allProgress[id]
+		var summary MergeSummary
+		if ok {
+			allProgress[id], summary = mergePlayer(local, other, loadArchivedHistory(id))
+		} else {
+			allProgress[id] = other
+			summary = MergeSummary{New: true, Answers: len(other.History), Cards: len(other.Cards), Practice: len(other.Practice)}
+		}
+
+		name := allProgress[id].Name
+		switch {
+		case summary.New:
+			fmt.Printf(tr("%s (%s): new player with %d answer(s) on %d card(s)\n"), name, id, summary.Answers, summary.Cards)
+		case summary == MergeSummary{} && sameProgress(local, allProgress[id]):
+			fmt.Printf(tr("%s (%s): already up to date\n"), name, id)
+			continue
+		default:
+			fmt.Printf(tr("%s (%s): %d new answer(s), %d practice answer(s), %d card(s) updated\n"), name, id, summary.Answers, summary.Practice, summary.Cards)
+		}
+		changed = true
+	}
+
+	if dryRun || !changed {
+		if dryRun {
+			fmt.Println(tr("Dry run; nothing was saved."))
+		}
+		return
+	}
+	// The merge can't be undone otherwise
+	if fileExists(progressPath()) {
+		handleBackup(0)
+	}
+	saveAllProgress(allProgress)
+	fmt.Printf(tr("Merged %s into %s.\n"), filePath, progressPath())
+}
+
+// --- Helpers ---
+
+// answerKey identifies an answer for merging. Timestamps are compared as
+// instants, whatever zone they were written in.
+func answerKey(item AnswerLogItem) AnswerLogItem {
+	item.Timestamp = item.Timestamp.UTC()
+	return item
+}
+
+// mergeMap returns the union of two maps; pick decides keys in both.
+func mergeMap[V any](ours, theirs map[string]V, pick func(ours, theirs V) V) map[string]V {
+	if len(ours) == 0 && len(theirs) == 0 {
+		return ours
+	}
+	merged := make(map[string]V, len(ours)+len(theirs))
+	for key, value := range ours {
+		merged[key] = value
+	}
+	for key, value := range theirs {
+		if known, ok := merged[key]; ok {
+			value = pick(known, value)
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// sameProgress reports whether a merge left a player as it was.
+func sameProgress(a, b PlayerData) bool {
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(dataA, dataB)
+}
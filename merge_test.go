@@ -0,0 +1,109 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergeCardProgress(t *testing.T) {
+	earlier := testNow.Add(-time.Hour)
+	tests := []struct {
+		name         string
+		ours, theirs CardProgress
+		want         CardProgress
+	}{
+		{
+			name:   "their later review wins",
+			ours:   CardProgress{Box: 3, Streak: 2, Passed: 4, Failed: 1, LastReviewed: earlier},
+			theirs: CardProgress{Box: 1, Passed: 2, Failed: 3, LastReviewed: testNow},
+			want:   CardProgress{Box: 1, Passed: 4, Failed: 3, LastReviewed: testNow},
+		},
+		{
+			name:   "our later review wins",
+			ours:   CardProgress{Box: 2, Streak: 1, Passed: 1, LastReviewed: testNow},
+			theirs: CardProgress{Box: 4, Streak: 3, Passed: 3, LastReviewed: earlier},
+			want:   CardProgress{Box: 2, Streak: 1, Passed: 3, LastReviewed: testNow},
+		},
+		{
+			name:   "same time, higher box",
+			ours:   CardProgress{Box: 2, LastReviewed: testNow},
+			theirs: CardProgress{Box: 3, LastReviewed: testNow},
+			want:   CardProgress{Box: 3, LastReviewed: testNow},
+		},
+		{
+			name:   "new card",
+			theirs: CardProgress{Box: 2, Passed: 1, LastReviewed: testNow},
+			want:   CardProgress{Box: 2, Passed: 1, LastReviewed: testNow},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeCardProgress(tt.ours, tt.theirs); got != tt.want {
+				t.Errorf("mergeCardProgress = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePlayer(t *testing.T) {
+	answer := func(card string, minutes int) AnswerLogItem {
+		return AnswerLogItem{CardID: card, Timestamp: testNow.Add(time.Duration(minutes) * time.Minute), Correct: true}
+	}
+	local := PlayerData{
+		Name:          "laptop",
+		XP:            30,
+		TotalAnswered: 3,
+		Cards:         map[string]CardProgress{"a": {Box: 2, Passed: 1, LastReviewed: testNow}},
+		History:       []AnswerLogItem{answer("a", 0), answer("a", 2), answer("b", 4)},
+		Notes:         map[string]string{"a": "ours"},
+	}
+	other := PlayerData{
+		Name:          "desktop",
+		XP:            50,
+		TotalAnswered: 4,
+		Cards:         map[string]CardProgress{"a": {Box: 1, Failed: 1, LastReviewed: testNow.Add(time.Hour)}, "c": {Box: 2, Passed: 1}},
+		// The answer at minute 0 is the same one, written in another zone
+		History: []AnswerLogItem{{CardID: "a", Timestamp: testNow.In(time.FixedZone("CET", 3600)), Correct: true}, answer("c", 1), answer("c", 3), answer("old", -60)},
+		Notes:   map[string]string{"a": "theirs", "c": "theirs"},
+	}
+	// The oldest answer was archived locally and must not come back
+	archived := []AnswerLogItem{answer("old", -60)}
+
+	merged, summary := mergePlayer(local, other, archived)
+	want := MergeSummary{Answers: 2, Cards: 2}
+	if summary != want {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+	var history []string
+	for _, item := range merged.History {
+		history = append(history, item.CardID+"@"+item.Timestamp.Sub(testNow).String())
+	}
+	if want := []string{"a@0s", "c@1m0s", "a@2m0s", "c@3m0s", "b@4m0s"}; !reflect.DeepEqual(history, want) {
+		t.Errorf("history = %v, want %v", history, want)
+	}
+	if merged.Name != "laptop" || merged.XP != 50 || merged.TotalAnswered != 5 {
+		t.Errorf("name, xp, total = %s, %d, %d; want laptop, 50, 5", merged.Name, merged.XP, merged.TotalAnswered)
+	}
+	if want := map[string]string{"a": "ours", "c": "theirs"}; !reflect.DeepEqual(merged.Notes, want) {
+		t.Errorf("notes = %v, want %v", merged.Notes, want)
+	}
+	if got := merged.Cards["a"]; got.Box != 1 || got.Passed != 1 || got.Failed != 1 {
+		t.Errorf("card a = %+v, want box 1 with 1 pass and 1 failure", got)
+	}
+
+	again, summary := mergePlayer(merged, other, archived)
+	if summary != (MergeSummary{}) || !sameProgress(again, merged) {
+		t.Errorf("merging again changed the player: %+v", summary)
+	}
+}
+
+func TestMergePlayerIsSymmetricInAnswers(t *testing.T) {
+	a := PlayerData{History: []AnswerLogItem{{CardID: "x", Timestamp: testNow}}, Cards: map[string]CardProgress{"x": {Box: 2, LastReviewed: testNow}}}
+	b := PlayerData{History: []AnswerLogItem{{CardID: "y", Timestamp: testNow.Add(time.Minute)}}, Cards: map[string]CardProgress{"y": {Box: 3, LastReviewed: testNow}}}
+	ab, _ := mergePlayer(a, b, nil)
+	ba, _ := mergePlayer(b, a, nil)
+	if !reflect.DeepEqual(ab.History, ba.History) || !reflect.DeepEqual(ab.Cards, ba.Cards) {
+		t.Errorf("merge order matters:\n%+v\n%+v", ab, ba)
+	}
+}
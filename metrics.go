@@ -0,0 +1,206 @@
+// metrics.go
+//
+// Prometheus metrics for serve mode at /metrics. Learning metrics are read
+// from progress.json on every scrape, so they are always current even while
+// check-answer runs in another process; request latencies are measured by
+// the server itself. The text exposition format is simple enough to write
+// by hand, which keeps the Prometheus client library out of the build.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the request latency
+// histogram.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// requestMetrics collects request counts and latencies per route.
+type requestMetrics struct {
+	mu     sync.Mutex
+	routes map[routeKey]*latencyHistogram
+}
+
+type routeKey struct {
+	Route string
+	Code  int
+}
+
+type latencyHistogram struct {
+	Buckets []uint64 // cumulative counts per latencyBuckets entry
+	Count   uint64
+	Sum     float64
+}
+
+var serverMetrics = &requestMetrics{routes: make(map[routeKey]*latencyHistogram)}
+
+func (m *requestMetrics) observe(route string, code int, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := routeKey{route, code}
+	h, ok := m.routes[key]
+	if !ok {
+		h = &latencyHistogram{Buckets: make([]uint64, len(latencyBuckets))}
+		m.routes[key] = h
+	}
+	seconds := elapsed.Seconds()
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.Buckets[i]++
+		}
+	}
+	h.Count++
+	h.Sum += seconds
+}
+
+// statusRecorder remembers the status code a handler wrote.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// instrument wraps a handler so its requests show up in /metrics under the
+// given route pattern.
+func instrument(route string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		handler(recorder, r)
+		serverMetrics.observe(route, recorder.code, time.Since(started))
+	}
+}
+
+// --- HTTP Handlers ---
+
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writeLearningMetrics(w, loadAllProgress(), time.Now())
+	serverMetrics.write(w)
+}
+
+// --- Exposition ---
+
+func writeLearningMetrics(w io.Writer, allProgress map[string]PlayerData, now time.Time) {
+	ids := make([]string, 0, len(allProgress))
+	for id := range allProgress {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	metricHeader(w, "decouvertes_players", "gauge", "Number of players.")
+	fmt.Fprintf(w, "decouvertes_players %d\n", len(ids))
+
+	metricHeader(w, "decouvertes_answers_total", "counter", "Answers given, excluding practice.")
+	for _, id := range ids {
+		fmt.Fprintf(w, "decouvertes_answers_total{%s} %d\n", playerLabels(id, allProgress[id]), allProgress[id].TotalAnswered)
+	}
+
+	metricHeader(w, "decouvertes_answers_correct_total", "counter", "Correct answers, excluding practice.")
+	for _, id := range ids {
+		correct := 0
+		for _, progress := range allProgress[id].Cards {
+			correct += progress.Passed
+		}
+		fmt.Fprintf(w, "decouvertes_answers_correct_total{%s} %d\n", playerLabels(id, allProgress[id]), correct)
+	}
+
+	metricHeader(w, "decouvertes_accuracy_ratio", "gauge", "Share of correct answers over all cards.")
+	for _, id := range ids {
+		passed, failed := 0, 0
+		for _, progress := range allProgress[id].Cards {
+			passed += progress.Passed
+			failed += progress.Failed
+		}
+		if passed+failed > 0 {
+			fmt.Fprintf(w, "decouvertes_accuracy_ratio{%s} %s\n", playerLabels(id, allProgress[id]), formatMetric(float64(passed)/float64(passed+failed)))
+		}
+	}
+
+	metricHeader(w, "decouvertes_cards", "gauge", "Cards per box; box=\"retired\" counts retired cards.")
+	for _, id := range ids {
+		var counts [6]int
+		for _, progress := range allProgress[id].Cards {
+			switch {
+			case progress.Retired:
+				counts[5]++
+			case progress.Box > 0:
+				counts[min(progress.Box, topBox)-1]++
+			}
+		}
+		for i, n := range counts {
+			box := strconv.Itoa(i + 1)
+			if i == 5 {
+				box = "retired"
+			}
+			fmt.Fprintf(w, "decouvertes_cards{%s,box=\"%s\"} %d\n", playerLabels(id, allProgress[id]), box, n)
+		}
+	}
+
+	metricHeader(w, "decouvertes_xp", "gauge", "Experience points.")
+	for _, id := range ids {
+		fmt.Fprintf(w, "decouvertes_xp{%s} %d\n", playerLabels(id, allProgress[id]), allProgress[id].XP)
+	}
+
+	metricHeader(w, "decouvertes_daily_streak_days", "gauge", "Current daily streak.")
+	for _, id := range ids {
+		current, _ := dailyStreaks(allProgress[id].History, now)
+		fmt.Fprintf(w, "decouvertes_daily_streak_days{%s} %d\n", playerLabels(id, allProgress[id]), current)
+	}
+}
+
+func (m *requestMetrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]routeKey, 0, len(m.routes))
+	for key := range m.routes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Route != keys[j].Route {
+			return keys[i].Route < keys[j].Route
+		}
+		return keys[i].Code < keys[j].Code
+	})
+
+	metricHeader(w, "decouvertes_http_request_duration_seconds", "histogram", "Latency of HTTP requests by route and status code.")
+	for _, key := range keys {
+		h := m.routes[key]
+		labels := fmt.Sprintf("route=\"%s\",code=\"%d\"", escapeLabel(key.Route), key.Code)
+		for i, bound := range latencyBuckets {
+			fmt.Fprintf(w, "decouvertes_http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, formatMetric(bound), h.Buckets[i])
+		}
+		fmt.Fprintf(w, "decouvertes_http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.Count)
+		fmt.Fprintf(w, "decouvertes_http_request_duration_seconds_sum{%s} %s\n", labels, formatMetric(h.Sum))
+		fmt.Fprintf(w, "decouvertes_http_request_duration_seconds_count{%s} %d\n", labels, h.Count)
+	}
+}
+
+func metricHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func playerLabels(id string, player PlayerData) string {
+	return fmt.Sprintf("player_id=\"%s\",player=\"%s\"", escapeLabel(id), escapeLabel(player.Name))
+}
+
+// escapeLabel escapes a label value as the exposition format requires.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatMetric(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
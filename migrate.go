@@ -0,0 +1,160 @@
+// migrate.go
+//
+// Format versions of progress.json and deck files. Every file carries a
+// "version" field; older files are upgraded on load by running the
+// migrations from their version up to the current one, on the raw JSON,
+// before it is decoded into structs. That way a renamed or restructured
+// field is carried over instead of silently decoding as zero.
+//
+// To change a format: bump the constant, and add a migration whose From is
+// the old version.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"os"
+)
+
+// Current format versions.
+//
+// progress.json: 1 was a bare object of players; 2 wraps them as
+// {"version": 2, "players": {...}}; 3 keeps retired cards in box 5 with
+// "retired" set, where they used to move on to box 6.
+//
+// Decks: 1 was a bare array of cards; 2 is an object with normalization
+// options and cards.
+const (
+	progressFormat = 3
+	deckFormat     = 2
+)
+
+// migration upgrades a decoded JSON document by one version.
+type migration struct {
+	From        int
+	Description string
+	Apply       func(doc interface{}) (interface{}, error)
+}
+
+var progressMigrations = []migration{
+	{From: 1, Description: "wrap players in a versioned file", Apply: func(doc interface{}) (interface{}, error) {
+		return map[string]interface{}{"players": doc}, nil
+	}},
+	{From: 2, Description: "retire cards past box 5", Apply: func(doc interface{}) (interface{}, error) {
+		for _, player := range objectMembers(doc, "players") {
+			for _, value := range objectMembers(player, "cards") {
+				card, ok := value.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if box, ok := card["box"].(float64); ok && box > topBox {
+					card["box"] = float64(topBox)
+					card["retired"] = true
+				}
+			}
+		}
+		return doc, nil
+	}},
+}
+
+var deckMigrations = []migration{
+	{From: 1, Description: "turn the list of cards into a deck", Apply: func(doc interface{}) (interface{}, error) {
+		return map[string]interface{}{"cards": doc}, nil
+	}},
+}
+
+// progressFile is progress.json as it is written.
+type progressFile struct {
+	Version int                   `json:"version"`
+	Players map[string]PlayerData `json:"players"`
+}
+
+// progressVersion tells the format of a decoded progress.json. Files from
+// before versioning have no "version" key.
+func progressVersion(doc interface{}) int {
+	if object, ok := doc.(map[string]interface{}); ok {
+		if version, ok := object["version"].(float64); ok {
+			return int(version)
+		}
+	}
+	return 1
+}
+
+// deckVersion tells the format of a decoded deck file. Deck objects from
+// before versioning are format 2.
+func deckVersion(doc interface{}) int {
+	object, ok := doc.(map[string]interface{})
+	if !ok {
+		return 1
+	}
+	if version, ok := object["version"].(float64); ok {
+		return int(version)
+	}
+	return 2
+}
+
+// migrate runs the migrations that take doc from version to current. name
+// is the file, for messages.
+func migrate(name string, doc interface{}, version, current int, migrations []migration) (interface{}, error) {
+	if version > current {
+		return nil, fmt.Errorf("%s has format version %d, but this version of decouvertes only reads up to %d; please upgrade", name, version, current)
+	}
+	for version < current {
+		var step *migration
+		for i := range migrations {
+			if migrations[i].From == version {
+				step = &migrations[i]
+			}
+		}
+		if step == nil {
+			return nil, fmt.Errorf("%s has format version %d, which can't be upgraded", name, version)
+		}
+		upgraded, err := step.Apply(doc)
+		if err != nil {
+			return nil, fmt.Errorf("upgrading %s from format %d (%s): %w", name, version, step.Description, err)
+		}
+		slog.Debug("Migrated file", "file", name, "from", version, "step", step.Description)
+		doc = upgraded
+		version++
+	}
+	if object, ok := doc.(map[string]interface{}); ok {
+		object["version"] = float64(current)
+	}
+	return doc, nil
+}
+
+// keepPreMigrationCopy saves the file as it was before an upgrade to
+// current, as <path>.v<version>, so the older release can still open it.
+func keepPreMigrationCopy(path string, data []byte, version, current int) {
+	copyPath := fmt.Sprintf("%s.v%d", path, version)
+	if _, err := os.Stat(copyPath); err == nil {
+		return
+	}
+	if err := ioutil.WriteFile(copyPath, data, 0644); err != nil {
+		fatalf("Error saving a copy of %s before upgrading it (%s): %v", path, copyPath, err)
+	}
+	slog.Info("Upgraded file format", "file", path, "from", version, "to", current, "copy", copyPath)
+}
+
+// objectMembers returns the members of the object at doc[key], or nil if
+// either isn't an object. Migrations leave damaged parts to the decoder.
+func objectMembers(doc interface{}, key string) map[string]interface{} {
+	object, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	members, _ := object[key].(map[string]interface{})
+	return members
+}
+
+// remarshal turns a generic JSON value back into raw JSON.
+func remarshal(value interface{}) json.RawMessage {
+	data, err := json.Marshal(value)
+	if err != nil {
+		fatalf("Error re-encoding JSON: %v", err)
+	}
+	return data
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestProgressVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want int
+	}{
+		{"bare players", `{"p1": {"name": "a"}}`, 1},
+		{"versioned", `{"version": 2, "players": {}}`, 2},
+		{"current", `{"version": 3, "players": {}}`, 3},
+		{"not an object", `[]`, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := progressVersion(decodeJSON(t, tt.doc)); got != tt.want {
+				t.Errorf("progressVersion = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeckVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want int
+	}{
+		{"bare cards", `[{"id": "a"}]`, 1},
+		{"unversioned deck", `{"cards": []}`, 2},
+		{"versioned deck", `{"version": 2, "cards": []}`, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deckVersion(decodeJSON(t, tt.doc)); got != tt.want {
+				t.Errorf("deckVersion = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrateProgress(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "version 1 is wrapped and box 6 retired",
+			doc:  `{"p1": {"name": "a", "cards": {"c1": {"box": 6}, "c2": {"box": 3}}}}`,
+			want: `{"version": 3, "players": {"p1": {"name": "a", "cards": {"c1": {"box": 5, "retired": true}, "c2": {"box": 3}}}}}`,
+		},
+		{
+			name: "version 2 retires box 6",
+			doc:  `{"version": 2, "players": {"p1": {"cards": {"c1": {"box": 6}}}}}`,
+			want: `{"version": 3, "players": {"p1": {"cards": {"c1": {"box": 5, "retired": true}}}}}`,
+		},
+		{
+			name: "current version is left alone",
+			doc:  `{"version": 3, "players": {"p1": {"cards": {"c1": {"box": 5}}}}}`,
+			want: `{"version": 3, "players": {"p1": {"cards": {"c1": {"box": 5}}}}}`,
+		},
+		{
+			name: "damaged cards are left to the decoder",
+			doc:  `{"version": 2, "players": {"p1": {"cards": {"c1": "box 6"}}}}`,
+			want: `{"version": 3, "players": {"p1": {"cards": {"c1": "box 6"}}}}`,
+		},
+		{
+			name:    "newer version",
+			doc:     `{"version": 4, "players": {}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := decodeJSON(t, tt.doc)
+			got, err := migrate("progress.json", doc, progressVersion(doc), progressFormat, progressMigrations)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("migrate succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("migrate: %v", err)
+			}
+			if want := decodeJSON(t, tt.want); string(remarshal(got)) != string(remarshal(want)) {
+				t.Errorf("migrate = %s, want %s", remarshal(got), remarshal(want))
+			}
+		})
+	}
+}
+
+func TestMigrateMissingStep(t *testing.T) {
+	if _, err := migrate("deck.json", decodeJSON(t, `[]`), 0, deckFormat, deckMigrations); err == nil {
+		t.Errorf("migrate from a version without a migration succeeded, want an error")
+	}
+}
+
+func decodeJSON(t *testing.T, data string) interface{} {
+	t.Helper()
+	var doc interface{}
+	if err := json.Unmarshal([]byte(data), &doc); err != nil {
+		t.Fatalf("bad test JSON %s: %v", data, err)
+	}
+	return doc
+}
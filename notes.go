@@ -0,0 +1,43 @@
+// notes.go
+//
+// Personal notes on cards: mnemonics and reminders a player attaches to a
+// card for themselves. They live in the player's progress, so the shared
+// deck stays untouched and every player has their own.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// --- Command Handlers ---
+
+func handleAnnotateCard(playerID, cardID, note string) {
+	if _, ok := findCard(loadCards(), cardID); !ok {
+		fatalf("Card with ID '%s' not found in deck.", cardID)
+	}
+
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	note = strings.TrimSpace(note)
+	if note == "" {
+		if _, ok := player.Notes[cardID]; !ok {
+			fmt.Printf(tr("Card %s has no note.\n"), cardID)
+			return
+		}
+		delete(player.Notes, cardID)
+		fmt.Printf(tr("Removed the note on card %s.\n"), cardID)
+	} else {
+		if player.Notes == nil {
+			player.Notes = make(map[string]string)
+		}
+		player.Notes[cardID] = note
+		fmt.Printf(tr("Saved the note on card %s.\n"), cardID)
+	}
+	allProgress[playerID] = player
+	saveAllProgress(allProgress)
+}
@@ -0,0 +1,243 @@
+// overview.go
+//
+// The household overview: every player on one screen. It shows the reviews
+// given today, each player's daily streak and week, the player whose
+// accuracy rose the most against the week before, and the cards all players
+// together get wrong most often. overview --json and GET /api/overview give
+// the same numbers to a web page.
+//
+// Weeks are the last seven calendar days, today included, as in weekly
+// reports. A player needs minImprovedAnswers answers in both weeks to count
+// as most improved, and a card minHardestAnswers answers to count as hard,
+// so a single unlucky answer doesn't decide either.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	minImprovedAnswers = 10
+	minHardestAnswers  = 5
+	// defaultHardestCards is how many hardest cards overview lists.
+	defaultHardestCards = 5
+)
+
+// Overview is the report of the overview command.
+type Overview struct {
+	Date         time.Time        `json:"date"`
+	ReviewsToday int              `json:"reviews_today"`
+	CorrectToday int              `json:"correct_today"`
+	Players      []PlayerOverview `json:"players"`
+	// MostImproved is the ID of the most improved player, if there is one.
+	MostImproved string     `json:"most_improved,omitempty"`
+	HardestCards []HardCard `json:"hardest_cards"`
+}
+
+// PlayerOverview is one player's line in the overview.
+type PlayerOverview struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	ReviewsToday  int     `json:"reviews_today"`
+	Streak        int     `json:"streak"`
+	LongestStreak int     `json:"longest_streak"`
+	WeekAnswered  int     `json:"week_answered"`
+	WeekAccuracy  float64 `json:"week_accuracy"`
+	// PreviousAnswered and PreviousAccuracy cover the week before.
+	PreviousAnswered int     `json:"previous_answered"`
+	PreviousAccuracy float64 `json:"previous_accuracy"`
+}
+
+// improvement is the change in accuracy against the week before, or false
+// if either week has too few answers to tell.
+func (p PlayerOverview) improvement() (float64, bool) {
+	if p.WeekAnswered < minImprovedAnswers || p.PreviousAnswered < minImprovedAnswers {
+		return 0, false
+	}
+	return p.WeekAccuracy - p.PreviousAccuracy, true
+}
+
+// HardCard is a card with the answers of all players to it.
+type HardCard struct {
+	ID       string  `json:"id"`
+	Prompt   string  `json:"prompt"`
+	Answered int     `json:"answered"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+	Players  int     `json:"players"`
+}
+
+// buildOverview summarizes all players at now.
+func buildOverview(allProgress map[string]PlayerData, cards []Card, hardest int, now time.Time) Overview {
+	overview := Overview{Date: calendarDay(now), Players: []PlayerOverview{}, HardestCards: []HardCard{}}
+	todayStart := calendarDay(now)
+	byCard := make(map[string]*HardCard)
+	playersByCard := make(map[string]map[string]bool)
+
+	for id, player := range allProgress {
+		history := loadFullHistory(id, player)
+		week := buildReport(player, history, cards, "weekly", reportPeriods["weekly"], now)
+		line := PlayerOverview{
+			ID:               id,
+			Name:             player.Name,
+			WeekAnswered:     week.Answered,
+			WeekAccuracy:     week.Accuracy(),
+			PreviousAnswered: week.PreviousAnswered,
+			PreviousAccuracy: week.PreviousAccuracy(),
+		}
+		line.Streak, line.LongestStreak = dailyStreaks(history, now)
+		for _, item := range history {
+			if !item.Timestamp.Before(todayStart) && !isFutureDated(item.Timestamp, now) {
+				line.ReviewsToday++
+				if item.Correct {
+					overview.CorrectToday++
+				}
+			}
+			card, ok := byCard[item.CardID]
+			if !ok {
+				card = &HardCard{ID: item.CardID}
+				byCard[item.CardID] = card
+				playersByCard[item.CardID] = make(map[string]bool)
+			}
+			card.Answered++
+			if item.Correct {
+				card.Correct++
+			}
+			playersByCard[item.CardID][id] = true
+		}
+		overview.ReviewsToday += line.ReviewsToday
+		overview.Players = append(overview.Players, line)
+	}
+	sort.Slice(overview.Players, func(i, j int) bool {
+		a, b := overview.Players[i], overview.Players[j]
+		if a.ReviewsToday != b.ReviewsToday {
+			return a.ReviewsToday > b.ReviewsToday
+		}
+		return a.Name < b.Name
+	})
+
+	best := 0.0
+	for _, player := range overview.Players {
+		if change, ok := player.improvement(); ok && change > best {
+			overview.MostImproved, best = player.ID, change
+		}
+	}
+
+	// Only cards still in the deck
+	for _, card := range cards {
+		hard, ok := byCard[card.ID]
+		if !ok || hard.Answered < minHardestAnswers {
+			continue
+		}
+		hard.Prompt = firstLine(card.Prompt)
+		hard.Accuracy = float64(hard.Correct) / float64(hard.Answered)
+		hard.Players = len(playersByCard[card.ID])
+		overview.HardestCards = append(overview.HardestCards, *hard)
+	}
+	sort.SliceStable(overview.HardestCards, func(i, j int) bool {
+		a, b := overview.HardestCards[i], overview.HardestCards[j]
+		if a.Accuracy != b.Accuracy {
+			return a.Accuracy < b.Accuracy
+		}
+		return a.Answered > b.Answered
+	})
+	overview.HardestCards = overview.HardestCards[:min(len(overview.HardestCards), hardest)]
+	return overview
+}
+
+// --- Command Handlers ---
+
+func handleOverview(hardest int, asJSON bool) {
+	allProgress := loadAllProgress()
+	if len(allProgress) == 0 {
+		fmt.Println(tr("No players found. Create one with 'create-player --name=\"YourName\"'"))
+		return
+	}
+	overview := buildOverview(allProgress, loadCards(), hardest, time.Now())
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(overview); err != nil {
+			fatalf("Error writing overview JSON: %v", err)
+		}
+		return
+	}
+	printOverview(overview, resolveLocale(""))
+}
+
+func serveOverview(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, buildOverview(loadAllProgress(), loadCards(), defaultHardestCards, time.Now()))
+}
+
+// --- Helpers ---
+
+func printOverview(overview Overview, loc Locale) {
+	active := 0
+	for _, player := range overview.Players {
+		if player.ReviewsToday > 0 {
+			active++
+		}
+	}
+	fmt.Printf(tr("Overview for %s\n\n"), loc.Date(overview.Date))
+	fmt.Printf(tr("Today: %s review(s) by %d of %d player(s)"), loc.Number(overview.ReviewsToday), active, len(overview.Players))
+	if overview.ReviewsToday > 0 {
+		fmt.Printf(tr(", %s correct"), loc.Percent(float64(overview.CorrectToday)/float64(overview.ReviewsToday)))
+	}
+	fmt.Println()
+
+	fmt.Printf("\n%-16s %6s %7s %10s %9s %10s\n", tr("Player"), tr("Today"), tr("Streak"), tr("This week"), tr("Accuracy"), tr("Last week"))
+	for _, player := range overview.Players {
+		accuracy, previous := "-", "-"
+		if player.WeekAnswered > 0 {
+			accuracy = loc.Percent(player.WeekAccuracy)
+		}
+		if player.PreviousAnswered > 0 {
+			previous = loc.Percent(player.PreviousAccuracy)
+		}
+		streak := "-"
+		if player.Streak > 0 {
+			streak = fmt.Sprintf("%dd", player.Streak)
+		}
+		fmt.Printf("%-16s %6s %7s %10s %9s %10s\n", truncateName(player.Name, 16), loc.Number(player.ReviewsToday), streak,
+			loc.Number(player.WeekAnswered), accuracy, previous)
+	}
+
+	var streaks []string
+	for _, player := range overview.Players {
+		if player.Streak > 0 {
+			streaks = append(streaks, fmt.Sprintf(tr("%s (%d day(s))"), player.Name, player.Streak))
+		}
+	}
+	if len(streaks) > 0 {
+		fmt.Printf(tr("\nOn a streak: %s\n"), strings.Join(streaks, ", "))
+	}
+	for _, player := range overview.Players {
+		if player.ID == overview.MostImproved {
+			fmt.Printf(tr("Most improved this week: %s, %s -> %s\n"), player.Name, loc.Percent(player.PreviousAccuracy), loc.Percent(player.WeekAccuracy))
+		}
+	}
+
+	if len(overview.HardestCards) == 0 {
+		return
+	}
+	fmt.Println(tr("\nHardest cards:"))
+	for _, card := range overview.HardestCards {
+		fmt.Printf(tr("  %5s of %-4s %s  %s\n"), loc.Percent(card.Accuracy), loc.Number(card.Answered), card.ID, card.Prompt)
+	}
+}
+
+// truncateName shortens a name to width runes for a table column.
+func truncateName(name string, width int) string {
+	runes := []rune(name)
+	if len(runes) <= width {
+		return name
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,157 @@
+// paths.go
+//
+// Where decouvertes keeps its files, and the init command that sets them
+// up. By default it follows the platform's conventions (the XDG base
+// directory spec on Linux): what the user writes (config.json, cards.json,
+// checkers, card types, seasonal events) lives in the config directory, and
+// what the program writes (progress, backups, logs, sessions and the other
+// records) in the data directory.
+//
+// --data-dir, or DECOUVERTES_HOME when the flag isn't given, puts
+// everything in one directory instead, e.g. a share in a school lab or a
+// deck kept next to a project.
+
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// starterDeck is the example deck init writes to a new setup.
+//
+//go:embed cards.json
+var starterDeck []byte
+
+// dataDirOverride is the --data-dir flag or DECOUVERTES_HOME.
+var dataDirOverride string
+
+// setDataDir applies --data-dir, falling back to DECOUVERTES_HOME.
+func setDataDir(flagValue string) {
+	dataDirOverride = flagValue
+	if dataDirOverride == "" {
+		dataDirOverride = os.Getenv("DECOUVERTES_HOME")
+	}
+	if dataDirOverride != "" {
+		if abs, err := filepath.Abs(dataDirOverride); err == nil {
+			dataDirOverride = abs
+		}
+	}
+}
+
+// getConfigDir returns the directory with config.json and the deck. That
+// is the platform's config directory as given by os.UserConfigDir:
+// $XDG_CONFIG_HOME/decouvertes (~/.config/decouvertes) on Linux and BSD,
+// ~/Library/Application Support/decouvertes on macOS and
+// %AppData%\decouvertes on Windows.
+//
+// Earlier releases used ~/.config/decouvertes everywhere; if only that
+// exists, it is still used.
+func getConfigDir() string {
+	if dataDirOverride != "" {
+		return dataDirOverride
+	}
+	base, err := os.UserConfigDir()
+	if err != nil {
+		fatalf("Could not find the config directory: %v. Use --data-dir or DECOUVERTES_HOME.", err)
+	}
+	configDir := filepath.Join(base, "decouvertes")
+	if home, err := os.UserHomeDir(); err == nil && !fileExists(configDir) {
+		if legacy := filepath.Join(home, ".config", "decouvertes"); fileExists(legacy) {
+			return legacy
+		}
+	}
+	return configDir
+}
+
+// getDataDir returns the directory with progress and the other files
+// decouvertes writes: $XDG_DATA_HOME/decouvertes
+// (~/.local/share/decouvertes) on Linux and BSD, %LocalAppData%\decouvertes
+// on Windows, and the config directory on macOS, where Application Support
+// holds both. It is created if needed.
+//
+// Setups from before the split kept everything in the config directory;
+// as long as progress.json is only found there, that stays the data
+// directory so nothing goes missing.
+func getDataDir() string {
+	if dataDirOverride != "" {
+		return dataDirOverride
+	}
+	configDir := getConfigDir()
+	dataDir := filepath.Join(userDataDir(configDir), "decouvertes")
+	if !fileExists(filepath.Join(dataDir, "progress.json")) && fileExists(filepath.Join(configDir, "progress.json")) {
+		return configDir
+	}
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		fatalf("Error creating data directory (%s): %v", dataDir, err)
+	}
+	return dataDir
+}
+
+// --- Command Handlers ---
+
+func handleInit() {
+	configDir := getConfigDir()
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		fatalf("Error creating config directory (%s): %v", configDir, err)
+	}
+	dataDir := getDataDir()
+
+	deckFile := deckPath()
+	if fileExists(deckFile) {
+		fmt.Printf(tr("%s already exists; leaving it as it is.\n"), deckFile)
+	} else {
+		var cards []Card
+		if err := json.Unmarshal(starterDeck, &cards); err != nil {
+			fatalf("Error unmarshalling starter deck JSON: %v", err)
+		}
+		data, err := json.MarshalIndent(Deck{Version: deckFormat, Cards: cards}, "", "  ")
+		if err != nil {
+			fatalf("Error marshalling starter deck to JSON: %v", err)
+		}
+		if err := ioutil.WriteFile(deckFile, data, 0644); err != nil {
+			fatalf("Error writing starter deck (%s): %v", deckFile, err)
+		}
+		fmt.Printf(tr("Wrote a starter deck of %d cards to %s.\n"), len(cards), deckFile)
+	}
+
+	fmt.Printf(tr("Config: %s\n"), configDir)
+	fmt.Printf(tr("Data:   %s\n"), dataDir)
+	fmt.Println(tr("\nNext, create a player with 'decouvertes create-player --name=<name>'."))
+}
+
+// --- Helpers ---
+
+// userDataDir returns the platform's base directory for application data.
+// configDir is the decouvertes config directory, used where the platform
+// doesn't separate the two.
+func userDataDir(configDir string) string {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return dir
+		}
+		return filepath.Dir(configDir)
+	case "darwin", "ios", "plan9":
+		return filepath.Dir(configDir)
+	}
+	// The spec asks for relative values to be ignored
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" && filepath.IsAbs(dir) {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		fatalf("Could not find user home directory: %v", err)
+	}
+	return filepath.Join(home, ".local", "share")
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
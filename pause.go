@@ -0,0 +1,154 @@
+// pause.go
+//
+// Paused study and exam sessions. While a session runs, its state is saved
+// to paused-sessions.json before every card is shown and after every
+// answer: the answers so far, the cards still to ask and the time of the
+// last checkpoint. A session that is interrupted, by Ctrl+C, a crash or a
+// closed terminal, can then be resumed with --resume, which asks the card
+// that was on screen first. The time between the last checkpoint and the
+// resume doesn't count towards the session's duration. There is one paused
+// session per player and mode; finishing or starting a session of the same
+// mode clears it.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Session modes that can be paused.
+const (
+	PausedStudy = "study"
+	PausedExam  = "exam"
+)
+
+// PausedSession is the saved state of an unfinished session.
+type PausedSession struct {
+	Mode     string    `json:"mode"`
+	PlayerID string    `json:"player_id"`
+	PausedAt time.Time `json:"paused_at"`
+	// Pending are the IDs of the cards still to ask, the card on screen
+	// first. Study picks its cards as it goes, so it has at most one.
+	Pending []string `json:"pending,omitempty"`
+	// Count is the number of cards in a study session, Correct the number
+	// answered correctly so far.
+	Count   int           `json:"count,omitempty"`
+	Correct int           `json:"correct,omitempty"`
+	Study   *StudySession `json:"study,omitempty"`
+	Exam    *ExamResult   `json:"exam,omitempty"`
+	// Paused is the time the session spent paused before, for exams.
+	Paused time.Duration `json:"paused,omitempty"`
+}
+
+// pauseMu keeps an interrupt from ending the program halfway through
+// writing a checkpoint.
+var pauseMu sync.Mutex
+
+// checkpointSession saves the state of a running session, replacing the
+// previous checkpoint of the same player and mode. Guests leave none.
+func checkpointSession(paused PausedSession) {
+	if isGuest(paused.PlayerID) {
+		return
+	}
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+	paused.PausedAt = time.Now()
+	sessions := withoutPausedSession(loadPausedSessions(), paused.Mode, paused.PlayerID)
+	savePausedSessions(append(sessions, paused))
+}
+
+// clearPausedSession removes the checkpoint of a player's session.
+func clearPausedSession(mode, playerID string) {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+	sessions := loadPausedSessions()
+	if kept := withoutPausedSession(sessions, mode, playerID); len(kept) != len(sessions) {
+		savePausedSessions(kept)
+	}
+}
+
+// findPausedSession returns the paused session of a player, if there is one.
+func findPausedSession(mode, playerID string) (PausedSession, bool) {
+	for _, paused := range loadPausedSessions() {
+		if paused.Mode == mode && paused.PlayerID == playerID {
+			return paused, true
+		}
+	}
+	return PausedSession{}, false
+}
+
+// warnPausedSession tells the player that starting a new session drops
+// the paused one.
+func warnPausedSession(mode, playerID string) {
+	if paused, ok := findPausedSession(mode, playerID); ok {
+		warnf("the %s session paused on %s is discarded; use --resume to continue it instead.", mode, resolveLocale("").DateTime(paused.PausedAt))
+	}
+}
+
+// pauseOnInterrupt makes Ctrl+C leave the session paused with a hint on
+// how to resume it, instead of just ending the program.
+func pauseOnInterrupt(mode, playerID string) {
+	if isGuest(playerID) {
+		return
+	}
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupts
+		pauseMu.Lock()
+		foldJournal()
+		fmt.Printf(tr("\n\nSession paused. Continue it with '%s --player-id=%s --resume'.\n"), mode, playerID)
+		stopProfiles()
+		os.Exit(130)
+	}()
+}
+
+// --- Helpers ---
+
+func withoutPausedSession(sessions []PausedSession, mode, playerID string) []PausedSession {
+	kept := sessions[:0:0]
+	for _, paused := range sessions {
+		if paused.Mode != mode || paused.PlayerID != playerID {
+			kept = append(kept, paused)
+		}
+	}
+	return kept
+}
+
+func loadPausedSessions() []PausedSession {
+	filePath := filepath.Join(getDataDir(), "paused-sessions.json")
+	file, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		fatalf("Error reading paused sessions (%s): %v", filePath, err)
+	}
+	var sessions []PausedSession
+	if len(file) == 0 {
+		return sessions
+	}
+	if err := json.Unmarshal(file, &sessions); err != nil {
+		fatalf("Error unmarshalling paused sessions JSON: %v", err)
+	}
+	return sessions
+}
+
+func savePausedSessions(sessions []PausedSession) {
+	filePath := filepath.Join(getDataDir(), "paused-sessions.json")
+	data, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		fatalf("Error marshalling paused sessions to JSON: %v", err)
+	}
+	if err := ioutil.WriteFile(filePath, data, 0644); err != nil {
+		fatalf("Error writing paused sessions (%s): %v", filePath, err)
+	}
+}
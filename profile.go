@@ -0,0 +1,73 @@
+// profile.go
+//
+// Profiling hooks for measuring where a command spends its time. The global
+// flags --cpuprofile and --memprofile write pprof profiles of whatever
+// subcommand follows them, to be read with 'go tool pprof':
+//
+//	decouvertes --cpuprofile=cpu.out bench --cards=50000
+//	go tool pprof -top decouvertes cpu.out
+//
+// The CPU profile covers the whole command; the heap profile is taken when
+// it ends, after a garbage collection. Commands that stop with an error
+// still write both.
+
+package main
+
+import (
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+)
+
+// stopProfiles ends profiling and writes the profiles; it does nothing
+// until startProfiles is called.
+var stopProfiles = func() {}
+
+// startProfiles starts the CPU profile and arranges for both profiles to
+// be written by stopProfiles. Empty paths turn a profile off.
+func startProfiles(cpuPath, memPath string) {
+	if cpuPath == "" && memPath == "" {
+		return
+	}
+	var cpuFile *os.File
+	if cpuPath != "" {
+		file, err := os.Create(cpuPath)
+		if err != nil {
+			fatalf("Error creating CPU profile (%s): %v", cpuPath, err)
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			fatalf("Error starting CPU profile: %v", err)
+		}
+		cpuFile = file
+	}
+
+	var once sync.Once
+	stopProfiles = func() {
+		once.Do(func() {
+			if cpuFile != nil {
+				pprof.StopCPUProfile()
+				cpuFile.Close()
+			}
+			if memPath != "" {
+				writeHeapProfile(memPath)
+			}
+		})
+	}
+}
+
+// --- Helpers ---
+
+func writeHeapProfile(filePath string) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		warnf("Error creating memory profile (%s): %v", filePath, err)
+		return
+	}
+	defer file.Close()
+	// Up-to-date statistics of what is still in use
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(file); err != nil {
+		warnf("Error writing memory profile (%s): %v", filePath, err)
+	}
+}
@@ -0,0 +1,264 @@
+// repair.go
+//
+// Tolerant reading of progress.json and the repair-progress command. A
+// damaged file (cut short by a crash, a bad merge, hand edits with wrong
+// types) shouldn't lock everyone out: whatever can be read is loaded, the
+// affected players are reported, and a copy of the original is kept next to
+// it before anything is written back.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ProgressDamage describes what couldn't be read from progress.json.
+type ProgressDamage struct {
+	// Players maps the IDs of partially read players to the problem.
+	Players map[string]string
+	// Truncated is set when the file ends or breaks off early; Lost is the
+	// error at that point.
+	Truncated bool
+	Lost      string
+}
+
+func (d ProgressDamage) empty() bool {
+	return len(d.Players) == 0 && !d.Truncated
+}
+
+// decodeProgress reads as much of a progress file as it can and upgrades
+// it to the current format; version is the format it was in. Players whose
+// fields have the wrong type are kept with those fields zeroed; a file that
+// breaks off keeps the players before the break. The error is only set for
+// files that can't be upgraded.
+func decodeProgress(data []byte) (progress map[string]PlayerData, version int, damage ProgressDamage, err error) {
+	damage = ProgressDamage{Players: make(map[string]string)}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		doc = salvageProgress(data)
+		damage.Truncated = true
+		damage.Lost = err.Error()
+	}
+	version = progressVersion(doc)
+	if doc, err = migrate("progress.json", doc, version, progressFormat, progressMigrations); err != nil {
+		return nil, version, damage, err
+	}
+
+	players, ok := doc.(map[string]interface{})["players"].(map[string]interface{})
+	if !ok && !damage.Truncated {
+		damage.Truncated = true
+		damage.Lost = "no players object"
+	}
+	progress = make(map[string]PlayerData, len(players))
+	for id, value := range players {
+		var player PlayerData
+		if err := json.Unmarshal(remarshal(value), &player); err != nil {
+			// Type errors still fill every other field
+			var typeErr *json.UnmarshalTypeError
+			if !errors.As(err, &typeErr) {
+				damage.Players[id] = err.Error()
+				continue
+			}
+			damage.Players[id] = fmt.Sprintf("wrong type for %s", typeErr.Field)
+		}
+		if player.Cards == nil {
+			player.Cards = make(map[string]CardProgress)
+		}
+		progress[id] = player
+	}
+	return progress, version, damage, nil
+}
+
+// salvageProgress reads a damaged file entry by entry, up to the first
+// that can't be parsed. The result has the shape of the file's format, so
+// it can be migrated like an intact one.
+func salvageProgress(data []byte) interface{} {
+	doc := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err == nil && token == json.Delim('{') {
+		salvageEntries(decoder, doc, true)
+	}
+	return doc
+}
+
+// salvageEntries reads the members of an object into object and reports
+// whether it got to the end. At the top level, the "players" object of a
+// versioned file is read member by member too.
+func salvageEntries(decoder *json.Decoder, object map[string]interface{}, top bool) bool {
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		key, ok := token.(string)
+		if !ok {
+			return false
+		}
+		if top && key == "players" {
+			if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+				return false
+			}
+			players := make(map[string]interface{})
+			object[key] = players
+			if !salvageEntries(decoder, players, false) {
+				return false
+			}
+			if _, err := decoder.Token(); err != nil {
+				return false
+			}
+			continue
+		}
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return false
+		}
+		object[key] = value
+	}
+	return true
+}
+
+// reportProgressDamage warns about a damaged progress file and keeps a copy
+// of it. The copy is named after its content, so loading the same damaged
+// file again doesn't pile up copies.
+func reportProgressDamage(filePath string, data []byte, damage ProgressDamage, progress map[string]PlayerData) {
+	sum := sha256.Sum256(data)
+	copyPath := filePath + ".damaged-" + hex.EncodeToString(sum[:4])
+	if _, err := os.Stat(copyPath); os.IsNotExist(err) {
+		if err := ioutil.WriteFile(copyPath, data, 0644); err != nil {
+			fatalf("progress.json is damaged and a copy couldn't be saved (%s): %v", copyPath, err)
+		}
+	}
+
+	var parts []string
+	if damage.Truncated {
+		parts = append(parts, fmt.Sprintf("it breaks off after %d readable player(s) (%s)", len(progress), damage.Lost))
+	}
+	ids := make([]string, 0, len(damage.Players))
+	for id := range damage.Players {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		parts = append(parts, fmt.Sprintf("player %s: %s", playerLabel(id, progress), damage.Players[id]))
+	}
+	warnf("progress.json is damaged: %s. The original was copied to %s; run 'repair-progress' to clean up.", strings.Join(parts, "; "), copyPath)
+}
+
+func playerLabel(id string, progress map[string]PlayerData) string {
+	if player, ok := progress[id]; ok && player.Name != "" {
+		return fmt.Sprintf("%s (%s)", player.Name, id)
+	}
+	return id
+}
+
+// --- Command Handlers ---
+
+func handleRepairProgress(dryRun bool) {
+	allProgress := loadAllProgress()
+	fixes := 0
+
+	ids := make([]string, 0, len(allProgress))
+	for id := range allProgress {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		player := allProgress[id]
+		problems := repairPlayer(id, &player)
+		if len(problems) == 0 {
+			continue
+		}
+		fmt.Printf(tr("Player %s:\n"), playerLabel(id, allProgress))
+		for _, problem := range problems {
+			fmt.Printf("  %s\n", problem)
+		}
+		fixes += len(problems)
+		allProgress[id] = player
+	}
+
+	if fixes == 0 {
+		fmt.Println(tr("No schema problems found."))
+		if !dryRun {
+			// Still rewrite, so a file that was only readable in part is
+			// replaced by what was salvaged
+			saveAllProgress(allProgress)
+		}
+		return
+	}
+	if dryRun {
+		fmt.Printf(tr("\nFound %d problem(s). Run 'repair-progress' without --dry-run to fix them.\n"), fixes)
+		return
+	}
+	saveAllProgress(allProgress)
+	fmt.Printf(tr("\nRepaired %d problem(s).\n"), fixes)
+}
+
+// repairPlayer fixes values that no version of the program writes and
+// returns a description of each fix.
+func repairPlayer(id string, player *PlayerData) []string {
+	var problems []string
+	if player.Name == "" {
+		player.Name = "Player " + id[:min(len(id), 8)]
+		problems = append(problems, fmt.Sprintf("missing name, now %q", player.Name))
+	}
+	if player.TotalAnswered < 0 {
+		problems = append(problems, fmt.Sprintf("negative total_answered %d, now 0", player.TotalAnswered))
+		player.TotalAnswered = 0
+	}
+	if player.XP < 0 {
+		problems = append(problems, fmt.Sprintf("negative xp %d, now 0", player.XP))
+		player.XP = 0
+	}
+
+	cardIDs := make([]string, 0, len(player.Cards))
+	for cardID := range player.Cards {
+		cardIDs = append(cardIDs, cardID)
+	}
+	sort.Strings(cardIDs)
+	for _, cardID := range cardIDs {
+		progress := player.Cards[cardID]
+		switch {
+		case progress.Box < 1:
+			problems = append(problems, fmt.Sprintf("card %s: box %d, now 1", cardID, progress.Box))
+			progress.Box = 1
+		case progress.Box > topBox:
+			problems = append(problems, fmt.Sprintf("card %s: box %d, now retired", cardID, progress.Box))
+			progress.Box = topBox
+			progress.Retired = true
+		}
+		for _, counter := range []struct {
+			name  string
+			value *int
+		}{{"streak", &progress.Streak}, {"passed", &progress.Passed}, {"failed", &progress.Failed}} {
+			if *counter.value < 0 {
+				problems = append(problems, fmt.Sprintf("card %s: negative %s %d, now 0", cardID, counter.name, *counter.value))
+				*counter.value = 0
+			}
+		}
+		player.Cards[cardID] = progress
+	}
+
+	kept := player.History[:0]
+	dropped := 0
+	for _, item := range player.History {
+		if item.CardID == "" || item.Timestamp.IsZero() {
+			dropped++
+			continue
+		}
+		kept = append(kept, item)
+	}
+	if dropped > 0 {
+		problems = append(problems, fmt.Sprintf("%d history entries without card or time, removed", dropped))
+		player.History = kept
+	}
+	return problems
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestDecodeProgress(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		wantPlayers   []string
+		wantDamaged   []string
+		wantTruncated bool
+		wantVersion   int
+	}{
+		{
+			name:        "intact",
+			data:        `{"version": 3, "players": {"p1": {"name": "a"}, "p2": {"name": "b"}}}`,
+			wantPlayers: []string{"p1", "p2"},
+			wantVersion: 3,
+		},
+		{
+			name:          "cut off in the second player",
+			data:          `{"version": 3, "players": {"p1": {"name": "a"}, "p2": {"name": "b", "hist`,
+			wantPlayers:   []string{"p1"},
+			wantTruncated: true,
+			wantVersion:   3,
+		},
+		{
+			name:          "cut off in an unversioned file",
+			data:          `{"p1": {"name": "a"}, "p2": {"na`,
+			wantPlayers:   []string{"p1"},
+			wantTruncated: true,
+			wantVersion:   1,
+		},
+		{
+			name:        "wrong type in one player",
+			data:        `{"version": 3, "players": {"p1": {"name": "a", "xp": "lots"}, "p2": {"name": "b"}}}`,
+			wantPlayers: []string{"p1", "p2"},
+			wantDamaged: []string{"p1"},
+			wantVersion: 3,
+		},
+		{
+			name:          "no players object",
+			data:          `{"version": 3}`,
+			wantTruncated: true,
+			wantVersion:   3,
+		},
+		{
+			name:          "garbage",
+			data:          `not json`,
+			wantTruncated: true,
+			wantVersion:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress, version, damage, err := decodeProgress([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("decodeProgress: %v", err)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", version, tt.wantVersion)
+			}
+			if got := sortedKeys(progress); !reflect.DeepEqual(got, tt.wantPlayers) {
+				t.Errorf("players = %v, want %v", got, tt.wantPlayers)
+			}
+			if got := sortedKeys(damage.Players); !reflect.DeepEqual(got, tt.wantDamaged) {
+				t.Errorf("damaged players = %v, want %v", got, tt.wantDamaged)
+			}
+			if damage.Truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", damage.Truncated, tt.wantTruncated)
+			}
+			for id, player := range progress {
+				if player.Cards == nil {
+					t.Errorf("player %s has nil cards", id)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeProgressKeepsOtherFields(t *testing.T) {
+	progress, _, _, err := decodeProgress([]byte(`{"version": 3, "players": {"p1": {"name": "a", "xp": "lots", "total_answered": 4}}}`))
+	if err != nil {
+		t.Fatalf("decodeProgress: %v", err)
+	}
+	if player := progress["p1"]; player.Name != "a" || player.TotalAnswered != 4 || player.XP != 0 {
+		t.Errorf("player = %+v, want name a, 4 answers and no xp", player)
+	}
+}
+
+func TestRepairPlayer(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		player       PlayerData
+		want         PlayerData
+		wantProblems int
+	}{
+		{
+			name:   "healthy player",
+			player: PlayerData{Name: "a", Cards: map[string]CardProgress{"c1": {Box: 2}}, History: []AnswerLogItem{{CardID: "c1", Timestamp: now}}},
+			want:   PlayerData{Name: "a", Cards: map[string]CardProgress{"c1": {Box: 2}}, History: []AnswerLogItem{{CardID: "c1", Timestamp: now}}},
+		},
+		{
+			name:         "missing name and negative totals",
+			player:       PlayerData{TotalAnswered: -1, XP: -5, Cards: map[string]CardProgress{}},
+			want:         PlayerData{Name: "Player 0123abcd", Cards: map[string]CardProgress{}},
+			wantProblems: 3,
+		},
+		{
+			name:         "boxes out of range",
+			player:       PlayerData{Name: "a", Cards: map[string]CardProgress{"c1": {Box: 0}, "c2": {Box: 7}}},
+			want:         PlayerData{Name: "a", Cards: map[string]CardProgress{"c1": {Box: 1}, "c2": {Box: topBox, Retired: true}}},
+			wantProblems: 2,
+		},
+		{
+			name:         "negative counters",
+			player:       PlayerData{Name: "a", Cards: map[string]CardProgress{"c1": {Box: 1, Streak: -1, Passed: -2, Failed: -3}}},
+			want:         PlayerData{Name: "a", Cards: map[string]CardProgress{"c1": {Box: 1}}},
+			wantProblems: 3,
+		},
+		{
+			name: "history entries without card or time",
+			player: PlayerData{Name: "a", Cards: map[string]CardProgress{}, History: []AnswerLogItem{
+				{CardID: "c1", Timestamp: now}, {Timestamp: now}, {CardID: "c2"},
+			}},
+			want:         PlayerData{Name: "a", Cards: map[string]CardProgress{}, History: []AnswerLogItem{{CardID: "c1", Timestamp: now}}},
+			wantProblems: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player := tt.player
+			problems := repairPlayer("0123abcd-ef", &player)
+			if len(problems) != tt.wantProblems {
+				t.Errorf("problems = %q, want %d", problems, tt.wantProblems)
+			}
+			if !reflect.DeepEqual(player, tt.want) {
+				t.Errorf("player = %+v, want %+v", player, tt.want)
+			}
+			if again := repairPlayer("0123abcd-ef", &player); len(again) != 0 {
+				t.Errorf("second repair found %q, want nothing", again)
+			}
+		})
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,304 @@
+// report.go
+//
+// Summary reports for the report command: activity, accuracy against the
+// period before, newly retired cards and the cards the player struggled
+// with, as Markdown or HTML. With --send the report goes out by mail
+// through the SMTP server in config.json, for accountability emails from a
+// weekly cron job.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/smtp"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reportPeriods are the periods report understands, in days.
+var reportPeriods = map[string]int{"weekly": 7, "monthly": 30}
+
+// maxStruggling is how many struggling cards a report lists.
+const maxStruggling = 10
+
+// ReportConfig is the "report" block of config.json.
+type ReportConfig struct {
+	SMTP SMTPConfig `json:"smtp,omitempty"`
+}
+
+// SMTPConfig is the mail server reports are sent through. The password can
+// be left out of config.json and given in DECOUVERTES_SMTP_PASSWORD instead.
+type SMTPConfig struct {
+	Host     string   `json:"host,omitempty"`
+	Port     int      `json:"port,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+}
+
+// Report is a player's summary for one period.
+type Report struct {
+	Player     string
+	Period     string
+	From, To   time.Time
+	Answered   int
+	Correct    int
+	ActiveDays int
+	// PreviousAnswered and PreviousCorrect cover the period before, for
+	// the trend.
+	PreviousAnswered int
+	PreviousCorrect  int
+	Days             []StatsRow
+	Retired          []Card
+	Struggling       []StatsRow
+}
+
+// Accuracy returns the share of correct answers in the period.
+func (r Report) Accuracy() float64 {
+	if r.Answered == 0 {
+		return 0
+	}
+	return float64(r.Correct) / float64(r.Answered)
+}
+
+// PreviousAccuracy returns the share of correct answers in the period
+// before.
+func (r Report) PreviousAccuracy() float64 {
+	if r.PreviousAnswered == 0 {
+		return 0
+	}
+	return float64(r.PreviousCorrect) / float64(r.PreviousAnswered)
+}
+
+// buildReport summarizes the days before now.
+func buildReport(player PlayerData, history []AnswerLogItem, cards []Card, period string, days int, now time.Time) Report {
+	to := calendarDay(now).AddDate(0, 0, 1)
+	from := to.AddDate(0, 0, -days)
+	previous := from.AddDate(0, 0, -days)
+	report := Report{Player: player.Name, Period: period, From: from, To: to}
+
+	var inPeriod []AnswerLogItem
+	for _, item := range history {
+		switch {
+		case !item.Timestamp.Before(from) && item.Timestamp.Before(to):
+			inPeriod = append(inPeriod, item)
+			report.Answered++
+			if item.Correct {
+				report.Correct++
+			}
+		case !item.Timestamp.Before(previous) && item.Timestamp.Before(from):
+			report.PreviousAnswered++
+			if item.Correct {
+				report.PreviousCorrect++
+			}
+		}
+	}
+
+	stats := buildStatsReport(player, inPeriod, cards)
+	report.Days = stats.Days
+	report.ActiveDays = len(stats.Days)
+	for _, row := range stats.Cards {
+		if row.Answered >= 2 && row.Accuracy() < 0.5 {
+			report.Struggling = append(report.Struggling, row)
+		}
+	}
+	sort.SliceStable(report.Struggling, func(i, j int) bool {
+		a, b := report.Struggling[i], report.Struggling[j]
+		return a.Answered-a.Correct > b.Answered-b.Correct
+	})
+	report.Struggling = report.Struggling[:min(len(report.Struggling), maxStruggling)]
+
+	// A card is retired by an answer, so its last review is when it happened
+	for _, card := range cards {
+		progress, ok := player.Cards[card.ID]
+		if ok && progress.Retired && !progress.LastReviewed.Before(from) && progress.LastReviewed.Before(to) {
+			report.Retired = append(report.Retired, card)
+		}
+	}
+	return report
+}
+
+// --- Command Handlers ---
+
+func handleReport(playerID, period, format, outPath string, send bool) {
+	days, ok := reportPeriods[period]
+	if !ok {
+		fatalf("Unknown period '%s', expected 'weekly' or 'monthly'.", period)
+	}
+	if format != "markdown" && format != "html" {
+		fatalf("Unknown format '%s', expected 'markdown' or 'html'.", format)
+	}
+	player, ok := loadAllProgress()[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	report := buildReport(player, loadFullHistory(playerID, player), loadCards(), period, days, time.Now())
+	loc := resolveLocale(player.Locale)
+
+	var body bytes.Buffer
+	if format == "html" {
+		writeReportHTML(&body, report, loc)
+	} else {
+		writeReportMarkdown(&body, report, loc)
+	}
+
+	switch {
+	case send:
+		config := loadConfig().Report.SMTP
+		subject := fmt.Sprintf("decouvertes: %s report for %s", period, player.Name)
+		if err := sendReport(config, subject, format, body.Bytes()); err != nil {
+			fatalf("Error sending report: %v", err)
+		}
+		fmt.Printf(tr("Sent the %s report for %s to %s.\n"), period, player.Name, strings.Join(config.To, ", "))
+	case outPath != "":
+		if err := ioutil.WriteFile(outPath, body.Bytes(), 0644); err != nil {
+			fatalf("Error writing report (%s): %v", outPath, err)
+		}
+		fmt.Printf(tr("Wrote the %s report for %s to %s.\n"), period, player.Name, outPath)
+	default:
+		os.Stdout.Write(body.Bytes())
+	}
+}
+
+// --- Output ---
+
+func writeReportMarkdown(out io.Writer, report Report, loc Locale) {
+	title := strings.ToUpper(report.Period[:1]) + report.Period[1:]
+	fmt.Fprintf(out, "# %s report for %s\n\n", title, markdownCell(report.Player))
+	fmt.Fprintf(out, "%s to %s\n\n", loc.Date(report.From), loc.Date(report.To.AddDate(0, 0, -1)))
+	if report.Answered == 0 {
+		fmt.Fprintln(out, "No answers in this period.")
+		return
+	}
+	fmt.Fprintf(out, "- Answers: %s on %s day(s)\n", loc.Number(report.Answered), loc.Number(report.ActiveDays))
+	fmt.Fprintf(out, "- Accuracy: %s%s\n", loc.Percent(report.Accuracy()), reportTrend(report, loc))
+	fmt.Fprintf(out, "- Newly retired cards: %s\n", loc.Number(len(report.Retired)))
+
+	fmt.Fprint(out, "\n## Activity\n\n| Day | Answered | Accuracy |\n|---|---:|---:|\n")
+	for _, row := range report.Days {
+		day, _ := time.ParseInLocation("2006-01-02", row.Key, time.Local)
+		fmt.Fprintf(out, "| %s | %s | %s |\n", loc.Date(day), loc.Number(row.Answered), loc.Percent(row.Accuracy()))
+	}
+	if len(report.Retired) > 0 {
+		fmt.Fprint(out, "\n## Newly Retired\n\n")
+		for _, card := range report.Retired {
+			fmt.Fprintf(out, "- %s: %s\n", card.ID, markdownCell(firstLine(card.Prompt)))
+		}
+	}
+	if len(report.Struggling) > 0 {
+		fmt.Fprint(out, "\n## Struggling\n\n| Card | Prompt | Wrong | Accuracy |\n|---|---|---:|---:|\n")
+		for _, row := range report.Struggling {
+			fmt.Fprintf(out, "| %s | %s | %s | %s |\n", markdownCell(row.Key), markdownCell(firstLine(row.Label)), loc.Number(row.Answered-row.Correct), loc.Percent(row.Accuracy()))
+		}
+	}
+}
+
+func writeReportHTML(out io.Writer, report Report, loc Locale) {
+	funcs := template.FuncMap{
+		"date":    loc.Date,
+		"number":  loc.Number,
+		"percent": loc.Percent,
+		"day": func(key string) string {
+			day, _ := time.ParseInLocation("2006-01-02", key, time.Local)
+			return loc.Date(day)
+		},
+		"last":  func(t time.Time) time.Time { return t.AddDate(0, 0, -1) },
+		"wrong": func(row StatsRow) int { return row.Answered - row.Correct },
+		"trend": func(report Report) string { return reportTrend(report, loc) },
+		"first": firstLine,
+		"title": func(s string) string { return strings.ToUpper(s[:1]) + s[1:] },
+	}
+	tmpl := template.Must(template.New("report").Funcs(funcs).Parse(reportTemplate))
+	if err := tmpl.Execute(out, report); err != nil {
+		fatalf("Error writing report: %v", err)
+	}
+}
+
+// reportTrend describes the change in accuracy against the period before.
+func reportTrend(report Report, loc Locale) string {
+	if report.PreviousAnswered == 0 {
+		return ""
+	}
+	change := (report.Accuracy() - report.PreviousAccuracy()) * 100
+	sign := "+"
+	if change < 0 {
+		sign = "-"
+	}
+	return fmt.Sprintf(" (%s%s points on the period before)", sign, loc.Float(max(change, -change), 1))
+}
+
+// --- Helpers ---
+
+// sendReport mails a report to the configured recipients.
+func sendReport(config SMTPConfig, subject, format string, body []byte) error {
+	if config.Host == "" || config.From == "" || len(config.To) == 0 {
+		return fmt.Errorf("set report.smtp.host, from and to in config.json")
+	}
+	port := config.Port
+	if port == 0 {
+		port = 587
+	}
+	password := config.Password
+	if env := os.Getenv("DECOUVERTES_SMTP_PASSWORD"); env != "" {
+		password = env
+	}
+	var auth smtp.Auth
+	if config.Username != "" {
+		auth = smtp.PlainAuth("", config.Username, password, config.Host)
+	}
+
+	contentType := "text/plain; charset=utf-8"
+	if format == "html" {
+		contentType = "text/html; charset=utf-8"
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\nContent-Type: %s\r\n\r\n", contentType)
+	msg.Write(bytes.ReplaceAll(body, []byte("\n"), []byte("\r\n")))
+
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(port))
+	return smtp.SendMail(addr, auth, config.From, config.To, msg.Bytes())
+}
+
+const reportTemplate = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{title .Period}} report for {{.Player}}</title>
+<style>
+body { font-family: sans-serif; max-width: 40em; margin: 2em auto; }
+table { border-collapse: collapse; }
+td, th { padding: 0.2em 0.8em; text-align: left; }
+td.n { text-align: right; }
+</style></head>
+<body>
+<h1>{{title .Period}} report for {{.Player}}</h1>
+<p>{{date .From}} to {{date (last .To)}}</p>
+{{if eq .Answered 0}}<p>No answers in this period.</p>{{else}}
+<ul>
+<li>Answers: {{number .Answered}} on {{number .ActiveDays}} day(s)</li>
+<li>Accuracy: {{percent .Accuracy}}{{trend .}}</li>
+<li>Newly retired cards: {{number (len .Retired)}}</li>
+</ul>
+<h2>Activity</h2>
+<table><tr><th>Day</th><th>Answered</th><th>Accuracy</th></tr>
+{{range .Days}}<tr><td>{{day .Key}}</td><td class="n">{{number .Answered}}</td><td class="n">{{percent .Accuracy}}</td></tr>
+{{end}}</table>
+{{if .Retired}}<h2>Newly Retired</h2>
+<ul>{{range .Retired}}<li>{{.ID}}: {{first .Prompt}}</li>{{end}}</ul>{{end}}
+{{if .Struggling}}<h2>Struggling</h2>
+<table><tr><th>Card</th><th>Prompt</th><th>Wrong</th><th>Accuracy</th></tr>
+{{range .Struggling}}<tr><td>{{.Key}}</td><td>{{first .Label}}</td><td class="n">{{number (wrong .)}}</td><td class="n">{{percent .Accuracy}}</td></tr>
+{{end}}</table>{{end}}
+{{end}}
+</body></html>
+`
@@ -0,0 +1,78 @@
+// retire.go
+//
+// Graduation of cards out of the top box. A card in box 5 stays there while
+// it keeps being answered correctly; after retire_after passes in a row it
+// is retired and leaves rotation for good, unless the player reactivates it.
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// topBox is the highest Leitner box.
+const topBox = 5
+
+// defaultRetireAfter is the number of passes in box 5 that retire a card.
+const defaultRetireAfter = 1
+
+// retireAfter returns the configured graduation rule.
+func (c SchedulerConfig) retireAfter() int {
+	if c.RetireAfter == nil || *c.RetireAfter < 1 {
+		return defaultRetireAfter
+	}
+	return *c.RetireAfter
+}
+
+// applyAnswer moves a card through the boxes for one answer: up a box when
+// correct, back to box 1 when wrong, and into retirement after enough
+// passes in the top box.
+func applyAnswer(progress CardProgress, correct bool, retireAfter int) CardProgress {
+	if correct {
+		progress.Streak++
+		progress.Passed++
+		if progress.Box >= topBox {
+			progress.Box = topBox
+			progress.TopBoxPasses++
+			progress.Retired = progress.TopBoxPasses >= retireAfter
+		} else {
+			progress.Box++
+		}
+	} else {
+		progress.Box = 1
+		progress.Streak = 0
+		progress.Failed++
+		progress.TopBoxPasses = 0
+	}
+	return progress
+}
+
+// inRotation reports whether get-card may serve a card with this progress.
+func inRotation(progress CardProgress) bool {
+	return progress.Box >= 1 && progress.Box <= topBox && !progress.Retired
+}
+
+// --- Command Handlers ---
+
+func handleReactivateCard(playerID, cardID string, box int) {
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	progress, ok := player.Cards[cardID]
+	if !ok || !progress.Retired {
+		fatalf("Card %s is not retired.", cardID)
+	}
+	progress.Retired = false
+	progress.TopBoxPasses = 0
+	progress.Box = box
+	// Decay counts from the last review; start afresh
+	progress.LastReviewed = time.Now()
+	progress.Decayed = 0
+	player.Cards[cardID] = progress
+	allProgress[playerID] = player
+	saveAllProgress(allProgress)
+	fmt.Printf(tr("Card %s is back in rotation in box %d.\n"), cardID, box)
+}
@@ -0,0 +1,77 @@
+package main
+
+import "testing"
+
+func TestApplyAnswer(t *testing.T) {
+	tests := []struct {
+		name        string
+		progress    CardProgress
+		correct     bool
+		retireAfter int
+		want        CardProgress
+	}{
+		{
+			name:        "correct moves up a box",
+			progress:    CardProgress{Box: 1},
+			correct:     true,
+			retireAfter: 1,
+			want:        CardProgress{Box: 2, Streak: 1, Passed: 1},
+		},
+		{
+			name:        "wrong goes back to box 1",
+			progress:    CardProgress{Box: 4, Streak: 3, Passed: 3},
+			retireAfter: 1,
+			want:        CardProgress{Box: 1, Passed: 3, Failed: 1},
+		},
+		{
+			name:        "pass in the top box retires",
+			progress:    CardProgress{Box: topBox, Streak: 4, Passed: 4},
+			correct:     true,
+			retireAfter: 1,
+			want:        CardProgress{Box: topBox, Streak: 5, Passed: 5, TopBoxPasses: 1, Retired: true},
+		},
+		{
+			name:        "top box needs more passes",
+			progress:    CardProgress{Box: topBox, Streak: 4, Passed: 4},
+			correct:     true,
+			retireAfter: 3,
+			want:        CardProgress{Box: topBox, Streak: 5, Passed: 5, TopBoxPasses: 1},
+		},
+		{
+			name:        "last of several top box passes",
+			progress:    CardProgress{Box: topBox, Streak: 6, Passed: 6, TopBoxPasses: 2},
+			correct:     true,
+			retireAfter: 3,
+			want:        CardProgress{Box: topBox, Streak: 7, Passed: 7, TopBoxPasses: 3, Retired: true},
+		},
+		{
+			name:        "wrong in the top box starts the passes over",
+			progress:    CardProgress{Box: topBox, Streak: 6, Passed: 6, TopBoxPasses: 2},
+			retireAfter: 3,
+			want:        CardProgress{Box: 1, Passed: 6, Failed: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyAnswer(tt.progress, tt.correct, tt.retireAfter); got != tt.want {
+				t.Errorf("applyAnswer = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetireAfter(t *testing.T) {
+	tests := []struct {
+		config SchedulerConfig
+		want   int
+	}{
+		{SchedulerConfig{}, defaultRetireAfter},
+		{SchedulerConfig{RetireAfter: intPtr(0)}, defaultRetireAfter},
+		{SchedulerConfig{RetireAfter: intPtr(4)}, 4},
+	}
+	for _, tt := range tests {
+		if got := tt.config.retireAfter(); got != tt.want {
+			t.Errorf("retireAfter(%v) = %d, want %d", tt.config.RetireAfter, got, tt.want)
+		}
+	}
+}
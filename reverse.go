@@ -0,0 +1,60 @@
+// reverse.go
+//
+// Reverse cards for decks with "generate_reverse": true. Every card gets a
+// counterpart asking for the prompt given the solution, so a vocabulary
+// deck is learned in both directions without writing each pair twice. The
+// counterpart's ID is the card's ID with reverseSuffix appended, so its
+// progress is tracked separately.
+//
+// Cards whose answer isn't plain text to compare (a regex or numeric
+// validation, a checker or a card type) have no sensible reverse and are
+// left alone.
+
+package main
+
+import "fmt"
+
+// reverseSuffix is appended to a card's ID for its reverse card.
+const reverseSuffix = ":reverse"
+
+// reverseTag is added to the tags of every reverse card, so exams and
+// filters can pick one direction.
+const reverseTag = "reverse"
+
+// addReverseCards returns cards followed by the reverse of each card that
+// has one.
+func addReverseCards(cards []Card) ([]Card, error) {
+	ids := make(map[string]bool, len(cards))
+	for _, card := range cards {
+		ids[card.ID] = true
+	}
+	withReverse := append([]Card(nil), cards...)
+	for _, card := range cards {
+		if !reversible(card) {
+			continue
+		}
+		reverse := card
+		reverse.ID = card.ID + reverseSuffix
+		reverse.Prompt, reverse.Solution = card.Solution, card.Prompt
+		reverse.Tags = append(append([]string(nil), card.Tags...), reverseTag)
+		if ids[reverse.ID] {
+			return nil, fmt.Errorf("card '%s' already exists, so '%s' can't get a reverse card", reverse.ID, card.ID)
+		}
+		ids[reverse.ID] = true
+		withReverse = append(withReverse, reverse)
+	}
+	return withReverse, nil
+}
+
+// --- Helpers ---
+
+// reversible reports whether a card can be asked the other way round.
+func reversible(card Card) bool {
+	switch {
+	case card.Checker != "", card.Type != "":
+		return false
+	case card.Validation == ValidationRegex, card.Validation == ValidationNumeric:
+		return false
+	}
+	return card.Prompt != "" && card.Solution != ""
+}
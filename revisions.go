@@ -0,0 +1,337 @@
+// revisions.go
+//
+// Card revisions and soft deletes, for decks kept by several people. Every
+// change made with edit-card, delete-card or restore-card first saves the
+// card as it was to card-revisions.json, next to the deck in the config
+// directory so the history travels with it. Deleted cards aren't removed
+// from the deck: they get a "deleted_at" tombstone and are left out of the
+// game, their progress is kept, and restore-card brings them back as they
+// were. card-history lists a card's revisions and which fields each change
+// touched, and restore-card --revision puts an earlier version back.
+//
+// Only cards written out in cards.json have a history; cards generated from
+// templates, reverse cards and cards synced from notes are changed where
+// they come from.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Revision actions.
+const (
+	RevisionEdit    = "edit"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+)
+
+// CardRevision is a card as it was before a change.
+type CardRevision struct {
+	CardID string `json:"card_id"`
+	// Number counts the revisions of the card, from 1.
+	Number int    `json:"number"`
+	Action string `json:"action"`
+	// Card is the card before the change, as it was written in the deck.
+	Card   json.RawMessage `json:"card"`
+	Author string          `json:"author,omitempty"`
+	At     time.Time       `json:"at"`
+}
+
+// recordRevision saves the version of a card a change replaces and returns
+// its revision number.
+func recordRevision(cardID, action string, card json.RawMessage) int {
+	revisions := loadRevisions()
+	number := 1
+	for _, revision := range revisions {
+		if revision.CardID == cardID && revision.Number >= number {
+			number = revision.Number + 1
+		}
+	}
+	revisions = append(revisions, CardRevision{
+		CardID: cardID,
+		Number: number,
+		Action: action,
+		Card:   card,
+		Author: revisionAuthor(),
+		At:     time.Now(),
+	})
+	saveRevisions(revisions)
+	return number
+}
+
+// --- Command Handlers ---
+
+// handleEditCard changes the fields of a card given on the command line.
+func handleEditCard(fs *flag.FlagSet, cardID, prompt, solution, language, tags string) {
+	deck, i := readCardForChange(cardID)
+	card := decodeDeckCard(deck.cards[i])
+	if card.DeletedAt != nil {
+		fatalf("Card '%s' is deleted; bring it back with 'restore-card --id=%s' first.", cardID, cardID)
+	}
+	changed := false
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "prompt":
+			card.Prompt = prompt
+		case "solution":
+			card.Solution = solution
+		case "language":
+			card.Language = language
+		case "tags":
+			card.Tags = splitList(tags)
+		default:
+			return
+		}
+		changed = true
+	})
+	if !changed {
+		fatal("Nothing to change; pass --prompt, --solution, --language or --tags.")
+	}
+	if err := checkCardFields(card); err != nil {
+		fatalf("Card '%s' can't be saved: %v.", cardID, err)
+	}
+	updated := encodeDeckCard(card)
+	if len(changedFields(deck.cards[i], updated)) == 0 {
+		fmt.Printf(tr("Card %s already reads like that.\n"), cardID)
+		return
+	}
+	number := recordRevision(cardID, RevisionEdit, deck.cards[i])
+	deck.cards[i] = updated
+	deck.write()
+	fmt.Printf(tr("Updated card %s; the previous version is revision %d.\n"), cardID, number)
+}
+
+// handleDeleteCard leaves a tombstone in place of a card.
+func handleDeleteCard(cardID string) {
+	deck, i := readCardForChange(cardID)
+	card := decodeDeckCard(deck.cards[i])
+	if card.DeletedAt != nil {
+		fatalf("Card '%s' was already deleted on %s.", cardID, resolveLocale("").DateTime(*card.DeletedAt))
+	}
+	now := time.Now()
+	card.DeletedAt = &now
+	number := recordRevision(cardID, RevisionDelete, deck.cards[i])
+	deck.cards[i] = encodeDeckCard(card)
+	deck.write()
+	fmt.Printf(tr("Deleted card %s (revision %d). Its progress is kept; 'restore-card --id=%s' brings it back.\n"), cardID, number, cardID)
+}
+
+// handleRestoreCard undeletes a card, or with a revision number puts that
+// version of the card back.
+func handleRestoreCard(cardID string, number int) {
+	deck, i := readCardForChange(cardID)
+	current := decodeDeckCard(deck.cards[i])
+	var card Card
+	if number == 0 {
+		if current.DeletedAt == nil {
+			fatalf("Card '%s' isn't deleted; pass --revision to restore an earlier version.", cardID)
+		}
+		card = current
+	} else {
+		revision, ok := findRevision(cardID, number)
+		if !ok {
+			fatalf("Card '%s' has no revision %d; 'card-history --id=%s' lists them.", cardID, number, cardID)
+		}
+		card = decodeDeckCard(revision.Card)
+	}
+	card.DeletedAt = nil
+	restored := encodeDeckCard(card)
+	if len(changedFields(deck.cards[i], restored)) == 0 {
+		fmt.Printf(tr("Card %s already reads like revision %d.\n"), cardID, number)
+		return
+	}
+	saved := recordRevision(cardID, RevisionRestore, deck.cards[i])
+	deck.cards[i] = restored
+	deck.write()
+	if number == 0 {
+		fmt.Printf(tr("Restored card %s (revision %d).\n"), cardID, saved)
+	} else {
+		fmt.Printf(tr("Restored revision %d of card %s; the version it replaced is revision %d.\n"), number, cardID, saved)
+	}
+}
+
+func handleCardHistory(cardID string, number int, asJSON bool) {
+	var revisions []CardRevision
+	for _, revision := range loadRevisions() {
+		if revision.CardID == cardID {
+			revisions = append(revisions, revision)
+		}
+	}
+	sort.SliceStable(revisions, func(i, j int) bool { return revisions[i].Number < revisions[j].Number })
+
+	if number != 0 {
+		revision, ok := findRevision(cardID, number)
+		if !ok {
+			fatalf("Card '%s' has no revision %d.", cardID, number)
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, revision.Card, "", "  "); err != nil {
+			fatalf("Error reading revision: %v", err)
+		}
+		fmt.Println(out.String())
+		return
+	}
+	if asJSON {
+		if revisions == nil {
+			revisions = []CardRevision{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(revisions); err != nil {
+			fatalf("Error writing card history JSON: %v", err)
+		}
+		return
+	}
+
+	// The version after the last revision is the one in the deck now
+	var current json.RawMessage
+	if filePath := deckPath(); !isMarkdownDeck(filePath) && fileExists(filePath) {
+		deck := readRawDeck(filePath)
+		if i := deck.index(cardID); i >= 0 {
+			current = deck.cards[i]
+		}
+	}
+	if len(revisions) == 0 {
+		if current == nil {
+			fatalf("Card with ID '%s' not found in deck.", cardID)
+		}
+		fmt.Printf(tr("Card %s has no earlier versions.\n"), cardID)
+		return
+	}
+
+	loc := resolveLocale("")
+	fmt.Printf(tr("Revisions of card %s, oldest first:\n"), cardID)
+	for i, revision := range revisions {
+		next := current
+		if i+1 < len(revisions) {
+			next = revisions[i+1].Card
+		}
+		changes := "-"
+		if next != nil {
+			changes = strings.Join(changedFields(revision.Card, next), ", ")
+		}
+		author := revision.Author
+		if author == "" {
+			author = "-"
+		}
+		fmt.Printf("  %3d  %s  %-7s  %-12s  %s\n", revision.Number, loc.DateTime(revision.At), revision.Action, author, changes)
+	}
+	fmt.Printf(tr("\nShow a revision with 'card-history --id=%s --revision=<n>', put it back with 'restore-card --id=%s --revision=<n>'.\n"), cardID, cardID)
+}
+
+// --- Helpers ---
+
+// readCardForChange reads the deck file and finds the card to change in it.
+func readCardForChange(cardID string) (rawDeck, int) {
+	deck := readRawDeck(deckPath())
+	i := deck.index(cardID)
+	if i < 0 {
+		if _, ok := findCard(loadCards(), cardID); ok {
+			fatalf("Card '%s' is generated from a template, another card or your notes; change the card it comes from instead.", cardID)
+		}
+		fatalf("Card with ID '%s' not found in deck.", cardID)
+	}
+	return deck, i
+}
+
+// decodeDeckCard decodes a card written in the deck file.
+func decodeDeckCard(raw json.RawMessage) Card {
+	var card Card
+	if err := decodeStrict(bytes.NewReader(raw), &card); err != nil {
+		fatalf("Error reading card: %v", err)
+	}
+	return card
+}
+
+func encodeDeckCard(card Card) json.RawMessage {
+	raw, err := json.Marshal(card)
+	if err != nil {
+		fatalf("Error marshalling cards to JSON: %v", err)
+	}
+	return raw
+}
+
+// changedFields lists the fields that differ between two versions of a
+// card.
+func changedFields(before, after json.RawMessage) []string {
+	var a, b map[string]interface{}
+	json.Unmarshal(before, &a)
+	json.Unmarshal(after, &b)
+	var fields []string
+	for field, value := range a {
+		if !reflect.DeepEqual(value, b[field]) {
+			fields = append(fields, field)
+		}
+	}
+	for field := range b {
+		if _, ok := a[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func findRevision(cardID string, number int) (CardRevision, bool) {
+	for _, revision := range loadRevisions() {
+		if revision.CardID == cardID && revision.Number == number {
+			return revision, true
+		}
+	}
+	return CardRevision{}, false
+}
+
+// revisionAuthor names the person making a change: the user running the
+// program.
+func revisionAuthor() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
+
+func revisionsPath() string {
+	return filepath.Join(getConfigDir(), "card-revisions.json")
+}
+
+func loadRevisions() []CardRevision {
+	filePath := revisionsPath()
+	file, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		fatalf("Error reading card revisions (%s): %v", filePath, err)
+	}
+	var revisions []CardRevision
+	if len(file) == 0 {
+		return revisions
+	}
+	if err := json.Unmarshal(file, &revisions); err != nil {
+		fatalf("Error unmarshalling card revisions JSON: %v", err)
+	}
+	return revisions
+}
+
+func saveRevisions(revisions []CardRevision) {
+	filePath := revisionsPath()
+	data, err := json.MarshalIndent(revisions, "", "  ")
+	if err != nil {
+		fatalf("Error marshalling card revisions to JSON: %v", err)
+	}
+	if err := ioutil.WriteFile(filePath, append(data, '\n'), 0644); err != nil {
+		fatalf("Error writing card revisions (%s): %v", filePath, err)
+	}
+}
@@ -0,0 +1,439 @@
+// scheduler.go
+//
+// Card selection for get-card. A box is drawn by weight (by default box 1 is
+// reviewed sixteen times as often as box 5) and then a card uniformly from
+// that box. Boosted cards (see boost.go) are drawn more or less often than
+// the rest of their box, and in adaptive mode (see adaptive.go) the box
+// weights follow each player's accuracy.
+// Cards the player has just seen are held back for a few picks so the same
+// card isn't served twice in a row, and optionally runs of cards sharing a
+// tag are broken up (interleaved practice).
+
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// defaultBoxWeights are the relative chances of drawing from boxes 1 to 5
+// unless config.json says otherwise.
+var defaultBoxWeights = []int{16, 8, 4, 2, 1}
+
+// defaultRecentCards is how many recent picks are held back unless
+// config.json says otherwise.
+const defaultRecentCards = 3
+
+// defaultNewCardTarget is how many cards box 1 is filled up to with new
+// cards unless config.json says otherwise.
+const defaultNewCardTarget = 10
+
+// Orders in which new cards enter rotation.
+const (
+	NewCardOrderFile   = "" // default: as they appear in cards.json
+	NewCardOrderRandom = "random"
+	NewCardOrderTags   = "tags"
+	NewCardOrderField  = "order"
+)
+
+// SchedulerConfig is the "scheduler" block of config.json.
+type SchedulerConfig struct {
+	// RecentCards is the number of most recent picks that are not served
+	// again. Nil means the default; 0 turns the buffer off.
+	RecentCards *int `json:"recent_cards,omitempty"`
+	// MaxSameTag is the most consecutive cards sharing a tag that may be
+	// served; 0 turns interleaving off.
+	MaxSameTag int `json:"max_same_tag,omitempty"`
+	// NewCards controls how never-seen cards enter box 1.
+	NewCards NewCardsConfig `json:"new_cards,omitempty"`
+	// RetireAfter is the number of passes in a row in box 5 after which a
+	// card is retired. Nil means the default.
+	RetireAfter *int `json:"retire_after,omitempty"`
+	// BoxWeights are the relative chances of drawing from boxes 1 to 5.
+	BoxWeights []int `json:"box_weights,omitempty"`
+	// Adaptive adjusts the box weights to each player's accuracy.
+	Adaptive AdaptiveConfig `json:"adaptive,omitempty"`
+	// DueOnly serves only cards that are due, new cards included (see
+	// completion.go).
+	DueOnly bool `json:"due_only,omitempty"`
+	// Plugin names an external scheduler to use instead of the Leitner
+	// boxes, and PluginOptions is passed on to it as is.
+	Plugin        string          `json:"plugin,omitempty"`
+	PluginOptions json.RawMessage `json:"plugin_options,omitempty"`
+}
+
+// NewCardsConfig controls the introduction of new cards.
+type NewCardsConfig struct {
+	// Order is "" (file order), "random", "tags" or "order".
+	Order string `json:"order,omitempty"`
+	// TagPriority lists tags whose cards come first, in that order, when
+	// Order is "tags".
+	TagPriority []string `json:"tag_priority,omitempty"`
+	// Target is the number of cards box 1 is filled up to with new ones.
+	// Nil means the default; 0 introduces every card at once.
+	Target *int `json:"target,omitempty"`
+}
+
+// recentLimit returns the configured number of picks to hold back.
+func (c SchedulerConfig) recentLimit() int {
+	if c.RecentCards == nil {
+		return defaultRecentCards
+	}
+	return max(*c.RecentCards, 0)
+}
+
+// boxWeights returns the configured weights of boxes 1 to 5.
+func (c SchedulerConfig) boxWeights() []int {
+	if c.BoxWeights == nil {
+		return defaultBoxWeights
+	}
+	if len(c.BoxWeights) != topBox {
+		fatalf("scheduler.box_weights must have %d entries, one per box; got %d.", topBox, len(c.BoxWeights))
+	}
+	for _, weight := range c.BoxWeights {
+		if weight < 1 {
+			fatal("scheduler.box_weights must all be at least 1.")
+		}
+	}
+	return c.BoxWeights
+}
+
+// boxIntervalDays is the nominal number of days between reviews of a card
+// in box, as implied by the box weights: box 1 comes up daily, and a box
+// drawn half as often waits twice as long. With the default weights that
+// is 1, 2, 4, 8 and 16 days.
+func (c SchedulerConfig) boxIntervalDays(box int) int {
+	weights := c.boxWeights()
+	return max(int(math.Round(float64(weights[0])/float64(weights[box-1]))), 1)
+}
+
+// isDue reports whether a card has gone its box's interval without a
+// review, counted in calendar days.
+func isDue(progress CardProgress, config SchedulerConfig, now time.Time) bool {
+	if !inRotation(progress) {
+		return false
+	}
+	dueDay := calendarDay(progress.LastReviewed).AddDate(0, 0, config.boxIntervalDays(progress.Box))
+	return !calendarDay(now).Before(dueDay)
+}
+
+// historyLimit returns how many picks the recent-cards buffer must keep to
+// serve both the hold-back and the interleaving rule.
+func (c SchedulerConfig) historyLimit() int {
+	return max(c.recentLimit(), c.MaxSameTag)
+}
+
+// newCardsToIntroduce returns the never-seen cards that should enter box 1
+// now, so that box 1 holds the configured number of cards.
+func newCardsToIntroduce(cards []Card, player PlayerData, config NewCardsConfig, rng *rand.Rand) []Card {
+	var unseen []Card
+	inBoxOne := 0
+	for _, card := range cards {
+		progress, ok := player.Cards[card.ID]
+		if !ok {
+			unseen = append(unseen, card)
+		} else if progress.Box == 1 {
+			inBoxOne++
+		}
+	}
+
+	target := defaultNewCardTarget
+	if config.Target != nil {
+		target = *config.Target
+	}
+	if target <= 0 {
+		return unseen
+	}
+	if inBoxOne >= target || len(unseen) == 0 {
+		return nil
+	}
+
+	switch config.Order {
+	case NewCardOrderFile:
+	case NewCardOrderRandom:
+		rng.Shuffle(len(unseen), func(i, j int) { unseen[i], unseen[j] = unseen[j], unseen[i] })
+	case NewCardOrderTags:
+		rank := make(map[string]int, len(config.TagPriority))
+		for i, tag := range config.TagPriority {
+			rank[tag] = i
+		}
+		// Cards without a listed tag go last
+		tagRank := func(card Card) int {
+			best := len(config.TagPriority)
+			for _, tag := range card.Tags {
+				if r, ok := rank[tag]; ok && r < best {
+					best = r
+				}
+			}
+			return best
+		}
+		sort.SliceStable(unseen, func(i, j int) bool { return tagRank(unseen[i]) < tagRank(unseen[j]) })
+	case NewCardOrderField:
+		// Cards without an order go last
+		sort.SliceStable(unseen, func(i, j int) bool {
+			a, b := unseen[i].Order, unseen[j].Order
+			if a == nil || b == nil {
+				return a != nil
+			}
+			return *a < *b
+		})
+	default:
+		fatalf("Unknown new card order '%s', expected 'random', 'tags' or 'order'.", config.Order)
+	}
+	introduced := unseen[:min(target-inBoxOne, len(unseen))]
+	slog.Debug("Introducing new cards", "count", len(introduced), "unseen", len(unseen), "box_1", inBoxOne, "target", target, "order", config.Order)
+	return introduced
+}
+
+// candidatePool is what selectCard draws from.
+type candidatePool struct {
+	// all are the cards in rotation, boxes those that pass the rules.
+	all   []Card
+	boxes map[int][]Card
+	// held are the recent picks held back, streakTags the tags of the
+	// current run of cards sharing one.
+	held       map[string]bool
+	streakTags map[string]bool
+	// relaxed lists the rules that were dropped to find a card.
+	relaxed []string
+}
+
+// buildPool sorts the cards in rotation into boxes, leaving out the cards
+// the hold-back and interleaving rules hold back. The rules are relaxed,
+// interleaving first, when they would leave nothing to serve.
+func buildPool(cards []Card, player PlayerData, recent []string, config SchedulerConfig) candidatePool {
+	pool := candidatePool{held: make(map[string]bool), boxes: make(map[int][]Card)}
+	for _, id := range lastN(recent, config.recentLimit()) {
+		pool.held[id] = true
+	}
+	if config.MaxSameTag > 0 && len(recent) >= config.MaxSameTag {
+		pool.streakTags = sharedTags(cards, lastN(recent, config.MaxSameTag))
+	}
+
+	for _, card := range cards {
+		if inRotation(player.Cards[card.ID]) {
+			pool.all = append(pool.all, card)
+		}
+	}
+
+	rules := []func(Card) bool{
+		func(card Card) bool { return !pool.held[card.ID] && !hasAnyTag(card, pool.streakTags) },
+		func(card Card) bool { return !pool.held[card.ID] },
+		func(card Card) bool { return true },
+	}
+	for i, allowed := range rules {
+		for _, card := range pool.all {
+			if allowed(card) {
+				box := player.Cards[card.ID].Box
+				pool.boxes[box] = append(pool.boxes[box], card)
+			}
+		}
+		if len(pool.boxes) > 0 {
+			pool.relaxed = []string{"interleaving", "recent cards"}[:i]
+			if i > 0 {
+				slog.Debug("Relaxed scheduling rules to find a card", "dropped", pool.relaxed)
+			}
+			break
+		}
+	}
+	return pool
+}
+
+// Scheduler decides which card to serve next and what an answer does to a
+// card. The built-in Leitner boxes are one; others run as plugins (see
+// externalscheduler.go).
+type Scheduler interface {
+	// NextCard picks the next card for player from cards, the deck without
+	// the player's skipped cards, with the player's recent picks in
+	// player.RecentCards. The cards it brings into rotation are added to
+	// player.Cards and listed in the pick. It returns false when there is
+	// nothing to serve; reviewAhead asks for a card anyway.
+	NextCard(player PlayerData, cards []Card, reviewAhead bool, now time.Time, rng *rand.Rand) (Pick, bool, error)
+	// OnAnswer returns the card's progress after an answer given at now.
+	OnAnswer(player PlayerData, card Card, correct bool, now time.Time) (CardProgress, error)
+}
+
+// Pick is a card chosen by a Scheduler.
+type Pick struct {
+	Card Card
+	Box  int
+	// Introduced are the new cards the pick brought into rotation.
+	Introduced []Card
+	// Ahead is set for a card served for review ahead (see completion.go).
+	Ahead bool
+}
+
+// leitnerScheduler is the built-in scheduler: weighted boxes with the
+// rules described at the top of this file.
+type leitnerScheduler struct {
+	config SchedulerConfig
+}
+
+func (s leitnerScheduler) NextCard(player PlayerData, cards []Card, reviewAhead bool, now time.Time, rng *rand.Rand) (Pick, bool, error) {
+	var pick Pick
+	pick.Introduced = newCardsToIntroduce(cards, player, s.config.NewCards, rng)
+	for _, card := range pick.Introduced {
+		player.Cards[card.ID] = CardProgress{Box: 1, LastReviewed: now}
+	}
+	candidates := cards
+	if s.config.DueOnly {
+		candidates = dueCards(cards, player, s.config, now)
+	}
+	var ok bool
+	pick.Card, pick.Box, ok = selectCard(candidates, player, player.RecentCards, s.config, now, rng)
+	if !ok && reviewAhead {
+		pick.Card, pick.Box, ok = reviewAheadCard(cards, player, player.RecentCards, s.config, now, rng)
+		pick.Ahead = ok
+	}
+	return pick, ok, nil
+}
+
+func (s leitnerScheduler) OnAnswer(player PlayerData, card Card, correct bool, now time.Time) (CardProgress, error) {
+	return applyAnswer(player.Cards[card.ID], correct, s.config.retireAfter()), nil
+}
+
+// candidates returns the cards a pick was drawn from, for explaining it.
+func (s leitnerScheduler) candidates(cards []Card, player PlayerData, pick Pick, now time.Time) []Card {
+	if s.config.DueOnly && !pick.Ahead {
+		return dueCards(cards, player, s.config, now)
+	}
+	return cards
+}
+
+// selectCard draws the next card for player from the boxes 1 to 5, given the
+// recent picks (newest last), from the pool buildPool makes, with the boosts
+// active at now. It returns false when every card has been retired.
+func selectCard(cards []Card, player PlayerData, recent []string, config SchedulerConfig, now time.Time, rng *rand.Rand) (Card, int, bool) {
+	pool := buildPool(cards, player, recent, config)
+	all, boxes, held, streakTags := pool.all, pool.boxes, pool.held, pool.streakTags
+	if len(all) == 0 {
+		return Card{}, 0, false
+	}
+
+	weights := config.weightsFor(player)
+	if multipliers := boostMultipliers(all, player, now); len(multipliers) > 0 {
+		chosen, chosenBox := boostedPick(boxes, weights, multipliers, rng)
+		slog.Debug("Selected card", "card", chosen.ID, "box", chosenBox, "candidates", len(boxes[chosenBox]),
+			"in_rotation", len(all), "held_back", len(held), "streak_tags", len(streakTags), "boost", multipliers[chosen.ID])
+		return chosen, chosenBox, true
+	}
+	totalWeight := 0
+	for box := range boxes {
+		totalWeight += weights[box-1]
+	}
+	r := rng.Intn(totalWeight)
+	chosenBox := 0
+	for box := 1; box <= 5; box++ {
+		if len(boxes[box]) == 0 {
+			continue
+		}
+		if r < weights[box-1] {
+			chosenBox = box
+			break
+		}
+		r -= weights[box-1]
+	}
+	chosen := boxes[chosenBox][rng.Intn(len(boxes[chosenBox]))]
+	slog.Debug("Selected card", "card", chosen.ID, "box", chosenBox, "candidates", len(boxes[chosenBox]),
+		"in_rotation", len(all), "held_back", len(held), "streak_tags", len(streakTags))
+	return chosen, chosenBox, true
+}
+
+// pickChance returns the chance that selectCard draws the card with the
+// given ID, leaving out the hold-back and interleaving rules, which only
+// shift picks around for a moment. Boosts active at now are included. It is
+// 0 for cards out of rotation.
+func pickChance(cards []Card, player PlayerData, config SchedulerConfig, now time.Time, cardID string) float64 {
+	progress, ok := player.Cards[cardID]
+	if !ok || !inRotation(progress) {
+		return 0
+	}
+	boxes := make(map[int][]Card)
+	for _, card := range cards {
+		if p, ok := player.Cards[card.ID]; ok && inRotation(p) {
+			boxes[p.Box] = append(boxes[p.Box], card)
+		}
+	}
+	if len(boxes[progress.Box]) == 0 {
+		return 0
+	}
+	// A card's weight is its box's weight shared among the box's cards,
+	// times any boost; without boosts this is the draw selectCard makes
+	weights := config.weightsFor(player)
+	multipliers := boostMultipliers(cards, player, now)
+	totalWeight, cardWeight := 0.0, 0.0
+	for box, inBox := range boxes {
+		for _, card := range inBox {
+			weight := boostedWeight(weights[box-1], len(inBox), multipliers, card.ID)
+			totalWeight += weight
+			if card.ID == cardID {
+				cardWeight = weight
+			}
+		}
+	}
+	return cardWeight / totalWeight
+}
+
+// lastN returns the last n entries of ids.
+func lastN(ids []string, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	if len(ids) > n {
+		return ids[len(ids)-n:]
+	}
+	return ids
+}
+
+// sharedTags returns the tags every one of the picks has.
+func sharedTags(cards []Card, picks []string) map[string]bool {
+	if len(picks) == 0 {
+		return nil
+	}
+	tagsByID := make(map[string][]string, len(cards))
+	for _, card := range cards {
+		tagsByID[card.ID] = card.Tags
+	}
+	shared := make(map[string]bool)
+	for _, tag := range tagsByID[picks[0]] {
+		shared[tag] = true
+	}
+	for _, id := range picks[1:] {
+		tags := make(map[string]bool)
+		for _, tag := range tagsByID[id] {
+			tags[tag] = true
+		}
+		for tag := range shared {
+			if !tags[tag] {
+				delete(shared, tag)
+			}
+		}
+	}
+	return shared
+}
+
+func hasAnyTag(card Card, tags map[string]bool) bool {
+	for _, tag := range card.Tags {
+		if tags[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+// rememberPick appends cardID to the recent-cards buffer, keeping at most
+// limit entries.
+func rememberPick(recent []string, cardID string, limit int) []string {
+	if limit <= 0 {
+		return nil
+	}
+	recent = append(recent, cardID)
+	if len(recent) > limit {
+		recent = append([]string(nil), recent[len(recent)-limit:]...)
+	}
+	return recent
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testNow is the clock of the engine tests.
+var testNow = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+// boxPlayer returns a player with one card in each of boxes 1 to 5, and the
+// deck of those cards.
+func boxPlayer() (PlayerData, []Card) {
+	player := PlayerData{Name: "test", Cards: make(map[string]CardProgress)}
+	var cards []Card
+	for box := 1; box <= topBox; box++ {
+		id := string(rune('a' + box - 1))
+		cards = append(cards, Card{ID: id})
+		player.Cards[id] = CardProgress{Box: box, LastReviewed: testNow}
+	}
+	return player, cards
+}
+
+// intPtr returns a pointer to n, for the optional config fields.
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestSelectCardIsReproducible(t *testing.T) {
+	player, cards := boxPlayer()
+	config := SchedulerConfig{RecentCards: intPtr(0)}
+	draw := func(seed int64) []string {
+		rng := newRand(seed)
+		var picks []string
+		for i := 0; i < 50; i++ {
+			card, _, ok := selectCard(cards, player, nil, config, testNow, rng)
+			if !ok {
+				t.Fatalf("selectCard found nothing to serve")
+			}
+			picks = append(picks, card.ID)
+		}
+		return picks
+	}
+	if a, b := draw(7), draw(7); !reflect.DeepEqual(a, b) {
+		t.Errorf("two draws with seed 7 differ:\n%v\n%v", a, b)
+	}
+	if a, b := draw(7), draw(8); reflect.DeepEqual(a, b) {
+		t.Errorf("draws with seeds 7 and 8 are the same: %v", a)
+	}
+}
+
+func TestSelectCardFollowsBoxWeights(t *testing.T) {
+	tests := []struct {
+		name   string
+		config SchedulerConfig
+		want   []int
+	}{
+		{"default weights", SchedulerConfig{RecentCards: intPtr(0)}, defaultBoxWeights},
+		{"configured weights", SchedulerConfig{RecentCards: intPtr(0), BoxWeights: []int{1, 1, 1, 1, 4}}, []int{1, 1, 1, 1, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player, cards := boxPlayer()
+			rng := newRand(1)
+			const draws = 40000
+			counts := make([]int, topBox)
+			for i := 0; i < draws; i++ {
+				_, box, _ := selectCard(cards, player, nil, tt.config, testNow, rng)
+				counts[box-1]++
+			}
+			total := 0
+			for _, weight := range tt.want {
+				total += weight
+			}
+			for box, weight := range tt.want {
+				want := float64(weight) / float64(total)
+				if got := float64(counts[box]) / draws; math.Abs(got-want) > 0.01 {
+					t.Errorf("box %d drawn %.3f of the time, want %.3f", box+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectCardSkipsCardsOutOfRotation(t *testing.T) {
+	tests := []struct {
+		name   string
+		cards  map[string]CardProgress
+		wantOK bool
+		want   string
+	}{
+		{"nothing seen", map[string]CardProgress{}, false, ""},
+		{"all retired", map[string]CardProgress{"a": {Box: topBox, Retired: true}, "b": {Box: topBox, Retired: true}}, false, ""},
+		{"one left", map[string]CardProgress{"a": {Box: topBox, Retired: true}, "b": {Box: 3}}, true, "b"},
+		{"box out of range", map[string]CardProgress{"a": {Box: 0}, "b": {Box: 2}}, true, "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player := PlayerData{Cards: tt.cards}
+			rng := newRand(1)
+			for i := 0; i < 20; i++ {
+				card, _, ok := selectCard([]Card{{ID: "a"}, {ID: "b"}}, player, nil, SchedulerConfig{}, testNow, rng)
+				if ok != tt.wantOK || card.ID != tt.want {
+					t.Fatalf("selectCard = %q, %v; want %q, %v", card.ID, ok, tt.want, tt.wantOK)
+				}
+			}
+		})
+	}
+}
+
+func TestPickChance(t *testing.T) {
+	player, cards := boxPlayer()
+	config := SchedulerConfig{}
+	total := 0.0
+	for _, card := range cards {
+		total += pickChance(cards, player, config, testNow, card.ID)
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("chances add up to %v, want 1", total)
+	}
+	if got, want := pickChance(cards, player, config, testNow, "a"), 16.0/31; math.Abs(got-want) > 1e-9 {
+		t.Errorf("chance of the box 1 card = %v, want %v", got, want)
+	}
+	if got := pickChance(cards, player, config, testNow, "missing"); got != 0 {
+		t.Errorf("chance of an unseen card = %v, want 0", got)
+	}
+}
+
+func TestSelectCardHoldsBackRecentCards(t *testing.T) {
+	cards := []Card{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+	player := PlayerData{Cards: map[string]CardProgress{"a": {Box: 1}, "b": {Box: 1}, "c": {Box: 1}, "d": {Box: 1}}}
+	tests := []struct {
+		name     string
+		config   SchedulerConfig
+		recent   []string
+		possible []string
+	}{
+		{"default holds back three", SchedulerConfig{}, []string{"a", "b", "c"}, []string{"d"}},
+		{"only the newest picks count", SchedulerConfig{RecentCards: intPtr(2)}, []string{"a", "b", "c"}, []string{"a", "d"}},
+		{"turned off", SchedulerConfig{RecentCards: intPtr(0)}, []string{"a", "b", "c"}, []string{"a", "b", "c", "d"}},
+		{"relaxed when everything is recent", SchedulerConfig{RecentCards: intPtr(10)}, []string{"a", "b", "c", "d"}, []string{"a", "b", "c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := drawnCards(t, cards, player, tt.recent, tt.config); !reflect.DeepEqual(got, tt.possible) {
+				t.Errorf("drawn cards = %v, want %v", got, tt.possible)
+			}
+		})
+	}
+}
+
+func TestRememberPick(t *testing.T) {
+	recent := []string(nil)
+	for _, id := range []string{"a", "b", "c", "d"} {
+		recent = rememberPick(recent, id, 3)
+	}
+	if want := []string{"b", "c", "d"}; !reflect.DeepEqual(recent, want) {
+		t.Errorf("recent = %v, want %v", recent, want)
+	}
+	if got := rememberPick(recent, "e", 0); got != nil {
+		t.Errorf("recent with the buffer off = %v, want nil", got)
+	}
+}
+
+// drawnCards returns the sorted IDs of the cards selectCard serves in many
+// draws with the same recent picks.
+func drawnCards(t *testing.T, cards []Card, player PlayerData, recent []string, config SchedulerConfig) []string {
+	t.Helper()
+	rng := newRand(1)
+	seen := make(map[string]bool)
+	for i := 0; i < 200; i++ {
+		card, _, ok := selectCard(cards, player, recent, config, testNow, rng)
+		if !ok {
+			t.Fatalf("selectCard found nothing to serve")
+		}
+		seen[card.ID] = true
+	}
+	return sortedKeys(seen)
+}
+
+func TestSelectCardInterleavesTags(t *testing.T) {
+	cards := []Card{
+		{ID: "v1", Tags: []string{"verbs"}},
+		{ID: "v2", Tags: []string{"verbs"}},
+		{ID: "v3", Tags: []string{"verbs", "a1"}},
+		{ID: "n1", Tags: []string{"nouns"}},
+		{ID: "n2", Tags: []string{"nouns", "a1"}},
+	}
+	player := PlayerData{Cards: make(map[string]CardProgress)}
+	for _, card := range cards {
+		player.Cards[card.ID] = CardProgress{Box: 1}
+	}
+	tests := []struct {
+		name     string
+		config   SchedulerConfig
+		recent   []string
+		possible []string
+	}{
+		{"run of a tag is broken", SchedulerConfig{RecentCards: intPtr(0), MaxSameTag: 2}, []string{"v1", "v2"}, []string{"n1", "n2"}},
+		{"run shorter than the limit", SchedulerConfig{RecentCards: intPtr(0), MaxSameTag: 3}, []string{"v1", "v2"}, []string{"n1", "n2", "v1", "v2", "v3"}},
+		{"every shared tag counts", SchedulerConfig{RecentCards: intPtr(0), MaxSameTag: 2}, []string{"v3", "n2"}, []string{"n1", "v1", "v2"}},
+		{"no shared tag", SchedulerConfig{RecentCards: intPtr(0), MaxSameTag: 2}, []string{"v1", "n1"}, []string{"n1", "n2", "v1", "v2", "v3"}},
+		{"with hold-back", SchedulerConfig{RecentCards: intPtr(2), MaxSameTag: 2}, []string{"n1", "n2"}, []string{"v1", "v2", "v3"}},
+		{"turned off", SchedulerConfig{RecentCards: intPtr(0)}, []string{"v1", "v2"}, []string{"n1", "n2", "v1", "v2", "v3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := drawnCards(t, cards, player, tt.recent, tt.config); !reflect.DeepEqual(got, tt.possible) {
+				t.Errorf("drawn cards = %v, want %v", got, tt.possible)
+			}
+		})
+	}
+}
+
+func TestSelectCardRelaxesInterleaving(t *testing.T) {
+	cards := []Card{{ID: "v1", Tags: []string{"verbs"}}, {ID: "v2", Tags: []string{"verbs"}}, {ID: "v3", Tags: []string{"verbs"}}}
+	player := PlayerData{Cards: map[string]CardProgress{"v1": {Box: 1}, "v2": {Box: 1}, "v3": {Box: 1}}}
+	config := SchedulerConfig{RecentCards: intPtr(1), MaxSameTag: 2}
+	// Only verbs are left: interleaving gives way, the hold-back doesn't
+	if got, want := drawnCards(t, cards, player, []string{"v1", "v2"}, config), []string{"v1", "v3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("drawn cards = %v, want %v", got, want)
+	}
+}
+
+func TestNewCardsToIntroduce(t *testing.T) {
+	cards := []Card{
+		{ID: "c1", Tags: []string{"b2"}, Order: intPtr(3)},
+		{ID: "c2", Tags: []string{"a1"}},
+		{ID: "c3", Tags: []string{"a2"}, Order: intPtr(1)},
+		{ID: "c4", Tags: []string{"a1"}, Order: intPtr(2)},
+		{ID: "c5"},
+	}
+	tests := []struct {
+		name   string
+		seen   map[string]CardProgress
+		config NewCardsConfig
+		want   []string
+	}{
+		{"file order", nil, NewCardsConfig{Target: intPtr(2)}, []string{"c1", "c2"}},
+		{"by tag priority", nil, NewCardsConfig{Order: NewCardOrderTags, TagPriority: []string{"a1", "a2"}, Target: intPtr(4)}, []string{"c2", "c4", "c3", "c1"}},
+		{"by order field", nil, NewCardsConfig{Order: NewCardOrderField, Target: intPtr(5)}, []string{"c3", "c4", "c1", "c2", "c5"}},
+		{"box 1 tops up to the target", map[string]CardProgress{"c1": {Box: 1}, "c2": {Box: 3}}, NewCardsConfig{Target: intPtr(2)}, []string{"c3"}},
+		{"box 1 is full", map[string]CardProgress{"c1": {Box: 1}, "c2": {Box: 1}}, NewCardsConfig{Target: intPtr(2)}, nil},
+		{"target 0 introduces everything", map[string]CardProgress{"c1": {Box: 1}}, NewCardsConfig{Target: intPtr(0)}, []string{"c2", "c3", "c4", "c5"}},
+		{"default target", nil, NewCardsConfig{}, []string{"c1", "c2", "c3", "c4", "c5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player := PlayerData{Cards: tt.seen}
+			var got []string
+			for _, card := range newCardsToIntroduce(cards, player, tt.config, newRand(1)) {
+				got = append(got, card.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("introduced %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCardsToIntroduceRandomOrder(t *testing.T) {
+	cards := syntheticCards(20)
+	config := NewCardsConfig{Order: NewCardOrderRandom, Target: intPtr(5)}
+	ids := func(seed int64) []string {
+		var ids []string
+		for _, card := range newCardsToIntroduce(cards, PlayerData{}, config, newRand(seed)) {
+			ids = append(ids, card.ID)
+		}
+		return ids
+	}
+	first := ids(1)
+	if len(first) != 5 {
+		t.Fatalf("introduced %d cards, want 5", len(first))
+	}
+	if again := ids(1); !reflect.DeepEqual(first, again) {
+		t.Errorf("seed 1 introduced %v, then %v", first, again)
+	}
+	if inFileOrder := []string{"sim-1", "sim-2", "sim-3", "sim-4", "sim-5"}; reflect.DeepEqual(first, inFileOrder) {
+		t.Errorf("random order introduced the cards in file order")
+	}
+}
@@ -0,0 +1,235 @@
+// schema.go
+//
+// JSON Schemas and strict decoding. The schemas in schema/ describe the
+// deck file and the request bodies of the API; they are embedded, printed
+// by the schema command and served at /api/schemas/<name>, so editors can
+// check a deck while it is written:
+//
+//	{ "$schema": "https://example.org/deck.schema.json", "cards": [ ... ] }
+//
+// Decks and API requests are decoded strictly to the same rules: unknown
+// fields, values of the wrong type and cards without an id, prompt or
+// solution are errors naming the card and field, instead of being dropped
+// or read as zero values.
+
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+//go:embed schema/*.json
+var schemaFiles embed.FS
+
+// schemaNames lists the embedded schemas.
+func schemaNames() []string {
+	entries, err := schemaFiles.ReadDir("schema")
+	if err != nil {
+		fatalf("Error reading schemas: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".schema.json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readSchema returns the schema with the given name.
+func readSchema(name string) ([]byte, bool) {
+	data, err := schemaFiles.ReadFile(path.Join("schema", name+".schema.json"))
+	return data, err == nil
+}
+
+// decodeStrict decodes a single JSON value into v, rejecting unknown fields
+// and trailing data.
+func decodeStrict(r io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return describeJSONError(err, v)
+	}
+	if decoder.More() {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
+
+// decodeDeckStrict decodes a migrated deck document card by card, so that
+// errors name the card they are in.
+func decodeDeckStrict(doc interface{}) (Deck, error) {
+	// The raw cards shadow the decoded ones
+	var file struct {
+		Deck
+		Cards []json.RawMessage `json:"cards"`
+	}
+	if err := decodeStrict(bytes.NewReader(remarshal(doc)), &file); err != nil {
+		return Deck{}, err
+	}
+	deck := file.Deck
+	deck.Cards = make([]Card, len(file.Cards))
+	for i, raw := range file.Cards {
+		card := &deck.Cards[i]
+		err := decodeStrict(bytes.NewReader(raw), card)
+		if err == nil {
+			err = checkCardFields(*card)
+		}
+		if err != nil {
+			return Deck{}, fmt.Errorf("%s: %w", cardLabel(i, raw), err)
+		}
+	}
+	return deck, nil
+}
+
+// checkCardFields reports fields a card can't do without. Card types render
+// and grade cards themselves, and checkers and regex cards may grade without
+// a solution.
+func checkCardFields(card Card) error {
+	switch {
+	case strings.TrimSpace(card.ID) == "":
+		return errors.New(`"id" is missing`)
+	case card.Type != "":
+		return nil
+	case strings.TrimSpace(card.Prompt) == "":
+		return errors.New(`"prompt" is missing`)
+	case card.Solution == "" && card.Checker == "" && card.Pattern == "":
+		return errors.New(`"solution" is missing`)
+	}
+	return nil
+}
+
+// --- Command Handlers ---
+
+func handleSchema(name string) {
+	if name == "" {
+		fmt.Println(tr("Schemas (print one with 'schema --name=<name>'):"))
+		for _, name := range schemaNames() {
+			fmt.Printf("  %s\n", name)
+		}
+		return
+	}
+	data, ok := readSchema(name)
+	if !ok {
+		fatalf("Unknown schema '%s', expected one of: %s.", name, strings.Join(schemaNames(), ", "))
+	}
+	os.Stdout.Write(data)
+}
+
+func serveSchema(w http.ResponseWriter, r *http.Request) {
+	data, ok := readSchema(r.PathValue("name"))
+	if !ok {
+		http.Error(w, fmt.Sprintf("Unknown schema, expected one of: %s.", strings.Join(schemaNames(), ", ")), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(data)
+}
+
+// --- Helpers ---
+
+// cardLabel names a card in an error by its index and, if it has one, id.
+func cardLabel(index int, raw json.RawMessage) string {
+	var card struct {
+		ID interface{} `json:"id"`
+	}
+	if json.Unmarshal(raw, &card) == nil {
+		if id, ok := card.ID.(string); ok && id != "" {
+			return fmt.Sprintf("cards[%d] (%q)", index, id)
+		}
+	}
+	return fmt.Sprintf("cards[%d]", index)
+}
+
+// describeJSONError rewrites the errors of encoding/json in terms of the
+// JSON document rather than the Go types it is decoded into.
+func describeJSONError(err error, v interface{}) error {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return fmt.Errorf("expected %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value)
+		}
+		return fmt.Errorf("%q must be %s, not %s", typeErr.Field, jsonTypeName(typeErr.Type), typeErr.Value)
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("invalid JSON at byte %d: %v", syntaxErr.Offset, err)
+	case errors.Is(err, io.EOF):
+		return errors.New("no JSON value")
+	}
+	field, ok := strings.CutPrefix(err.Error(), "json: unknown field ")
+	if !ok {
+		return err
+	}
+	field = strings.Trim(field, `"`)
+	if suggestion := closestField(field, reflect.TypeOf(v)); suggestion != "" {
+		return fmt.Errorf("unknown field %q (did you mean %q?)", field, suggestion)
+	}
+	return fmt.Errorf("unknown field %q", field)
+}
+
+// jsonSyntaxError gives the line and column of a syntax error in data.
+func jsonSyntaxError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return err
+	}
+	before := data[:min(int(syntaxErr.Offset), len(data))]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n') - 1
+	return fmt.Errorf("invalid JSON at line %d, column %d: %v", line, column, err)
+}
+
+// jsonTypeName describes a Go type as the JSON value it is decoded from.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a whole number"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "a list of " + strings.TrimPrefix(strings.TrimPrefix(jsonTypeName(t.Elem()), "a "), "an ") + "s"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return "a JSON value"
+}
+
+// closestField returns the field of the struct t that name is most likely
+// a typo of, or "" if none is close.
+func closestField(name string, t reflect.Type) string {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return ""
+	}
+	best, bestDistance := "", len(name)/3+1
+	for _, field := range reflect.VisibleFields(t) {
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		if d := editDistance([]rune(strings.ToLower(name)), []rune(tag)); d < bestDistance {
+			best, bestDistance = tag, d
+		}
+	}
+	return best
+}
@@ -0,0 +1,142 @@
+// search.go
+//
+// Full-text search over the deck for search-cards. Prompts, solutions,
+// tags and players' notes are matched ignoring case and diacritics, so
+// "meteo" finds "météo" and "STRASSE" finds "Straße".
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// searchKey folds s for matching: compatibility forms and case are folded
+// and combining marks dropped.
+func searchKey(s string) string {
+	folded := cases.Fold().String(norm.NFKC.String(s))
+	stripped, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), folded)
+	if err != nil {
+		return folded
+	}
+	return stripped
+}
+
+// SearchMatch is a card found by search-cards and the fields it matched in.
+type SearchMatch struct {
+	Card   Card
+	Fields []string
+}
+
+// searchCards returns the cards where every word of query occurs in the
+// prompt, solution, tags or one of the given notes.
+func searchCards(cards []Card, query string, notes map[string][]string) []SearchMatch {
+	words := strings.Fields(searchKey(query))
+	if len(words) == 0 {
+		return nil
+	}
+	var matches []SearchMatch
+	for _, card := range cards {
+		fields := map[string]string{
+			"prompt":   searchKey(card.Prompt),
+			"solution": searchKey(card.Solution),
+			"tags":     searchKey(strings.Join(card.Tags, " ")),
+			"note":     searchKey(strings.Join(notes[card.ID], "\n")),
+		}
+		matched := make(map[string]bool)
+		all := true
+		for _, word := range words {
+			found := false
+			for name, text := range fields {
+				if strings.Contains(text, word) {
+					matched[name] = true
+					found = true
+				}
+			}
+			all = all && found
+		}
+		if !all {
+			continue
+		}
+		match := SearchMatch{Card: card}
+		for _, name := range []string{"prompt", "solution", "tags", "note"} {
+			if matched[name] {
+				match.Fields = append(match.Fields, name)
+			}
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// --- Command Handlers ---
+
+func handleSearchCards(query, playerID string) {
+	allProgress := loadAllProgress()
+	var ids []string
+	for id := range allProgress {
+		if playerID == "" || id == playerID {
+			ids = append(ids, id)
+		}
+	}
+	if playerID != "" && len(ids) == 0 {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return allProgress[ids[i]].Name < allProgress[ids[j]].Name })
+
+	notes := make(map[string][]string)
+	for _, id := range ids {
+		for cardID, note := range allProgress[id].Notes {
+			notes[cardID] = append(notes[cardID], note)
+		}
+	}
+
+	matches := searchCards(loadCards(), query, notes)
+	if len(matches) == 0 {
+		fmt.Printf(tr("No cards match %q.\n"), query)
+		return
+	}
+	for i, match := range matches {
+		card := match.Card
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf(tr("%s [%s] matched in %s\n"), card.ID, card.Language, strings.Join(match.Fields, ", "))
+		fmt.Printf(tr("  Prompt:   %s\n"), firstLine(card.Prompt))
+		fmt.Printf(tr("  Solution: %s\n"), firstLine(card.Solution))
+		if len(card.Tags) > 0 {
+			fmt.Printf(tr("  Tags:     %s\n"), strings.Join(card.Tags, ", "))
+		}
+		for _, id := range ids {
+			player := allProgress[id]
+			if note, ok := player.Notes[card.ID]; ok {
+				fmt.Printf(tr("  Note (%s): %s\n"), player.Name, firstLine(note))
+			}
+			fmt.Printf("  %s: %s\n", player.Name, describeProgress(player, card.ID))
+		}
+	}
+	fmt.Printf(tr("\n%d card(s) found.\n"), len(matches))
+}
+
+// describeProgress summarizes a player's progress on a card in a few words.
+func describeProgress(player PlayerData, cardID string) string {
+	if _, skipped := player.Skipped[cardID]; skipped {
+		return "skipped"
+	}
+	progress, ok := player.Cards[cardID]
+	if !ok {
+		return "not seen yet"
+	}
+	state := fmt.Sprintf("box %d", progress.Box)
+	if progress.Retired {
+		state = "retired"
+	}
+	return fmt.Sprintf("%s, %d of %d correct", state, progress.Passed, progress.Passed+progress.Failed)
+}
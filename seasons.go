@@ -0,0 +1,185 @@
+// seasons.go
+//
+// Seasonal events: time-boxed bonuses (an exam season sprint, a summer
+// streak challenge) configured in seasonal-events.json. While an event runs,
+// correct answers earn extra XP, and its special achievement is awarded as
+// soon as a player meets the requirements. Everything is evaluated during
+// check-answer.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// baseXP is what a correct answer earns outside of any event.
+const baseXP = 10
+
+// SeasonalEvent is one entry of seasonal-events.json. Start and End are
+// inclusive calendar dates (YYYY-MM-DD) in local time.
+type SeasonalEvent struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Start        string            `json:"start"`
+	End          string            `json:"end"`
+	XPMultiplier float64           `json:"xp_multiplier,omitempty"`
+	Achievement  *EventAchievement `json:"achievement,omitempty"`
+}
+
+// EventAchievement is awarded once a player reaches every non-zero goal
+// while the event is running.
+type EventAchievement struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// CorrectAnswers counts correct answers given during the event.
+	CorrectAnswers int `json:"correct_answers,omitempty"`
+	// DailyStreak is the current daily streak required.
+	DailyStreak int `json:"daily_streak,omitempty"`
+}
+
+// Achievement is an achievement a player has earned.
+type Achievement struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	EarnedAt time.Time `json:"earned_at"`
+}
+
+// --- Event Evaluation ---
+
+// applySeasonalEvents awards XP for an answer given at now, plus any event
+// achievements the player has just earned. It returns the XP gained and the
+// names of new achievements.
+func applySeasonalEvents(player *PlayerData, correct bool, now time.Time) (int, []string) {
+	events := activeSeasonalEvents(loadSeasonalEvents(), now)
+
+	xp := 0
+	if correct {
+		multiplier := 1.0
+		for _, event := range events {
+			if event.XPMultiplier > multiplier {
+				multiplier = event.XPMultiplier
+			}
+		}
+		xp = int(float64(baseXP)*multiplier + 0.5)
+		player.XP += xp
+	}
+
+	var earned []string
+	for _, event := range events {
+		goal := event.Achievement
+		if goal == nil || hasAchievement(*player, goal.ID) {
+			continue
+		}
+		start, end := eventBounds(event)
+		if goal.CorrectAnswers > 0 {
+			count := 0
+			for _, item := range player.History {
+				if item.Correct && !item.Timestamp.Before(start) && item.Timestamp.Before(end) {
+					count++
+				}
+			}
+			if count < goal.CorrectAnswers {
+				continue
+			}
+		}
+		if goal.DailyStreak > 0 {
+			if current, _ := dailyStreaks(player.History, now); current < goal.DailyStreak {
+				continue
+			}
+		}
+		player.Achievements = append(player.Achievements, Achievement{ID: goal.ID, Name: goal.Name, EarnedAt: now})
+		earned = append(earned, goal.Name)
+	}
+	return xp, earned
+}
+
+func activeSeasonalEvents(events []SeasonalEvent, now time.Time) []SeasonalEvent {
+	var active []SeasonalEvent
+	for _, event := range events {
+		start, end := eventBounds(event)
+		if !now.Before(start) && now.Before(end) {
+			active = append(active, event)
+		}
+	}
+	return active
+}
+
+// eventBounds returns the first instant of the start date and the first
+// instant after the end date.
+func eventBounds(event SeasonalEvent) (time.Time, time.Time) {
+	start, err := time.ParseInLocation("2006-01-02", event.Start, time.Local)
+	if err != nil {
+		fatalf("Seasonal event '%s' has an invalid start date: %v", event.ID, err)
+	}
+	end, err := time.ParseInLocation("2006-01-02", event.End, time.Local)
+	if err != nil {
+		fatalf("Seasonal event '%s' has an invalid end date: %v", event.ID, err)
+	}
+	return start, end.AddDate(0, 0, 1)
+}
+
+func hasAchievement(player PlayerData, id string) bool {
+	for _, achievement := range player.Achievements {
+		if achievement.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Command Handlers ---
+
+func handleSeasons() {
+	events := loadSeasonalEvents()
+	if len(events) == 0 {
+		fmt.Println(tr("No seasonal events configured. Add them to seasonal-events.json in the config directory."))
+		return
+	}
+	loc := resolveLocale("")
+	now := time.Now()
+	for _, event := range events {
+		start, end := eventBounds(event)
+		status := "upcoming"
+		switch {
+		case !now.Before(end):
+			status = "finished"
+		case !now.Before(start):
+			status = "active"
+		}
+		fmt.Printf("%-8s %s (%s - %s)", status, event.Name, loc.Date(start), loc.Date(end.AddDate(0, 0, -1)))
+		if event.XPMultiplier > 1 {
+			fmt.Printf(tr("  x%s XP"), loc.Float(event.XPMultiplier, 1))
+		}
+		if event.Achievement != nil {
+			fmt.Printf(tr("  achievement: %s"), event.Achievement.Name)
+		}
+		fmt.Println()
+	}
+}
+
+// --- File I/O ---
+
+func loadSeasonalEvents() []SeasonalEvent {
+	filePath := filepath.Join(getConfigDir(), "seasonal-events.json")
+	file, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		fatalf("Error reading seasonal events (%s): %v", filePath, err)
+	}
+	var events []SeasonalEvent
+	if len(file) == 0 {
+		return events
+	}
+	if err := json.Unmarshal(file, &events); err != nil {
+		fatalf("Error unmarshalling seasonal events JSON: %v", err)
+	}
+	return events
+}
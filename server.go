@@ -0,0 +1,271 @@
+// server.go
+//
+// The `serve` command: a small HTTP server for things that want to watch a
+// player live: an overlay page meant to be added as an OBS browser source by
+// language-learning streamers, and Prometheus metrics for self-hosters. It
+// also offers get-card, check-answer and player management over JSON, for
+// frontends other than the Neovim plugin; see auth.go for who may call what
+// and cache.go for how state is kept between requests.
+
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+//go:embed overlay.html
+var overlayPage []byte
+
+// sessionGap is the pause after which a new study session is assumed to
+// have started.
+const sessionGap = 30 * time.Minute
+
+// progressLock serializes every change to progress in serve mode: the
+// requests that read, change and save it, and the reloads of changes made
+// by other commands (see cache.go), so that neither overwrites the other.
+var progressLock sync.Mutex
+
+// AnswerRequest is the body of POST /api/players/{id}/answer.
+type AnswerRequest struct {
+	CardID   string `json:"card_id"`
+	Answer   string `json:"answer"`
+	Practice bool   `json:"practice,omitempty"`
+	// Spoken marks the answer as a speech transcript, matched loosely.
+	Spoken bool `json:"spoken,omitempty"`
+}
+
+// PlayerSummary is an entry of GET /api/players.
+type PlayerSummary struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Guest bool   `json:"guest,omitempty"`
+}
+
+// OverlayState is the live data shown by the stream overlay.
+type OverlayState struct {
+	Player          string  `json:"player"`
+	Streak          int     `json:"streak"`
+	SessionAnswered int     `json:"session_answered"`
+	SessionCorrect  int     `json:"session_correct"`
+	SessionAccuracy float64 `json:"session_accuracy"`
+	CardsInRotation int     `json:"cards_in_rotation"`
+}
+
+// --- Command Handlers ---
+
+func handleServe(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /overlay", instrument("/overlay", serveOverlayPage))
+	mux.HandleFunc("GET /api/overlay", instrument("/api/overlay", requirePlayer(serveOverlayState)))
+	mux.HandleFunc("GET /api/players/{id}/card", instrument("/api/players/{id}/card", requirePlayer(serveNextCard)))
+	mux.HandleFunc("POST /api/players/{id}/answer", instrument("/api/players/{id}/answer", requirePlayer(serveAnswer)))
+	mux.HandleFunc("GET /api/players", instrument("/api/players", requireAdmin(serveListPlayers)))
+	mux.HandleFunc("POST /api/players", instrument("/api/players", requireAdmin(serveCreatePlayer)))
+	mux.HandleFunc("DELETE /api/players/{id}", instrument("/api/players/{id}", requireAdmin(serveDeletePlayer)))
+	mux.HandleFunc("GET /api/overview", instrument("/api/overview", requireAdmin(serveOverview)))
+	mux.HandleFunc("GET /api/schemas/{name}", instrument("/api/schemas/{name}", serveSchema))
+	mux.HandleFunc("GET /metrics", instrument("/metrics", requireAdmin(serveMetrics)))
+
+	if len(loadTokens()) == 0 {
+		warnf("no API tokens exist, so anyone who can reach %s can answer for and manage every player. Create one with 'create-token'.", addr)
+	}
+	state := enableCache()
+	server := &http.Server{Addr: addr, Handler: mux}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	fmt.Printf(tr("Serving on http://%s (overlay at /overlay?player-id=<id>, metrics at /metrics)\n"), addr)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		fatalf("Server error: %v", err)
+	}
+	// Answers still in memory are written before exiting
+	state.flush()
+	cache = nil
+	foldJournal()
+}
+
+// --- HTTP Handlers ---
+
+func serveOverlayPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(overlayPage)
+}
+
+func serveOverlayState(w http.ResponseWriter, r *http.Request) {
+	playerID := r.URL.Query().Get("player-id")
+	player, ok := loadAllProgress()[playerID]
+	if !ok {
+		http.Error(w, fmt.Sprintf("Player with ID '%s' not found.", playerID), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, overlayState(player, time.Now()))
+}
+
+func serveNextCard(w http.ResponseWriter, r *http.Request) {
+	playerID := r.PathValue("id")
+	progressLock.Lock()
+	defer progressLock.Unlock()
+	if _, ok := loadAllProgress()[playerID]; !ok {
+		http.Error(w, fmt.Sprintf("Player with ID '%s' not found.", playerID), http.StatusNotFound)
+		return
+	}
+	seed := time.Now().UnixNano()
+	var explainSeed *int64
+	if r.URL.Query().Get("explain") == "true" {
+		explainSeed = &seed
+	}
+	query := r.URL.Query()
+	view, ok, err := nextCard(playerID, query.Get("practice") == "true", query.Get("review_ahead") == "true", explainSeed, newRand(seed))
+	if err != nil {
+		writeCardError(w, err)
+		return
+	}
+	if !ok {
+		writeJSON(w, playerDoneView(playerID))
+		return
+	}
+	writeJSON(w, view)
+}
+
+func serveAnswer(w http.ResponseWriter, r *http.Request) {
+	playerID := r.PathValue("id")
+	var request AnswerRequest
+	if err := decodeStrict(r.Body, &request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	card, ok := findCard(loadCards(), request.CardID)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Card with ID '%s' not found.", request.CardID), http.StatusNotFound)
+		return
+	}
+	progressLock.Lock()
+	defer progressLock.Unlock()
+	if _, ok := loadAllProgress()[playerID]; !ok {
+		http.Error(w, fmt.Sprintf("Player with ID '%s' not found.", playerID), http.StatusNotFound)
+		return
+	}
+	result, err := recordAnswer(playerID, card, request.Answer, request.Practice, request.Spoken)
+	if err != nil {
+		writeCardError(w, err)
+		return
+	}
+	writeJSON(w, result)
+}
+
+func serveListPlayers(w http.ResponseWriter, r *http.Request) {
+	players := []PlayerSummary{}
+	for id, player := range loadAllProgress() {
+		players = append(players, PlayerSummary{ID: id, Name: player.Name, Guest: isGuest(id)})
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].Name < players[j].Name })
+	writeJSON(w, players)
+}
+
+func serveCreatePlayer(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Name  string `json:"name"`
+		Guest bool   `json:"guest"`
+	}
+	if err := decodeStrict(r.Body, &request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if request.Name == "" {
+		http.Error(w, "A JSON body with a name is required.", http.StatusBadRequest)
+		return
+	}
+	progressLock.Lock()
+	defer progressLock.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if request.Guest {
+		writeJSON(w, PlayerSummary{ID: createGuest(request.Name), Name: request.Name, Guest: true})
+		return
+	}
+	writeJSON(w, PlayerSummary{ID: createPlayer(request.Name), Name: request.Name})
+}
+
+func serveDeletePlayer(w http.ResponseWriter, r *http.Request) {
+	playerID := r.PathValue("id")
+	progressLock.Lock()
+	defer progressLock.Unlock()
+	if deleteGuest(playerID) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	allProgress := loadAllProgress()
+	if _, ok := allProgress[playerID]; !ok {
+		http.Error(w, fmt.Sprintf("Player with ID '%s' not found.", playerID), http.StatusNotFound)
+		return
+	}
+	delete(allProgress, playerID)
+	saveAllProgress(allProgress)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- Helpers ---
+
+func overlayState(player PlayerData, now time.Time) OverlayState {
+	state := OverlayState{Player: player.Name}
+	state.Streak, _ = dailyStreaks(player.History, now)
+
+	// The session is the run of answers without a long pause, as long as
+	// the last of them was recent
+	if n := len(player.History); n > 0 && now.Sub(player.History[n-1].Timestamp) < sessionGap {
+		for i := n - 1; i >= 0; i-- {
+			item := player.History[i]
+			if i < n-1 && player.History[i+1].Timestamp.Sub(item.Timestamp) >= sessionGap {
+				break
+			}
+			state.SessionAnswered++
+			if item.Correct {
+				state.SessionCorrect++
+			}
+		}
+		state.SessionAccuracy = float64(state.SessionCorrect) / float64(state.SessionAnswered)
+	}
+
+	for _, cardProgress := range player.Cards {
+		if inRotation(cardProgress) {
+			state.CardsInRotation++
+		}
+	}
+	return state
+}
+
+// writeCardError answers a request for a card that couldn't be served or
+// graded: a broken card is the deck's problem, anything else, like a
+// failing plugin, the server's.
+func writeCardError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var invalid invalidCardError
+	if errors.As(err, &invalid) {
+		status = http.StatusUnprocessableEntity
+	}
+	slog.Error("Error serving card", "err", err)
+	http.Error(w, err.Error(), status)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Error writing JSON response", "err", err)
+	}
+}
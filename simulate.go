@@ -0,0 +1,219 @@
+// simulate.go
+//
+// A virtual learner for the simulate command. It studies a number of cards
+// a day against the real scheduler (the same selection, new-card, retire
+// and decay rules as get-card and check-answer) and answers correctly with a
+// fixed probability per box. Nothing is read from or written to the
+// player's progress, so weights and rules can be tried out before putting
+// them in config.json.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Simulation describes the virtual learner.
+type Simulation struct {
+	Days   int
+	PerDay int
+	// Accuracy is the chance of a correct answer in boxes 1 to 5.
+	Accuracy []float64
+	Seed     int64
+}
+
+// SimulationDay is the state at the end of one simulated day.
+type SimulationDay struct {
+	Day          int
+	Reviews      int
+	Correct      int
+	New          int
+	Counts       [6]int // boxes 1 to 5 and retired
+	ReviewsByBox [5]int
+}
+
+// SimulationResult is the outcome of a whole simulation.
+type SimulationResult struct {
+	Days []SimulationDay
+	// RetiredAfter holds the number of reviews each retired card took.
+	RetiredAfter []int
+}
+
+// simulate runs the virtual learner over cards.
+func simulate(cards []Card, scheduler SchedulerConfig, decay DecayConfig, sim Simulation) SimulationResult {
+	rng := newRand(sim.Seed)
+	next, err := activeScheduler(scheduler)
+	if err != nil {
+		fatalf("Error loading the scheduler: %v", err)
+	}
+	player := PlayerData{Name: "simulation", Cards: make(map[string]CardProgress)}
+	reviews := make(map[string]int)
+	start := calendarDay(time.Now())
+
+	var result SimulationResult
+	for day := 0; day < sim.Days; day++ {
+		now := start.AddDate(0, 0, day)
+		decayPlayer(&player, decay.AfterDays, now)
+		stats := SimulationDay{Day: day + 1}
+		for i := 0; i < sim.PerDay; i++ {
+			pick, ok, err := next.NextCard(player, cards, false, now, rng)
+			if err != nil {
+				fatalf("Scheduler '%s' failed: %v", scheduler.Plugin, err)
+			}
+			stats.New += len(pick.Introduced)
+			if !ok {
+				break
+			}
+			card, box := pick.Card, pick.Box
+			correct := rng.Float64() < sim.Accuracy[box-1]
+			progress, err := next.OnAnswer(player, card, correct, now)
+			if err != nil {
+				fatalf("Scheduler '%s' failed: %v", scheduler.Plugin, err)
+			}
+			progress.LastReviewed = now
+			progress.Decayed = 0
+			player.Cards[card.ID] = progress
+			player.RecentCards = rememberPick(player.RecentCards, card.ID, scheduler.historyLimit())
+
+			reviews[card.ID]++
+			if progress.Retired {
+				result.RetiredAfter = append(result.RetiredAfter, reviews[card.ID])
+			}
+			stats.Reviews++
+			stats.ReviewsByBox[box-1]++
+			if correct {
+				stats.Correct++
+			}
+		}
+		for _, progress := range player.Cards {
+			if progress.Retired {
+				stats.Counts[5]++
+			} else {
+				stats.Counts[progress.Box-1]++
+			}
+		}
+		result.Days = append(result.Days, stats)
+	}
+	return result
+}
+
+// syntheticCards makes a deck of n placeholder cards.
+func syntheticCards(n int) []Card {
+	cards := make([]Card, n)
+	for i := range cards {
+		cards[i] = Card{ID: fmt.Sprintf("sim-%d", i+1), Language: "simulation"}
+	}
+	return cards
+}
+
+// --- Command Handlers ---
+
+func handleSimulate(sim Simulation, cardCount int, weights string, retireAfter int) {
+	config := loadConfig()
+	scheduler := config.Scheduler
+	if weights != "" {
+		scheduler.BoxWeights = parseIntList("--weights", weights)
+	}
+	if retireAfter > 0 {
+		scheduler.RetireAfter = &retireAfter
+	}
+	cards := syntheticCards(cardCount)
+	if cardCount == 0 {
+		cards = loadCards()
+	}
+	if len(cards) == 0 {
+		fatal("No cards to simulate with.")
+	}
+
+	result := simulate(cards, scheduler, config.Decay, sim)
+
+	var accuracy, weightList []string
+	for i, a := range sim.Accuracy {
+		accuracy = append(accuracy, fmt.Sprintf("%.0f%%", a*100))
+		weightList = append(weightList, strconv.Itoa(scheduler.boxWeights()[i]))
+	}
+	fmt.Printf(tr("Simulated %d day(s) of %d review(s) on %d card(s), seed %d.\n"), sim.Days, sim.PerDay, len(cards), sim.Seed)
+	fmt.Printf(tr("Weights %s, accuracy %s, retired after %d pass(es) in box 5.\n\n"), strings.Join(weightList, "/"), strings.Join(accuracy, "/"), scheduler.retireAfter())
+
+	fmt.Printf("%5s %8s %8s %5s %7s %7s %7s %7s %7s %8s\n", "Day", "Reviews", "Correct", "New", "Box 1", "Box 2", "Box 3", "Box 4", "Box 5", "Retired")
+	step := max((sim.Days+9)/10, 1)
+	var byBox [5]int
+	total := 0
+	for i, day := range result.Days {
+		for box, n := range day.ReviewsByBox {
+			byBox[box] += n
+		}
+		total += day.Reviews
+		if i%step != 0 && i != len(result.Days)-1 {
+			continue
+		}
+		correct := "-"
+		if day.Reviews > 0 {
+			correct = fmt.Sprintf("%.0f%%", float64(day.Correct)/float64(day.Reviews)*100)
+		}
+		fmt.Printf("%5d %8d %8s %5d", day.Day, day.Reviews, correct, day.New)
+		for _, n := range day.Counts {
+			fmt.Printf(" %7d", n)
+		}
+		fmt.Println()
+	}
+
+	if total == 0 {
+		return
+	}
+	var load []string
+	for box, n := range byBox {
+		load = append(load, fmt.Sprintf("box %d %.0f%%", box+1, float64(n)/float64(total)*100))
+	}
+	fmt.Printf(tr("\nReview load: %s of %d review(s).\n"), strings.Join(load, ", "), total)
+	last := result.Days[len(result.Days)-1]
+	fmt.Printf(tr("Retired: %d of %d card(s).\n"), last.Counts[5], len(cards))
+	if len(result.RetiredAfter) > 0 {
+		sum := 0
+		for _, n := range result.RetiredAfter {
+			sum += n
+		}
+		fmt.Printf(tr("Reviews per retired card: %.1f on average.\n"), float64(sum)/float64(len(result.RetiredAfter)))
+	}
+}
+
+// --- Helpers ---
+
+// parseIntList parses one positive integer per box from a comma-separated
+// flag value.
+func parseIntList(flagName, value string) []int {
+	parts := strings.Split(value, ",")
+	if len(parts) != topBox {
+		fatalf("%s needs %d comma-separated values, one per box.", flagName, topBox)
+	}
+	values := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 1 {
+			fatalf("%s: '%s' is not a positive whole number.", flagName, part)
+		}
+		values[i] = n
+	}
+	return values
+}
+
+// parseAccuracy parses one probability per box from a comma-separated flag
+// value.
+func parseAccuracy(value string) []float64 {
+	parts := strings.Split(value, ",")
+	if len(parts) != topBox {
+		fatalf("--accuracy needs %d comma-separated values, one per box.", topBox)
+	}
+	values := make([]float64, len(parts))
+	for i, part := range parts {
+		p, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || p < 0 || p > 1 {
+			fatalf("--accuracy: '%s' is not a probability between 0 and 1.", part)
+		}
+		values[i] = p
+	}
+	return values
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSimulate(t *testing.T) {
+	tests := []struct {
+		name     string
+		cards    int
+		sim      Simulation
+		retireAt int
+	}{
+		{"perfect learner", 20, Simulation{Days: 60, PerDay: 30, Accuracy: []float64{1, 1, 1, 1, 1}, Seed: 1}, 1},
+		{"shaky learner", 20, Simulation{Days: 30, PerDay: 20, Accuracy: []float64{0.6, 0.7, 0.8, 0.9, 0.9}, Seed: 2}, 1},
+		{"retiring after three passes", 10, Simulation{Days: 30, PerDay: 20, Accuracy: []float64{0.9, 0.9, 0.9, 0.9, 0.9}, Seed: 3}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cards := syntheticCards(tt.cards)
+			config := SchedulerConfig{RetireAfter: intPtr(tt.retireAt)}
+			result := simulate(cards, config, DecayConfig{}, tt.sim)
+			if again := simulate(cards, config, DecayConfig{}, tt.sim); !reflect.DeepEqual(result, again) {
+				t.Fatalf("two runs with seed %d differ", tt.sim.Seed)
+			}
+			if len(result.Days) != tt.sim.Days {
+				t.Fatalf("%d days simulated, want %d", len(result.Days), tt.sim.Days)
+			}
+			introduced := 0
+			for _, day := range result.Days {
+				introduced += day.New
+				if day.Reviews > tt.sim.PerDay || day.Correct > day.Reviews {
+					t.Errorf("day %d: %d reviews, %d correct with %d a day", day.Day, day.Reviews, day.Correct, tt.sim.PerDay)
+				}
+				byBox, inBoxes := 0, 0
+				for box := 0; box < topBox; box++ {
+					byBox += day.ReviewsByBox[box]
+				}
+				for _, count := range day.Counts {
+					inBoxes += count
+				}
+				if byBox != day.Reviews {
+					t.Errorf("day %d: reviews by box add up to %d, want %d", day.Day, byBox, day.Reviews)
+				}
+				if inBoxes != introduced {
+					t.Errorf("day %d: %d cards in the boxes, want the %d introduced", day.Day, inBoxes, introduced)
+				}
+			}
+			last := result.Days[len(result.Days)-1]
+			if last.Counts[5] != len(result.RetiredAfter) {
+				t.Errorf("%d cards retired, but %d retirements recorded", last.Counts[5], len(result.RetiredAfter))
+			}
+			for _, reviews := range result.RetiredAfter {
+				// Up through boxes 1 to 5, then the passes in box 5
+				if fewest := topBox - 1 + tt.retireAt; reviews < fewest {
+					t.Errorf("a card retired after %d reviews, want at least %d", reviews, fewest)
+				}
+			}
+		})
+	}
+}
+
+func TestSimulatePerfectLearnerRetiresEverything(t *testing.T) {
+	cards := syntheticCards(15)
+	result := simulate(cards, SchedulerConfig{}, DecayConfig{}, Simulation{Days: 90, PerDay: 40, Accuracy: []float64{1, 1, 1, 1, 1}, Seed: 4})
+	last := result.Days[len(result.Days)-1]
+	if last.Counts[5] != len(cards) {
+		t.Errorf("%d of %d cards retired after 90 perfect days, want all; boxes %v", last.Counts[5], len(cards), last.Counts)
+	}
+	for _, reviews := range result.RetiredAfter {
+		if reviews != topBox {
+			t.Errorf("a perfectly known card retired after %d reviews, want %d", reviews, topBox)
+		}
+	}
+}
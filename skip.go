@@ -0,0 +1,105 @@
+// skip.go
+//
+// Per-player skip list. A skipped card is taken out of the player's
+// rotation for good, e.g. vocabulary they already know cold, until they
+// unskip it. Its box and counters are kept, so unskipping picks up where
+// the card left off.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// --- Command Handlers ---
+
+func handleSkipCard(playerID, cardID string) {
+	if _, ok := findCard(loadCards(), cardID); !ok {
+		fatalf("Card with ID '%s' not found in deck.", cardID)
+	}
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	if _, ok := player.Skipped[cardID]; ok {
+		fmt.Printf(tr("Card %s is already skipped.\n"), cardID)
+		return
+	}
+	if player.Skipped == nil {
+		player.Skipped = make(map[string]time.Time)
+	}
+	player.Skipped[cardID] = time.Now()
+	allProgress[playerID] = player
+	saveAllProgress(allProgress)
+	fmt.Printf(tr("Card %s won't be asked anymore. Use 'unskip-card' to bring it back.\n"), cardID)
+}
+
+func handleUnskipCard(playerID, cardID string) {
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	if _, ok := player.Skipped[cardID]; !ok {
+		fatalf("Card %s is not skipped.", cardID)
+	}
+	delete(player.Skipped, cardID)
+	allProgress[playerID] = player
+	saveAllProgress(allProgress)
+	fmt.Printf(tr("Card %s is back in rotation.\n"), cardID)
+}
+
+func handleListSkipped(playerID string) {
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	if len(player.Skipped) == 0 {
+		fmt.Println(tr("No skipped cards."))
+		return
+	}
+	loc := resolveLocale(player.Locale)
+	cards := loadCards()
+
+	ids := make([]string, 0, len(player.Skipped))
+	for id := range player.Skipped {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return player.Skipped[ids[i]].Before(player.Skipped[ids[j]]) })
+	for _, id := range ids {
+		prompt := "(no longer in the deck)"
+		if card, ok := findCard(cards, id); ok {
+			prompt = firstLine(card.Prompt)
+		}
+		fmt.Printf("%s  %s  %s\n", loc.Date(player.Skipped[id]), id, prompt)
+	}
+}
+
+// --- Helpers ---
+
+// withoutSkipped returns the cards the player hasn't skipped.
+func withoutSkipped(cards []Card, player PlayerData) []Card {
+	if len(player.Skipped) == 0 {
+		return cards
+	}
+	kept := make([]Card, 0, len(cards))
+	for _, card := range cards {
+		if _, skipped := player.Skipped[card.ID]; !skipped {
+			kept = append(kept, card)
+		}
+	}
+	return kept
+}
+
+// firstLine shortens a prompt to its first line for listings.
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " ..."
+	}
+	return s
+}
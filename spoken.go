@@ -0,0 +1,127 @@
+// spoken.go
+//
+// Spoken answers, for pronunciation practice. check-answer --spoken takes
+// the answer from a speech-to-text command set in config.json, which
+// records from the microphone and prints what it heard:
+//
+//	{"speech": {"command": ["transcribe", "--language", "{language}"], "tolerance": 0.2}}
+//
+// {language} in the arguments is replaced by the card's language, and the
+// command gets {"card": {...}} on stdin, as checkers do, so it can show the
+// prompt while it listens. Frontends that transcribe themselves pass the
+// transcript with --answer instead.
+//
+// Transcripts are matched more loosely than typed answers: case, accents,
+// punctuation and spacing are ignored, and a few letters may differ, up to
+// the tolerance as a share of the solution's length. Spoken answers are
+// marked in the history so they can be counted apart from typed ones.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// defaultSpeechTolerance is the share of a solution's letters a
+	// transcript may get wrong.
+	defaultSpeechTolerance = 0.2
+	// speechTimeout bounds how long recording and transcribing may take.
+	speechTimeout = 2 * time.Minute
+)
+
+// SpeechConfig is the "speech" block of config.json.
+type SpeechConfig struct {
+	// Command records an answer and prints its transcript; the first
+	// element is the executable.
+	Command   []string `json:"command,omitempty"`
+	Tolerance float64  `json:"tolerance,omitempty"`
+}
+
+// transcribeAnswer runs the speech-to-text command for card and returns
+// what it heard.
+func transcribeAnswer(card Card, config SpeechConfig) string {
+	if len(config.Command) == 0 {
+		fatal("No speech-to-text command is set; add \"speech\": {\"command\": [...]} to config.json or pass the transcript with --answer.")
+	}
+	input, err := json.Marshal(map[string]Card{"card": card})
+	if err != nil {
+		fatalf("Error marshalling card to JSON: %v", err)
+	}
+	args := make([]string, len(config.Command))
+	for i, arg := range config.Command {
+		args[i] = strings.ReplaceAll(arg, "{language}", card.Language)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), speechTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		fatalf("Speech-to-text command '%s' failed: %v", args[0], err)
+	}
+	transcript := strings.TrimSpace(string(output))
+	if transcript == "" {
+		fatal("The speech-to-text command heard nothing.")
+	}
+	return transcript
+}
+
+// checkSpokenAnswer grades a transcript. Anything the card's own check
+// accepts is right; otherwise a card with a plain solution is compared with
+// the transcript loosely.
+func checkSpokenAnswer(card Card, transcript string, config SpeechConfig) (Verdict, error) {
+	verdict, err := checkAnswer(card, transcript)
+	if err != nil || verdict.Correct || card.Type != "" || card.Checker != "" {
+		return verdict, err
+	}
+	tolerance := config.Tolerance
+	if tolerance <= 0 {
+		tolerance = defaultSpeechTolerance
+	}
+	heard, expected := []rune(spokenKey(transcript)), []rune(spokenKey(card.Solution))
+	if editDistance(heard, expected) <= int(tolerance*float64(len(expected))) {
+		verdict.Correct = true
+		if string(heard) != string(expected) {
+			verdict.Feedback = strings.TrimSpace(verdict.Feedback + fmt.Sprintf(" Close enough; the solution is %q.", card.Solution))
+		}
+	}
+	return verdict, nil
+}
+
+// --- Helpers ---
+
+// countSpoken counts the spoken answers among items and the correct ones.
+func countSpoken(items []AnswerLogItem) (spoken, correct int) {
+	for _, item := range items {
+		if item.Spoken {
+			spoken++
+			if item.Correct {
+				correct++
+			}
+		}
+	}
+	return spoken, correct
+}
+
+// spokenKey reduces text to the letters and digits a transcript can be
+// expected to get right, separated by single spaces.
+func spokenKey(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return ' '
+		}
+		return r
+	}, searchKey(s))
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,256 @@
+// study.go
+//
+// Seeded card selection and the study command. Every random choice of the
+// scheduler comes from one generator, so the same seed, progress and
+// answers always give the same cards. study runs a whole session in the
+// terminal with the same engine as get-card and check-answer and records
+// its seed in sessions.json, so a session can be replayed with --replay
+// for debugging the scheduler or as an integration test. Sessions that were
+// paused and resumed draw the cards after the pause with a new seed and
+// can't be replayed exactly.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StudySession is the stored record of a study session.
+type StudySession struct {
+	ID        string          `json:"id"`
+	PlayerID  string          `json:"player_id"`
+	Seed      int64           `json:"seed"`
+	Practice  bool            `json:"practice,omitempty"`
+	StartedAt time.Time       `json:"started_at"`
+	EndedAt   time.Time       `json:"ended_at"`
+	Answers   []AnswerLogItem `json:"answers"`
+	// Paused is the time the session spent paused (see pause.go).
+	Paused time.Duration `json:"paused,omitempty"`
+}
+
+// newRand returns the generator card selection draws from.
+func newRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// chooseSeed returns the value of the --seed flag of fs if it was given,
+// and a fresh seed otherwise.
+func chooseSeed(fs *flag.FlagSet, seed int64) int64 {
+	given := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			given = true
+		}
+	})
+	if !given {
+		seed = time.Now().UnixNano()
+	}
+	slog.Debug("Card selection seed", "seed", seed)
+	return seed
+}
+
+// --- Command Handlers ---
+
+func handleStudy(playerID string, count int, seed int64, practice bool) {
+	player, ok := loadAllProgress()[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	warnPausedSession(PausedStudy, playerID)
+	session := StudySession{
+		ID:        generateUniqueID(),
+		PlayerID:  playerID,
+		Seed:      seed,
+		Practice:  practice,
+		StartedAt: time.Now(),
+	}
+	publishEvent(Event{
+		Type:      EventSessionStart,
+		Timestamp: session.StartedAt,
+		PlayerID:  playerID,
+		Data:      map[string]interface{}{"mode": "study", "session_id": session.ID, "seed": seed},
+	})
+
+	fmt.Printf(tr("Study session for %s: %d card(s), seed %d.\n"), player.Name, count, seed)
+	runStudy(PausedSession{Mode: PausedStudy, PlayerID: playerID, Count: count, Study: &session}, newRand(seed))
+}
+
+// handleResumeStudy continues a paused study session. The cards after the
+// one that was on screen are drawn with a new seed.
+func handleResumeStudy(playerID string) {
+	paused, ok := findPausedSession(PausedStudy, playerID)
+	if !ok {
+		fatalf("No paused study session for player '%s'.", playerID)
+	}
+	player, ok := loadAllProgress()[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	paused.Study.Paused += time.Since(paused.PausedAt)
+	publishEvent(Event{
+		Type:     EventSessionStart,
+		PlayerID: playerID,
+		Data:     map[string]interface{}{"mode": "study", "session_id": paused.Study.ID, "seed": paused.Study.Seed, "resumed": true},
+	})
+
+	fmt.Printf(tr("Resuming the study session for %s: %d of %d card(s) answered, %d correct.\n"), player.Name, len(paused.Study.Answers), paused.Count, paused.Correct)
+	runStudy(paused, newRand(time.Now().UnixNano()))
+}
+
+// runStudy asks the rest of a study session, checkpointing as it goes.
+func runStudy(state PausedSession, rng *rand.Rand) {
+	playerID, session := state.PlayerID, state.Study
+	practice := session.Practice
+	reader := bufio.NewReader(os.Stdin)
+	pauseOnInterrupt("study", playerID)
+
+	for i := len(session.Answers); i < state.Count; i++ {
+		view, ok := pendingCard(playerID, state.Pending)
+		if !ok {
+			var err error
+			view, ok, err = nextCard(playerID, practice, false, nil, rng)
+			if err != nil {
+				warnf("Could not pick the next card: %v", err)
+				continue
+			}
+		}
+		if !ok {
+			done := playerDoneView(playerID)
+			fmt.Println("\n" + tr(done.Message))
+			if done.DueIn != "" {
+				fmt.Printf(tr("The next card is due in %s.\n"), done.DueIn)
+			}
+			break
+		}
+		state.Pending = []string{view.ID}
+		checkpointSession(state)
+
+		fmt.Printf(tr("\nCard %d/%d (box %d)\n"), i+1, state.Count, view.Box)
+		answer, ok, err := askCard(reader, view.Card)
+		if err != nil {
+			warnf("Skipping card '%s': %v", view.ID, err)
+			state.Pending = nil
+			checkpointSession(state)
+			continue
+		}
+		if !ok {
+			fmt.Println(tr("\nInput closed, ending the session."))
+			break
+		}
+		result, err := recordAnswer(playerID, view.Card, answer, practice, false)
+		if err != nil {
+			// The card is skipped; the session goes on
+			warnf("Could not check the answer to card '%s': %v", view.ID, err)
+			state.Pending = nil
+			checkpointSession(state)
+			continue
+		}
+		session.Answers = append(session.Answers, AnswerLogItem{CardID: view.ID, Timestamp: time.Now(), Correct: result.Correct})
+		switch {
+		case result.Retired:
+			fmt.Println(tr("Correct! The card is retired."))
+		case result.Correct:
+			fmt.Printf(tr("Correct! Moved to box %d.\n"), result.NewBox)
+		default:
+			fmt.Printf(tr("Incorrect. The answer was: %s\n"), result.Solution)
+		}
+		if result.Feedback != "" {
+			fmt.Println(result.Feedback)
+		}
+		if result.Correct {
+			state.Correct++
+		}
+		state.Pending = nil
+		checkpointSession(state)
+	}
+	session.EndedAt = time.Now()
+
+	if !isGuest(playerID) {
+		sessions := loadSessions()
+		sessions = append(sessions, *session)
+		saveSessions(sessions)
+		clearPausedSession(PausedStudy, playerID)
+		foldJournal()
+	}
+	publishEvent(Event{
+		Type:     EventSessionEnd,
+		PlayerID: playerID,
+		Data:     map[string]interface{}{"mode": "study", "session_id": session.ID, "seed": session.Seed, "answered": len(session.Answers), "correct": state.Correct},
+	})
+	fmt.Printf(tr("\n%d of %d correct. Session %s, seed %d.\n"), state.Correct, len(session.Answers), session.ID, session.Seed)
+}
+
+// pendingCard returns the card that was on screen when a session was
+// paused, if it is still in the deck and in rotation.
+func pendingCard(playerID string, pending []string) (CardView, bool) {
+	if len(pending) == 0 {
+		return CardView{}, false
+	}
+	card, ok := findCard(loadCards(), pending[0])
+	if !ok {
+		return CardView{}, false
+	}
+	player := loadAllProgress()[playerID]
+	progress, ok := player.Cards[card.ID]
+	if !ok || !inRotation(progress) {
+		return CardView{}, false
+	}
+	view, err := cardView(player, card, progress.Box)
+	if err != nil {
+		warnf("Could not show card '%s' again: %v", card.ID, err)
+		return CardView{}, false
+	}
+	return view, true
+}
+
+// replaySeed returns the seed of a recorded session.
+func replaySeed(sessionID string) int64 {
+	for _, session := range loadSessions() {
+		if session.ID == sessionID {
+			return session.Seed
+		}
+	}
+	fatalf("Session '%s' not found.", sessionID)
+	return 0
+}
+
+// --- Helpers ---
+
+func loadSessions() []StudySession {
+	filePath := filepath.Join(getDataDir(), "sessions.json")
+	file, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		fatalf("Error reading study sessions (%s): %v", filePath, err)
+	}
+	var sessions []StudySession
+	if len(file) == 0 {
+		return sessions
+	}
+	if err := json.Unmarshal(file, &sessions); err != nil {
+		fatalf("Error unmarshalling study sessions JSON: %v", err)
+	}
+	return sessions
+}
+
+func saveSessions(sessions []StudySession) {
+	filePath := filepath.Join(getDataDir(), "sessions.json")
+	data, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		fatalf("Error marshalling study sessions to JSON: %v", err)
+	}
+	if err := ioutil.WriteFile(filePath, data, 0644); err != nil {
+		fatalf("Error writing study sessions (%s): %v", filePath, err)
+	}
+}
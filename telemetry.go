@@ -0,0 +1,262 @@
+// telemetry.go
+//
+// Strictly opt-in usage telemetry. Nothing is recorded unless config.json
+// contains "telemetry": {"enabled": true, ...}. Even then only two kinds of
+// numbers are kept: how often each subcommand ran and how often each class
+// of error occurred. No cards, answers, names or IDs ever leave the machine,
+// and `telemetry preview` prints the exact payload before anything is sent.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TelemetryConfig is the "telemetry" block of config.json.
+type TelemetryConfig struct {
+	Enabled  bool   `json:"enabled,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+	// AutoSend sends the report at most once a week after a command runs.
+	AutoSend bool `json:"auto_send,omitempty"`
+}
+
+// TelemetryReport is both the local counter file and the payload sent.
+type TelemetryReport struct {
+	Since        time.Time      `json:"since"`
+	FeatureUsage map[string]int `json:"feature_usage"`
+	ErrorClasses map[string]int `json:"error_classes"`
+	// LastSent is local bookkeeping and is never part of the payload.
+	LastSent time.Time `json:"last_sent,omitempty"`
+}
+
+// autoSendInterval is the minimum time between automatic reports.
+const autoSendInterval = 7 * 24 * time.Hour
+
+var (
+	// telemetry holds the settings read at startup, so that recording an
+	// error never needs to read the config again.
+	telemetry TelemetryConfig
+	// telemetryPath is the counter file, resolved at startup for the same
+	// reason: finding the data directory can itself end in a log call.
+	telemetryPath string
+	// telemetryMu serializes changes to the counter file. Errors are
+	// counted from whichever goroutine logs them, such as serve's handlers.
+	telemetryMu sync.Mutex
+)
+
+// setupTelemetry reads the telemetry settings. Once enabled, the console
+// log handler counts the classes of the warnings and errors it shows.
+func setupTelemetry() {
+	config := loadConfig().Telemetry
+	if config.Enabled {
+		telemetryPath = filepath.Join(getDataDir(), "telemetry.json")
+	}
+	telemetry = config
+}
+
+// recordFeatureUsage counts one run of a subcommand.
+func recordFeatureUsage(command string) {
+	if !telemetry.Enabled {
+		return
+	}
+	updateTelemetryReport(func(report *TelemetryReport) {
+		report.FeatureUsage[command]++
+	})
+}
+
+// countErrorClass counts one warning or error shown to the user. Nothing
+// it calls logs, so it can't recurse into itself.
+func countErrorClass(level slog.Level, message string) {
+	if !telemetry.Enabled {
+		return
+	}
+	class := "warning"
+	if level >= slog.LevelError {
+		class = classifyError(message)
+	}
+	updateTelemetryReport(func(report *TelemetryReport) {
+		report.ErrorClasses[class]++
+	})
+}
+
+// classifyError reduces an error message to a coarse class. The message
+// itself is never stored.
+func classifyError(message string) string {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "not found"):
+		return "not_found"
+	case strings.Contains(lower, "required") || strings.Contains(lower, "expected") || strings.Contains(lower, "invalid") || strings.Contains(lower, "unknown subcommand"):
+		return "usage"
+	case strings.Contains(lower, "unmarshalling") || strings.Contains(lower, "decoding"):
+		return "decode"
+	case strings.Contains(lower, "error reading") || strings.Contains(lower, "error opening"):
+		return "read"
+	case strings.Contains(lower, "error writing") || strings.Contains(lower, "error creating"):
+		return "write"
+	case strings.Contains(lower, "server"):
+		return "server"
+	default:
+		return "other"
+	}
+}
+
+// maybeAutoSendTelemetry sends the report if the user asked for automatic
+// sending and the last report is old enough. Failures are silent.
+func maybeAutoSendTelemetry() {
+	if !telemetry.Enabled || !telemetry.AutoSend || telemetry.Endpoint == "" {
+		return
+	}
+	report := loadTelemetryReport()
+	lastSent := report.LastSent
+	if lastSent.IsZero() {
+		lastSent = report.Since
+	}
+	if time.Since(lastSent) < autoSendInterval {
+		return
+	}
+	if sendTelemetryReport(report) == nil {
+		resetTelemetryReport(true)
+	}
+}
+
+// --- Command Handlers ---
+
+func handleTelemetry(action string) {
+	switch action {
+	case "preview":
+		fmt.Printf(tr("Telemetry enabled: %t\n"), telemetry.Enabled)
+		if telemetry.Endpoint != "" {
+			fmt.Printf(tr("Endpoint: %s\n"), telemetry.Endpoint)
+		}
+		fmt.Println(tr("Payload that would be sent:"))
+		data, err := json.MarshalIndent(telemetryPayload(loadTelemetryReport()), "", "  ")
+		if err != nil {
+			fatalf("Error marshalling telemetry report: %v", err)
+		}
+		fmt.Println(string(data))
+	case "send":
+		if !telemetry.Enabled {
+			fatal("Telemetry is disabled. Set \"telemetry\": {\"enabled\": true} in config.json to opt in.")
+		}
+		if telemetry.Endpoint == "" {
+			fatal("No telemetry endpoint configured in config.json.")
+		}
+		if err := sendTelemetryReport(loadTelemetryReport()); err != nil {
+			fatalf("Error sending telemetry: %v", err)
+		}
+		resetTelemetryReport(true)
+		fmt.Println(tr("Telemetry report sent."))
+	case "reset":
+		resetTelemetryReport(false)
+		fmt.Println(tr("Local telemetry counters cleared."))
+	default:
+		fatalf("Unknown telemetry action '%s', expected 'preview', 'send' or 'reset'.", action)
+	}
+}
+
+// --- Helpers ---
+
+// telemetryPayload strips local bookkeeping from the report.
+func telemetryPayload(report TelemetryReport) interface{} {
+	return struct {
+		Since        time.Time      `json:"since"`
+		FeatureUsage map[string]int `json:"feature_usage"`
+		ErrorClasses map[string]int `json:"error_classes"`
+	}{report.Since, report.FeatureUsage, report.ErrorClasses}
+}
+
+func sendTelemetryReport(report TelemetryReport) error {
+	data, err := json.Marshal(telemetryPayload(report))
+	if err != nil {
+		return err
+	}
+	client := http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Post(telemetry.Endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return nil
+}
+
+// resetTelemetryReport clears the counters, remembering when a report was
+// last sent.
+func resetTelemetryReport(sent bool) {
+	updateTelemetryReport(func(report *TelemetryReport) {
+		*report = TelemetryReport{
+			Since:        time.Now(),
+			FeatureUsage: make(map[string]int),
+			ErrorClasses: make(map[string]int),
+			LastSent:     report.LastSent,
+		}
+		if sent {
+			report.LastSent = time.Now()
+		}
+	})
+}
+
+// updateTelemetryReport loads the counters, applies change and saves them,
+// holding telemetryMu throughout so that no count is lost.
+func updateTelemetryReport(change func(report *TelemetryReport)) {
+	telemetryMu.Lock()
+	defer telemetryMu.Unlock()
+	report := readTelemetryReport()
+	change(&report)
+	saveTelemetryReport(report)
+}
+
+func loadTelemetryReport() TelemetryReport {
+	telemetryMu.Lock()
+	defer telemetryMu.Unlock()
+	return readTelemetryReport()
+}
+
+// readTelemetryReport reads the counter file; the caller holds telemetryMu.
+func readTelemetryReport() TelemetryReport {
+	report := TelemetryReport{Since: time.Now()}
+	file, err := ioutil.ReadFile(telemetryFile())
+	if err == nil && len(file) > 0 {
+		// A damaged counter file is simply started over
+		json.Unmarshal(file, &report)
+	}
+	if report.FeatureUsage == nil {
+		report.FeatureUsage = make(map[string]int)
+	}
+	if report.ErrorClasses == nil {
+		report.ErrorClasses = make(map[string]int)
+	}
+	return report
+}
+
+// saveTelemetryReport writes the counters; the caller holds telemetryMu.
+// Telemetry must never break the tool, so write errors are ignored rather
+// than logged (logging would recurse into the error counter).
+func saveTelemetryReport(report TelemetryReport) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return
+	}
+	ioutil.WriteFile(telemetryFile(), data, 0644)
+}
+
+// telemetryFile returns the counter file. It is resolved at startup while
+// telemetry is on; preview and reset also work with it off.
+func telemetryFile() string {
+	if telemetryPath != "" {
+		return telemetryPath
+	}
+	return filepath.Join(getDataDir(), "telemetry.json")
+}
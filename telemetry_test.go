@@ -0,0 +1,37 @@
+package main
+
+import (
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestCountErrorClassConcurrently(t *testing.T) {
+	telemetry = TelemetryConfig{Enabled: true}
+	telemetryPath = filepath.Join(t.TempDir(), "telemetry.json")
+	defer func() { telemetry, telemetryPath = TelemetryConfig{}, "" }()
+
+	const count = 50
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			countErrorClass(slog.LevelError, "Card with ID 'x' not found.")
+		}()
+		go func() {
+			defer wg.Done()
+			recordFeatureUsage("serve")
+		}()
+	}
+	wg.Wait()
+
+	report := loadTelemetryReport()
+	if got := report.ErrorClasses["not_found"]; got != count {
+		t.Errorf("not_found errors = %d, want %d", got, count)
+	}
+	if got := report.FeatureUsage["serve"]; got != count {
+		t.Errorf("serve runs = %d, want %d", got, count)
+	}
+}
@@ -0,0 +1,121 @@
+// template.go
+//
+// Template cards. A card with "variants" is not studied itself; each
+// variant becomes a card of its own, with the {{name}} placeholders in the
+// prompt, solution, pattern and tags filled in:
+//
+//	{
+//	  "id": "fr_conj",
+//	  "prompt": "Conjugate {{verb}} in the {{tense}}, 1st person singular.",
+//	  "solution": "{{answer}}",
+//	  "variants": [
+//	    {"verb": "être", "tense": "present", "answer": "je suis"},
+//	    {"verb": "avoir", "tense": "present", "answer": "j'ai"}
+//	  ]
+//	}
+//
+// Generated IDs are derived from the template ID and the variant's values
+// in key order ("fr_conj:je-suis-present-etre"), or from the variant's own
+// "id" value if it has one, so progress stays attached to the same variant
+// when the list is reordered or extended.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// placeholderPattern matches {{name}} in template cards.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// expandTemplates replaces every template card with the cards generated
+// from its variants.
+func expandTemplates(cards []Card) ([]Card, error) {
+	ids := make(map[string]bool, len(cards))
+	for _, card := range cards {
+		if len(card.Variants) == 0 {
+			ids[card.ID] = true
+		}
+	}
+	expanded := make([]Card, 0, len(cards))
+	for _, card := range cards {
+		if len(card.Variants) == 0 {
+			expanded = append(expanded, card)
+			continue
+		}
+		for i, variant := range card.Variants {
+			generated, err := fillTemplate(card, variant)
+			if err != nil {
+				return nil, fmt.Errorf("card '%s', variant %d: %v", card.ID, i+1, err)
+			}
+			if ids[generated.ID] {
+				return nil, fmt.Errorf("card '%s', variant %d: ID '%s' is already taken; give the variant its own \"id\"", card.ID, i+1, generated.ID)
+			}
+			ids[generated.ID] = true
+			expanded = append(expanded, generated)
+		}
+	}
+	return expanded, nil
+}
+
+// fillTemplate makes the card for one variant of a template card.
+func fillTemplate(template Card, variant map[string]string) (Card, error) {
+	card := template
+	card.Variants = nil
+	card.ID = template.ID + ":" + variantID(variant)
+
+	var missing []string
+	fill := func(text string) string {
+		return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+			name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+			value, ok := variant[name]
+			if !ok {
+				missing = append(missing, name)
+			}
+			return value
+		})
+	}
+	card.Prompt = fill(template.Prompt)
+	card.Solution = fill(template.Solution)
+	card.Pattern = fill(template.Pattern)
+	card.Tags = make([]string, len(template.Tags))
+	for i, tag := range template.Tags {
+		card.Tags[i] = fill(tag)
+	}
+	if len(missing) > 0 {
+		return Card{}, fmt.Errorf("no value for {{%s}}", strings.Join(missing, "}}, {{"))
+	}
+	return card, nil
+}
+
+// --- Helpers ---
+
+// variantID is the part of a generated card's ID that identifies the
+// variant: its "id" value, or its values in key order made into a slug.
+func variantID(variant map[string]string) string {
+	if id := variant["id"]; id != "" {
+		return id
+	}
+	keys := make([]string, 0, len(variant))
+	for key := range variant {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = variant[key]
+	}
+	return slugify(strings.Join(values, " "))
+}
+
+// slugify lowercases s, drops accents and joins its words with dashes.
+func slugify(s string) string {
+	words := strings.FieldsFunc(searchKey(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandTemplates(t *testing.T) {
+	template := Card{
+		ID:       "fr_conj",
+		Prompt:   "Conjugate {{verb}} in the {{ tense }}.",
+		Solution: "{{answer}}",
+		Tags:     []string{"verbs", "{{tense}}"},
+		Variants: []map[string]string{
+			{"verb": "être", "tense": "present", "answer": "je suis"},
+			{"id": "avoir", "verb": "avoir", "tense": "present", "answer": "j'ai"},
+		},
+	}
+	plain := Card{ID: "hello", Prompt: "Hello", Solution: "Bonjour"}
+	cards, err := expandTemplates([]Card{plain, template})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Card{
+		plain,
+		{ID: "fr_conj:je-suis-present-etre", Prompt: "Conjugate être in the present.", Solution: "je suis", Tags: []string{"verbs", "present"}},
+		{ID: "fr_conj:avoir", Prompt: "Conjugate avoir in the present.", Solution: "j'ai", Tags: []string{"verbs", "present"}},
+	}
+	if !reflect.DeepEqual(cards, want) {
+		t.Errorf("expandTemplates =\n%+v\nwant\n%+v", cards, want)
+	}
+}
+
+func TestExpandTemplatesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		cards []Card
+		want  string
+	}{
+		{
+			name:  "missing value",
+			cards: []Card{{ID: "t", Prompt: "{{a}} {{b}} {{c}}", Variants: []map[string]string{{"a": "x"}}}},
+			want:  "card 't', variant 1: no value for {{b}}, {{c}}",
+		},
+		{
+			name: "ID taken by a card",
+			cards: []Card{
+				{ID: "t:x"},
+				{ID: "t", Prompt: "{{a}}", Variants: []map[string]string{{"a": "x"}}},
+			},
+			want: "ID 't:x' is already taken",
+		},
+		{
+			name:  "two variants with one slug",
+			cards: []Card{{ID: "t", Prompt: "{{a}}", Variants: []map[string]string{{"a": "Été"}, {"a": "ete"}}}},
+			want:  "card 't', variant 2: ID 't:ete' is already taken",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := expandTemplates(tt.cards)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"je suis", "je-suis"},
+		{"  Être, avoir!  ", "etre-avoir"},
+		{"j'ai 2 chats", "j-ai-2-chats"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := slugify(tt.s); got != tt.want {
+			t.Errorf("slugify(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// validate.go
+//
+// Answer validation modes. By default answers are compared after
+// normalization; a card can instead ask for an exact match, a regular
+// expression, a number within a tolerance, or a set of words in any order.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Validation modes a card can set in its "validation" field.
+const (
+	ValidationNormalized = "" // default: normalizeString on both sides
+	ValidationExact      = "exact"
+	ValidationRegex      = "regex"
+	ValidationNumeric    = "numeric"
+	ValidationWords      = "words"
+)
+
+func init() {
+	registerChecker(ValidationNormalized, builtinChecker(func(card Card, answer string) bool {
+		return normalizeString(answer, card.normalization) == normalizeString(card.Solution, card.normalization)
+	}))
+	registerChecker(ValidationExact, builtinChecker(func(card Card, answer string) bool {
+		return norm.NFC.String(strings.TrimSpace(answer)) == norm.NFC.String(strings.TrimSpace(card.Solution))
+	}))
+	registerChecker(ValidationRegex, CheckerFunc(func(card Card, answer string) (Verdict, error) {
+		correct, err := matchesPattern(card, answer)
+		return Verdict{Correct: correct}, err
+	}))
+	registerChecker(ValidationNumeric, CheckerFunc(func(card Card, answer string) (Verdict, error) {
+		correct, err := withinTolerance(card, answer)
+		return Verdict{Correct: correct}, err
+	}))
+	registerChecker(ValidationWords, builtinChecker(func(card Card, answer string) bool {
+		return sameWords(answer, card.Solution)
+	}))
+}
+
+// builtinChecker wraps a validation mode that can't fail and gives no
+// feedback as a Checker.
+func builtinChecker(matches func(card Card, answer string) bool) Checker {
+	return CheckerFunc(func(card Card, answer string) (Verdict, error) {
+		return Verdict{Correct: matches(card, answer)}, nil
+	})
+}
+
+// isAnswerCorrect reports whether answer solves card.
+func isAnswerCorrect(card Card, answer string) (bool, error) {
+	verdict, err := checkAnswer(card, answer)
+	return verdict.Correct, err
+}
+
+// matchesPattern checks the whole answer against the card's pattern, or
+// against the solution itself when no separate pattern is given.
+func matchesPattern(card Card, answer string) (bool, error) {
+	pattern := card.Pattern
+	if pattern == "" {
+		pattern = card.Solution
+	}
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return false, invalidCardError{card.ID, fmt.Sprintf("has an invalid pattern: %v", err)}
+	}
+	return re.MatchString(norm.NFC.String(strings.TrimSpace(answer))), nil
+}
+
+// withinTolerance compares answer and solution as numbers. Both "3.14" and
+// "3,14" are accepted.
+func withinTolerance(card Card, answer string) (bool, error) {
+	want, err := parseNumber(card.Solution)
+	if err != nil {
+		return false, invalidCardError{card.ID, fmt.Sprintf("has a non-numeric solution: %v", err)}
+	}
+	got, err := parseNumber(answer)
+	if err != nil {
+		return false, nil
+	}
+	return math.Abs(got-want) <= card.Tolerance, nil
+}
+
+func parseNumber(s string) (float64, error) {
+	// NFKC turns full-width digits into ASCII ones
+	s = strings.TrimSpace(norm.NFKC.String(s))
+	s = strings.ReplaceAll(s, ",", ".")
+	return strconv.ParseFloat(s, 64)
+}
+
+// sameWords reports whether both strings contain the same words, ignoring
+// order, case, Unicode form and punctuation between words.
+func sameWords(answer, solution string) bool {
+	a, b := wordList(answer), wordList(solution)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// wordList splits s into lower-case words and sorts them.
+func wordList(s string) []string {
+	s = norm.NFC.String(cases.Fold().String(norm.NFKC.String(s)))
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == ';'
+	})
+	sort.Strings(words)
+	return words
+}
@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+func TestIsAnswerCorrect(t *testing.T) {
+	tests := []struct {
+		name   string
+		card   Card
+		answer string
+		want   bool
+	}{
+		{"normalized", Card{Solution: "Bonjour"}, "  bonjour ", true},
+		{"normalized, wrong", Card{Solution: "Bonjour"}, "bonsoir", false},
+		{"exact", Card{Solution: "Bonjour", Validation: ValidationExact}, "Bonjour", true},
+		{"exact, case differs", Card{Solution: "Bonjour", Validation: ValidationExact}, "bonjour", false},
+		{"exact, decomposed accent", Card{Solution: "café", Validation: ValidationExact}, "café", true},
+		{"regex on the solution", Card{Solution: "colou?r", Validation: ValidationRegex}, "color", true},
+		{"regex with a pattern", Card{Solution: "grey", Pattern: "gr[ae]y", Validation: ValidationRegex}, "gray", true},
+		{"regex matches the whole answer", Card{Solution: "cat", Validation: ValidationRegex}, "cats", false},
+		{"numeric, comma", Card{Solution: "3.14", Tolerance: 0.01, Validation: ValidationNumeric}, "3,141", true},
+		{"numeric, full-width digits", Card{Solution: "42", Validation: ValidationNumeric}, "４２", true},
+		{"numeric, outside tolerance", Card{Solution: "3.14", Tolerance: 0.001, Validation: ValidationNumeric}, "3.2", false},
+		{"numeric, not a number", Card{Solution: "3.14", Validation: ValidationNumeric}, "pi", false},
+		{"words in any order", Card{Solution: "red, green, blue", Validation: ValidationWords}, "Blue green; RED", true},
+		{"words, one missing", Card{Solution: "red green blue", Validation: ValidationWords}, "red blue", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := isAnswerCorrect(tt.card, tt.answer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("isAnswerCorrect(%q, %q) = %v, want %v", tt.card.Solution, tt.answer, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAnswerCorrectInvalidCard(t *testing.T) {
+	tests := []struct {
+		name string
+		card Card
+	}{
+		{"unknown mode", Card{ID: "c1", Solution: "x", Validation: "fuzzy"}},
+		{"bad pattern", Card{ID: "c1", Solution: "x", Pattern: "(", Validation: ValidationRegex}},
+		{"non-numeric solution", Card{ID: "c1", Solution: "many", Validation: ValidationNumeric}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := isAnswerCorrect(tt.card, "x")
+			if _, ok := err.(invalidCardError); !ok {
+				t.Errorf("error = %v, want an invalid card error", err)
+			}
+		})
+	}
+}
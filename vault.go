@@ -0,0 +1,320 @@
+// vault.go
+//
+// Flashcards kept in a notes folder, such as an Obsidian vault. A card is
+// a fenced block with the language "flashcard" anywhere in a Markdown note:
+//
+//	```flashcard
+//	language: french
+//	tags: animals
+//	the cat
+//	---
+//	le chat
+//	```
+//
+// Optional id, language and tags lines come first, then the prompt, a ---
+// line and the solution. The watch command scans the folder, gives every
+// block without an id one made from a hash of its content and writes it
+// into the note, so the card keeps its id, and its progress, when the note
+// is edited or the block moved to another note. The cards found are written
+// to vault-cards.json in the data directory, which is read together with
+// the deck; blocks that are deleted take their cards out of the deck, while
+// their progress stays in progress.json as for any card removed from the
+// deck.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// VaultConfig is the "vault" block of config.json.
+type VaultConfig struct {
+	// Folder is the notes folder to scan; "~/" is the home directory.
+	Folder string `json:"folder,omitempty"`
+	// Language is used for blocks that don't name one (default "notes").
+	Language string `json:"language,omitempty"`
+}
+
+// --- Command Handlers ---
+
+func handleWatch(folder string, once bool) {
+	config := loadConfig().Vault
+	if folder == "" {
+		folder = config.Folder
+	}
+	if folder == "" {
+		fatal("Give --folder or set vault.folder in config.json.")
+	}
+	folder = expandHome(folder)
+	if info, err := os.Stat(folder); err != nil || !info.IsDir() {
+		fatalf("Notes folder not found at %s.", folder)
+	}
+	language := config.Language
+	if language == "" {
+		language = "notes"
+	}
+
+	if once {
+		syncVault(folder, language)
+		return
+	}
+	fmt.Printf(tr("Watching %s for flashcards; press Ctrl+C to stop.\n"), folder)
+	var known, seen map[string]fileStamp
+	for ; ; time.Sleep(watchInterval) {
+		stamps := vaultStamps(folder)
+		// Wait for a poll without changes, so notes being saved aren't read
+		// half-written
+		if reflect.DeepEqual(stamps, known) {
+			continue
+		}
+		if !reflect.DeepEqual(stamps, seen) && known != nil {
+			seen = stamps
+			continue
+		}
+		syncVault(folder, language)
+		// Writing ids into notes changes them, which needs no second sync
+		known, seen = vaultStamps(folder), nil
+	}
+}
+
+// syncVault scans the notes folder once and updates vault-cards.json.
+func syncVault(folder, language string) {
+	var cards []Card
+	ids := make(map[string]bool)
+	filepath.WalkDir(folder, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			warnf("can't read %s: %v", path, err)
+			return nil
+		}
+		if entry.IsDir() {
+			// Hidden folders such as .obsidian and .git hold no notes
+			if path != folder && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isMarkdownDeck(path) {
+			return nil
+		}
+		cards = append(cards, scanNote(folder, path, language, ids)...)
+		return nil
+	})
+
+	previous := make(map[string]Card)
+	for _, card := range readVaultCards() {
+		previous[card.ID] = card
+	}
+	deck := Deck{Version: deckFormat, Cards: make([]Card, len(cards))}
+	added, updated := 0, 0
+	for i, card := range cards {
+		deck.Cards[i] = card
+		old, ok := previous[card.ID]
+		switch {
+		case !ok:
+			added++
+		case !reflect.DeepEqual(old, card):
+			updated++
+		}
+		delete(previous, card.ID)
+	}
+	removed := len(previous)
+
+	data, err := json.MarshalIndent(deck, "", "  ")
+	if err != nil {
+		fatalf("Error marshalling vault cards to JSON: %v", err)
+	}
+	data = append(data, '\n')
+	filePath := vaultCardsPath()
+	if existing, err := ioutil.ReadFile(filePath); err != nil || !bytes.Equal(existing, data) {
+		if err := ioutil.WriteFile(filePath, data, 0644); err != nil {
+			fatalf("Error writing vault cards (%s): %v", filePath, err)
+		}
+	}
+	fmt.Printf(tr("%s  %d card(s) in %s: %d added, %d updated, %d removed.\n"), time.Now().Format("15:04:05"), len(cards), folder, added, updated, removed)
+}
+
+// scanNote reads the flashcard blocks of one note and writes ids into the
+// blocks that have none. ids holds the ids seen so far; a block copied
+// with its id gets a new one.
+func scanNote(folder, path, language string, ids map[string]bool) []Card {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		warnf("can't read %s: %v", path, err)
+		return nil
+	}
+	rel, _ := filepath.Rel(folder, path)
+	name := slugify(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	lines := strings.Split(string(data), "\n")
+
+	var cards []Card
+	var out []string
+	changed := false
+	for i := 0; i < len(lines); i++ {
+		out = append(out, lines[i])
+		fence, ok := flashcardFence(lines[i])
+		if !ok {
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), fence) {
+			end++
+		}
+		if end == len(lines) {
+			warnf("%s:%d: the flashcard block is not closed", rel, i+1)
+			out = append(out, lines[i+1:]...)
+			break
+		}
+
+		block := slices.Clone(lines[i+1 : end])
+		card, idLine, ok := parseFlashcard(block)
+		if !ok {
+			warnf("%s:%d: a flashcard block needs a prompt, a --- line and a solution", rel, i+1)
+		} else {
+			if card.Language == "" {
+				card.Language = language
+			}
+			card.Tags = append([]string{"vault", name}, card.Tags...)
+			if card.ID == "" || ids[card.ID] {
+				if card.ID = vaultID(rel, card); ids[card.ID] {
+					card.ID = vaultID(fmt.Sprintf("%s:%d", rel, i), card)
+				}
+				if idLine >= 0 {
+					block[idLine] = "id: " + card.ID
+				} else {
+					block = append([]string{"id: " + card.ID}, block...)
+				}
+				changed = true
+			}
+			ids[card.ID] = true
+			cards = append(cards, card)
+		}
+		out = append(append(out, block...), lines[end])
+		i = end
+	}
+
+	if changed {
+		// Only the id lines were added; everything else is written back as read
+		if err := ioutil.WriteFile(path, []byte(strings.Join(out, "\n")), 0644); err != nil {
+			warnf("can't write ids into %s: %v", path, err)
+		}
+	}
+	return cards
+}
+
+// parseFlashcard reads the lines between the fences of a block. It also
+// returns the index of the id line, or -1.
+func parseFlashcard(lines []string) (Card, int, bool) {
+	card, idLine := Card{}, -1
+	i := 0
+header:
+	for ; i < len(lines); i++ {
+		key, value, ok := strings.Cut(lines[i], ":")
+		if !ok {
+			break
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "id":
+			card.ID, idLine = value, i
+		case "language":
+			card.Language = value
+		case "tags":
+			card.Tags = splitMarkdownTags(value)
+		default:
+			break header
+		}
+	}
+
+	prompt, solution, found := []string{}, []string{}, false
+	for _, line := range lines[i:] {
+		switch {
+		case !found && strings.TrimSpace(line) == "---":
+			found = true
+		case found:
+			solution = append(solution, line)
+		default:
+			prompt = append(prompt, line)
+		}
+	}
+	card.Prompt = strings.TrimSpace(strings.Join(prompt, "\n"))
+	card.Solution = strings.TrimSpace(strings.Join(solution, "\n"))
+	return card, idLine, found && card.Prompt != "" && card.Solution != ""
+}
+
+// --- Helpers ---
+
+// readVaultCards returns the cards last synced from the notes folder.
+func readVaultCards() []Card {
+	data, err := ioutil.ReadFile(vaultCardsPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		fatalf("Error reading vault cards (%s): %v", vaultCardsPath(), err)
+	}
+	var deck Deck
+	if err := json.Unmarshal(data, &deck); err != nil {
+		fatalf("Error unmarshalling vault cards JSON: %v", err)
+	}
+	return deck.Cards
+}
+
+func vaultCardsPath() string {
+	return filepath.Join(getDataDir(), "vault-cards.json")
+}
+
+// vaultStamps returns the stamps of the notes in folder.
+func vaultStamps(folder string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	filepath.WalkDir(folder, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.IsDir() && path != folder && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if err == nil && !entry.IsDir() && isMarkdownDeck(path) {
+			stamps[path] = statFile(path)
+		}
+		return nil
+	})
+	return stamps
+}
+
+// flashcardFence returns the fence that closes a block opened by line, if
+// line opens a flashcard block.
+func flashcardFence(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	for _, fence := range []string{"```", "~~~"} {
+		if info, ok := strings.CutPrefix(trimmed, fence); ok && strings.TrimSpace(info) == "flashcard" {
+			return fence, true
+		}
+	}
+	return "", false
+}
+
+// vaultID is the id for a new block: a hash of where it was found and what
+// it says, so it doesn't change between scans until it is written down.
+func vaultID(where string, card Card) string {
+	sum := sha1.Sum([]byte(where + "\n" + card.Prompt + "\n" + card.Solution))
+	return "note-" + hex.EncodeToString(sum[:])[:10]
+}
+
+// expandHome replaces a leading "~/" with the home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
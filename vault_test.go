@@ -0,0 +1,134 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFlashcard(t *testing.T) {
+	tests := []struct {
+		name   string
+		block  string
+		want   Card
+		idLine int
+		ok     bool
+	}{
+		{
+			name:   "plain",
+			block:  "the cat\n---\nle chat",
+			want:   Card{Prompt: "the cat", Solution: "le chat"},
+			idLine: -1,
+			ok:     true,
+		},
+		{
+			name:   "header lines",
+			block:  "id: note-1\nLanguage: french\ntags: animals, pets\nthe cat\n---\nle chat",
+			want:   Card{ID: "note-1", Language: "french", Tags: []string{"animals", "pets"}, Prompt: "the cat", Solution: "le chat"},
+			idLine: 0,
+			ok:     true,
+		},
+		{
+			name:   "prompt with a colon",
+			block:  "Translate: the cat\n---\nle chat",
+			want:   Card{Prompt: "Translate: the cat", Solution: "le chat"},
+			idLine: -1,
+			ok:     true,
+		},
+		{
+			name:   "only the first --- separates",
+			block:  "a rule\n---\nabove\n---\nbelow",
+			want:   Card{Prompt: "a rule", Solution: "above\n---\nbelow"},
+			idLine: -1,
+			ok:     true,
+		},
+		{
+			name:   "no separator",
+			block:  "the cat\nle chat",
+			want:   Card{Prompt: "the cat\nle chat"},
+			idLine: -1,
+		},
+		{
+			name:   "no solution",
+			block:  "id: x\nthe cat\n---\n",
+			want:   Card{ID: "x", Prompt: "the cat"},
+			idLine: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card, idLine, ok := parseFlashcard(strings.Split(tt.block, "\n"))
+			if !reflect.DeepEqual(card, tt.want) || idLine != tt.idLine || ok != tt.ok {
+				t.Errorf("parseFlashcard = %+v, %d, %v; want %+v, %d, %v", card, idLine, ok, tt.want, tt.idLine, tt.ok)
+			}
+		})
+	}
+}
+
+func TestFlashcardFence(t *testing.T) {
+	tests := []struct {
+		line  string
+		fence string
+		ok    bool
+	}{
+		{"```flashcard", "```", true},
+		{"  ~~~ flashcard ", "~~~", true},
+		{"```go", "", false},
+		{"```", "", false},
+		{"flashcard", "", false},
+	}
+	for _, tt := range tests {
+		if fence, ok := flashcardFence(tt.line); fence != tt.fence || ok != tt.ok {
+			t.Errorf("flashcardFence(%q) = %q, %v; want %q, %v", tt.line, fence, ok, tt.fence, tt.ok)
+		}
+	}
+}
+
+func TestScanNote(t *testing.T) {
+	folder := t.TempDir()
+	path := filepath.Join(folder, "Animaux.md")
+	note := "# Animals\n\n```flashcard\nthe cat\n---\nle chat\n```\n\nSome text.\n\n" +
+		"~~~flashcard\nid: kept\nlanguage: french\nthe dog\n---\nle chien\n~~~\n\n" +
+		"```flashcard\nbroken\n```\n"
+	if err := ioutil.WriteFile(path, []byte(note), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := map[string]bool{}
+	cards := scanNote(folder, path, "notes", ids)
+	if len(cards) != 2 {
+		t.Fatalf("scanNote found %d cards, want 2: %+v", len(cards), cards)
+	}
+	cat, dog := cards[0], cards[1]
+	if !strings.HasPrefix(cat.ID, "note-") || cat.Language != "notes" || !reflect.DeepEqual(cat.Tags, []string{"vault", "animaux"}) {
+		t.Errorf("first card = %+v, want a new note- id, the default language and the note's tags", cat)
+	}
+	if dog.ID != "kept" || dog.Language != "french" {
+		t.Errorf("second card = %+v, want its own id and language", dog)
+	}
+
+	// The new id is written into the note and nothing else changes
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Replace(note, "```flashcard\nthe cat", "```flashcard\nid: "+cat.ID+"\nthe cat", 1)
+	if string(data) != want {
+		t.Errorf("note after the scan =\n%s\nwant\n%s", data, want)
+	}
+	again := scanNote(folder, path, "notes", map[string]bool{})
+	if !reflect.DeepEqual(again, cards) {
+		t.Errorf("second scan = %+v, want %+v", again, cards)
+	}
+
+	// A block copied with its id into another note gets a new one
+	copied := filepath.Join(folder, "copy.md")
+	if err := ioutil.WriteFile(copied, []byte("~~~flashcard\nid: kept\nthe dog\n---\nle chien\n~~~\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cards := scanNote(folder, copied, "notes", ids); len(cards) != 1 || cards[0].ID == "kept" {
+		t.Errorf("copied block = %+v, want one card with a new id", cards)
+	}
+}
@@ -0,0 +1,125 @@
+// webhooks.go
+//
+// Webhook notifications. Every URL in the "webhooks" list of config.json
+// receives a POST for the events it asks for, by default every milestone
+// (a card reaching box 5 or retiring, daily streaks, answer counts,
+// achievements). The body carries the message both as "content" and as
+// "text", which is what Discord and Slack incoming webhooks read, and the
+// event itself for anything else.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// webhookTimeout bounds each delivery, so a slow endpoint doesn't hold up
+// check-answer for long.
+const webhookTimeout = 5 * time.Second
+
+// Webhook is an entry of the "webhooks" list in config.json.
+type Webhook struct {
+	URL string `json:"url"`
+	// Events lists event types ("answer", "session_end", "milestone") or
+	// single milestones ("milestone:box_5") to send. Empty means every
+	// milestone.
+	Events []string `json:"events,omitempty"`
+}
+
+// WebhookPayload is the JSON body sent to a webhook.
+type WebhookPayload struct {
+	Content string `json:"content"`
+	Text    string `json:"text"`
+	Event   Event  `json:"event"`
+}
+
+func init() {
+	subscribeEvents(sendWebhooks)
+}
+
+// wants reports whether the webhook asked for event.
+func (w Webhook) wants(event Event) bool {
+	if len(w.Events) == 0 {
+		return event.Type == EventMilestone
+	}
+	for _, name := range w.Events {
+		if name == event.Type || (event.Type == EventMilestone && name == "milestone:"+fmt.Sprint(event.Data["milestone"])) {
+			return true
+		}
+	}
+	return false
+}
+
+// sendWebhooks delivers an event to every webhook that wants it.
+func sendWebhooks(event Event) {
+	var targets []Webhook
+	for _, webhook := range loadConfig().Webhooks {
+		if webhook.wants(event) {
+			targets = append(targets, webhook)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	player := event.PlayerID
+	if name := readAllProgress()[event.PlayerID].Name; name != "" {
+		player = name
+	}
+	message := describeEvent(event, player)
+	body, err := json.Marshal(WebhookPayload{Content: message, Text: message, Event: event})
+	if err != nil {
+		warnf("could not encode webhook payload: %v", err)
+		return
+	}
+	client := &http.Client{Timeout: webhookTimeout}
+	for _, webhook := range targets {
+		resp, err := client.Post(webhook.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			warnf("webhook %s failed: %v", webhook.URL, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			warnf("webhook %s answered %s", webhook.URL, resp.Status)
+		}
+	}
+}
+
+// describeEvent is the chat message for an event.
+func describeEvent(event Event, player string) string {
+	data := event.Data
+	switch event.Type {
+	case EventAnswer:
+		if data["correct"] == true {
+			return fmt.Sprintf("%s answered card %v correctly.", player, data["card_id"])
+		}
+		return fmt.Sprintf("%s got card %v wrong.", player, data["card_id"])
+	case EventSessionEnd:
+		return fmt.Sprintf("%s finished a %v session.", player, data["mode"])
+	case EventMilestone:
+	default:
+		return fmt.Sprintf("%s: %s", player, event.Type)
+	}
+	switch data["milestone"] {
+	case "box_5":
+		return fmt.Sprintf("%s moved card %v to box 5.", player, data["card_id"])
+	case "card_mastered":
+		return fmt.Sprintf("%s mastered card %v; it is retired.", player, data["card_id"])
+	case "daily_streak":
+		return fmt.Sprintf("%s is on a %v-day streak!", player, data["days"])
+	case "total_answered":
+		return fmt.Sprintf("%s has answered %v cards.", player, data["count"])
+	case "daily_goal":
+		return fmt.Sprintf("%s reached their daily goal of %v reviews.", player, data["reviews"])
+	case "weekly_goal":
+		return fmt.Sprintf("%s reached their weekly goal of %v new cards.", player, data["new_cards"])
+	case "achievement":
+		return fmt.Sprintf("%s earned the achievement %v.", player, data["name"])
+	}
+	return fmt.Sprintf("%s reached the milestone %v.", player, data["milestone"])
+}
@@ -0,0 +1,155 @@
+// writing.go
+//
+// Spaced writing practice. Instead of recalling a solution, the player
+// writes a short sentence that uses it. Sentences are kept and come back
+// for self-review after a few days; each time the player still agrees with
+// what they wrote the next review moves further out, as in the boxes.
+//
+// If config.json names a grader ("writing": {"grader": "my-grader"}), every
+// new sentence is also sent to that checker (see checker.go) and its verdict
+// and feedback are stored with the sentence.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// firstWritingReview is the interval before a new sentence is reviewed.
+const firstWritingReview = 3
+
+// WritingConfig is the "writing" block of config.json.
+type WritingConfig struct {
+	// Grader names a checker that grades new sentences.
+	Grader string `json:"grader,omitempty"`
+}
+
+// WritingEntry is a sentence a player wrote for a card.
+type WritingEntry struct {
+	ID        string    `json:"id"`
+	CardID    string    `json:"card_id"`
+	Sentence  string    `json:"sentence"`
+	WrittenAt time.Time `json:"written_at"`
+	// ReviewAt is when the sentence resurfaces; IntervalDays is the gap
+	// that led there and doubles with every confirming review.
+	ReviewAt     time.Time `json:"review_at"`
+	IntervalDays int       `json:"interval_days"`
+	Reviews      int       `json:"reviews,omitempty"`
+	// Grade is set when a grader was configured.
+	Grade *Verdict `json:"grade,omitempty"`
+}
+
+// --- Command Handlers ---
+
+func handleWriting(playerID string, count int) {
+	cards := loadCards()
+	allProgress := loadAllProgress()
+	player, ok := allProgress[playerID]
+	if !ok {
+		fatalf("Player with ID '%s' not found.", playerID)
+	}
+	cardsByID := make(map[string]Card, len(cards))
+	for _, card := range cards {
+		cardsByID[card.ID] = card
+	}
+	loc := resolveLocale(player.Locale)
+	reader := bufio.NewReader(os.Stdin)
+	now := time.Now()
+
+	// Old sentences that are due come first
+	reviewed := 0
+	for i, entry := range player.Writing {
+		if entry.ReviewAt.After(now) {
+			continue
+		}
+		card, ok := cardsByID[entry.CardID]
+		if !ok {
+			continue
+		}
+		if reviewed == 0 {
+			fmt.Println(tr("Time to look at some of your sentences again."))
+		}
+		reviewed++
+		fmt.Printf(tr("\nOn %s you wrote for \"%s\":\n  %s\n"), loc.Date(entry.WrittenAt), card.Solution, entry.Sentence)
+		fmt.Print(tr("Still happy with it? Enter y, n, or type a better sentence.\n> "))
+		answer, ok := readAnswer(reader)
+		if !ok {
+			break
+		}
+		entry.Reviews++
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes", "":
+			entry.IntervalDays *= 2
+		case "n", "no":
+			entry.IntervalDays = 1
+		default:
+			entry.Sentence = strings.TrimSpace(typedAnswer(card.Language, answer))
+			entry.IntervalDays = 1
+		}
+		entry.ReviewAt = now.AddDate(0, 0, entry.IntervalDays)
+		player.Writing[i] = entry
+	}
+
+	config := loadConfig()
+	rng := newRand(time.Now().UnixNano())
+	var recent []string
+	for written := 0; written < count; written++ {
+		card, _, ok := selectCard(cards, player, recent, config.Scheduler, now, rng)
+		if !ok {
+			fmt.Println(tr("No cards left to write about."))
+			break
+		}
+		recent = append(recent, card.ID)
+
+		fmt.Printf(tr("\nWrite a short sentence using \"%s\" (%s)\n> "), card.Solution, card.Prompt)
+		sentence, ok := readAnswer(reader)
+		if !ok {
+			break
+		}
+		sentence = strings.TrimSpace(typedAnswer(card.Language, sentence))
+		if sentence == "" {
+			fmt.Println(tr("Skipped."))
+			continue
+		}
+		if !strings.Contains(strings.ToLower(sentence), strings.ToLower(strings.TrimSpace(card.Solution))) {
+			fmt.Printf(tr("Note: your sentence doesn't contain \"%s\" as written.\n"), card.Solution)
+		}
+
+		entry := WritingEntry{
+			ID:           generateUniqueID(),
+			CardID:       card.ID,
+			Sentence:     sentence,
+			WrittenAt:    now,
+			ReviewAt:     now.AddDate(0, 0, firstWritingReview),
+			IntervalDays: firstWritingReview,
+		}
+		if config.Writing.Grader != "" {
+			graded := card
+			graded.Checker = config.Writing.Grader
+			verdict, err := checkAnswer(graded, sentence)
+			switch {
+			case err != nil:
+				// The sentence is kept ungraded
+				warnf("Could not grade the sentence: %v", err)
+			case verdict.Correct:
+				entry.Grade = &verdict
+				fmt.Println(tr("✅ The grader is happy with it."))
+			default:
+				entry.Grade = &verdict
+				fmt.Println(tr("❌ The grader found a problem."))
+			}
+			if verdict.Feedback != "" {
+				fmt.Println(verdict.Feedback)
+			}
+		}
+		player.Writing = append(player.Writing, entry)
+	}
+
+	allProgress[playerID] = player
+	saveAllProgress(allProgress)
+	fmt.Printf(tr("\n%s sentence(s) kept for later review.\n"), loc.Number(len(player.Writing)))
+}